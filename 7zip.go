@@ -5,7 +5,10 @@ package extract
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"hash"
+	"hash/crc32"
 	"io"
 	"io/fs"
 	"os"
@@ -73,19 +76,71 @@ func process7zip(ctx context.Context, t Target, dst string, sra seekerReaderAt,
 		return handleError(cfg, td, "cannot unarchive 7zip", fmt.Errorf("input size exceeds maximum input size"))
 	}
 
-	// create zip reader and extract
-	reader, err := sevenzip.NewReader(ra, size)
+	// create 7zip reader and extract
+	password, hasPassword, err := cfg.archivePassword()
 	if err != nil {
-		return handleError(cfg, td, "cannot create 7zip reader", err)
+		return handleError(cfg, td, "cannot get password", err)
+	}
+	reader, err := sevenzip.NewReaderWithPassword(ra, size, password)
+	if err != nil {
+		return handleError(cfg, td, "cannot create 7zip reader", sevenZipError(err, hasPassword))
 	}
 
-	return extract(ctx, t, dst, &sevenZipWalker{reader, 0}, cfg, td)
+	return extract(ctx, t, dst, &sevenZipWalker{r: reader, hasPassword: hasPassword}, cfg, td)
+}
+
+// walk7Zip returns a Walker for the 7zip archive in src. If src is not a
+// readerAt and a seeker, src is cached according to the configuration.
+func walk7Zip(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	sra, cleanup, err := cacheInput(cfg, src)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert reader to readerAt and seeker: %w", err)
+	}
+
+	// check input size
+	size, err := checkInputSize(cfg, sra)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	// create 7zip reader
+	password, hasPassword, err := cfg.archivePassword()
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("cannot get password: %w", err)
+	}
+	reader, err := sevenzip.NewReaderWithPassword(sra, size, password)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("cannot create 7zip reader: %w", sevenZipError(err, hasPassword))
+	}
+	return &sevenZipWalker{r: reader, hasPassword: hasPassword, cleanup: cleanup}, nil
+}
+
+// sevenZipError wraps err with a password error, if err is caused by encrypted content.
+func sevenZipError(err error, hasPassword bool) error {
+	var re *sevenzip.ReadError
+	if errors.As(err, &re) && re.Encrypted {
+		return passwordError(err, hasPassword)
+	}
+	return err
 }
 
 // sevenZipWalker is a walker for 7zip files
 type sevenZipWalker struct {
-	r  *sevenzip.Reader
-	fp int
+	r           *sevenzip.Reader
+	hasPassword bool
+	fp          int
+	cleanup     func()
+}
+
+// Close removes the cached input, if any.
+func (z *sevenZipWalker) Close() error {
+	if z.cleanup != nil {
+		z.cleanup()
+	}
+	return nil
 }
 
 // Type returns the file extension for 7zip files
@@ -94,17 +149,18 @@ func (z sevenZipWalker) Type() string {
 }
 
 // Next returns the next entry in the 7zip file
-func (z *sevenZipWalker) Next() (archiveEntry, error) {
+func (z *sevenZipWalker) Next() (Entry, error) {
 	if z.fp >= len(z.r.File) {
 		return nil, io.EOF
 	}
 	defer func() { z.fp++ }()
-	return &sevenZipEntry{z.r.File[z.fp]}, nil
+	return &sevenZipEntry{f: z.r.File[z.fp], hasPassword: z.hasPassword}, nil
 }
 
 // sevenZipEntry is an entry in a 7zip file
 type sevenZipEntry struct {
-	f *sevenzip.File
+	f           *sevenzip.File
+	hasPassword bool
 }
 
 // Name returns the name of the 7zip entry
@@ -127,7 +183,7 @@ func (z *sevenZipEntry) Linkname() string {
 	if !z.IsSymlink() {
 		return ""
 	}
-	f, err := z.f.Open()
+	f, err := z.Open()
 	if err != nil {
 		return ""
 	}
@@ -149,15 +205,57 @@ func (z *sevenZipEntry) IsDir() bool {
 	return z.f.FileInfo().Mode().IsDir()
 }
 
+// IsHardlink returns always false, because hard links are not supported for 7zip files
+func (z *sevenZipEntry) IsHardlink() bool {
+	return false
+}
+
 // IsSymlink returns true if the 7zip entry is a symlink
 // Remark: 7zip does not support symlinks
 func (z *sevenZipEntry) IsSymlink() bool {
 	return (z.f.FileInfo().Mode()&os.ModeSymlink != 0)
 }
 
-// Open returns a reader for the 7zip entry
+// Open returns a reader for the 7zip entry, which verifies the crc of the content.
 func (z *sevenZipEntry) Open() (io.ReadCloser, error) {
-	return z.f.Open()
+	rc, err := z.f.Open()
+	if err != nil {
+		return nil, sevenZipError(err, z.hasPassword)
+	}
+	return &sevenZipReader{rc: rc, hash: crc32.NewIEEE(), crc: z.f.CRC32, hasPassword: z.hasPassword}, nil
+}
+
+// sevenZipReader verifies the crc of the content of a 7zip entry and wraps read errors
+// of encrypted content with a password error.
+type sevenZipReader struct {
+	rc          io.ReadCloser
+	hash        hash.Hash32
+	crc         uint32
+	hasPassword bool
+}
+
+// Read reads the content of the entry. The crc is verified after the content is read
+// completely. Because the encryption of 7zip archives does not verify the password, a
+// crc mismatch of an archive opened with a password is reported as [ErrWrongPassword]
+// and [ErrCorruptData].
+func (r *sevenZipReader) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF && r.crc != 0 && r.hash.Sum32() != r.crc {
+		if r.hasPassword {
+			return n, fmt.Errorf("%w or %w: checksum mismatch", ErrWrongPassword, ErrCorruptData)
+		}
+		return n, fmt.Errorf("%w: checksum mismatch", ErrCorruptData)
+	}
+	if err != nil && err != io.EOF {
+		return n, sevenZipError(err, r.hasPassword)
+	}
+	return n, err
+}
+
+// Close closes the reader of the entry
+func (r *sevenZipReader) Close() error {
+	return r.rc.Close()
 }
 
 // Type returns the type of the 7zip entry
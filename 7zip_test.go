@@ -0,0 +1,126 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestUnpackEncrypted7zip(t *testing.T) {
+	tests := []struct {
+		name        string
+		src         []byte
+		cfg         *extract.Config
+		expectedErr error
+	}{
+		{
+			name: "encrypted header",
+			src:  packEncrypted7z(t, "header"),
+			cfg:  extract.NewConfig(extract.WithPassword("password")),
+		},
+		{
+			name: "encrypted compressed files",
+			src:  packEncrypted7z(t, "compressed"),
+			cfg:  extract.NewConfig(extract.WithPassword("password")),
+		},
+		{
+			name: "encrypted uncompressed files",
+			src:  packEncrypted7z(t, "uncompressed"),
+			cfg:  extract.NewConfig(extract.WithPassword("password")),
+		},
+		{
+			name: "password provider",
+			src:  packEncrypted7z(t, "header"),
+			cfg: extract.NewConfig(extract.WithPasswordProvider(func(entryName string) (string, error) {
+				if entryName != "" {
+					t.Errorf("expected empty entry name, got %q", entryName)
+				}
+				return "password", nil
+			})),
+		},
+		{
+			name:        "password provider error",
+			src:         packEncrypted7z(t, "header"),
+			cfg:         extract.NewConfig(extract.WithPasswordProvider(func(string) (string, error) { return "", errCancelled })),
+			expectedErr: errCancelled,
+		},
+		{
+			name:        "encrypted header without password",
+			src:         packEncrypted7z(t, "header"),
+			expectedErr: extract.ErrPasswordRequired,
+		},
+		{
+			name:        "encrypted header with wrong password",
+			src:         packEncrypted7z(t, "header"),
+			cfg:         extract.NewConfig(extract.WithPassword("wrong")),
+			expectedErr: extract.ErrWrongPassword,
+		},
+		{
+			name:        "encrypted compressed files without password",
+			src:         packEncrypted7z(t, "compressed"),
+			expectedErr: extract.ErrPasswordRequired,
+		},
+		{
+			name:        "encrypted compressed files with wrong password",
+			src:         packEncrypted7z(t, "compressed"),
+			cfg:         extract.NewConfig(extract.WithPassword("wrong")),
+			expectedErr: extract.ErrWrongPassword,
+		},
+		{
+			name:        "encrypted uncompressed files without password",
+			src:         packEncrypted7z(t, "uncompressed"),
+			expectedErr: extract.ErrCorruptData,
+		},
+		{
+			name:        "encrypted uncompressed files with wrong password",
+			src:         packEncrypted7z(t, "uncompressed"),
+			cfg:         extract.NewConfig(extract.WithPassword("wrong")),
+			expectedErr: extract.ErrWrongPassword,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			if cfg == nil {
+				cfg = extract.NewConfig()
+			}
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackTo(context.Background(), tm, "", asIoReader(t, tc.src), cfg)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkMemoryContent(t, tm, map[string]string{"foo": "foo\n", "bar": "bar\n"})
+		})
+	}
+}
+
+// packEncrypted7z returns always the same 7z archive, which is encrypted with the
+// password "password" and contains the files foo ("foo\n") and bar ("bar\n"). The
+// variant selects, if the header is encrypted (header), or only the compressed
+// (compressed) or uncompressed (uncompressed) files.
+func packEncrypted7z(t *testing.T, variant string) []byte {
+	t.Helper()
+	archives := map[string]string{
+		"header":       "377abcaf271c00041f4171c7c0000000000000002800000000000000b9fb275aa044c1456ad5f47b9bf189836409cd1b38e4d49af8be29ab6aaed50046d843ea2936990d9181d33c0371f1b578aece6518ab9dce9bc5fab04de467e0356ebd9896fda7be1deb50c71681fd62f8c0bc51791c02241999ee97a2933bd66f8e78d96ef257c56242a9fc403848a34e55db629da6278f463aa51250261453551597f578129cdddf774b05c0a9a7747ee2d1631d3348110b863a738c12b61664575925947bd8be07089e84a75de7d180a055690df8902642ab4c68da0f74f8949ba0ef170620010980a000070b0100012406f107010a5307f4c1ea750f99e7630c80960a01f0f04c3b0000",
+		"compressed":   "377abcaf271c0004193f0abf7d00000000000000200000000000000037e9d2ecbfc858e415d69d6d3aba10349f48e6930000813307ae0fcef2b20c07b0c3daf75f458a97538229519801100212d33d249679dc0d4cbb35a48140bac19b5cfa610eefcb252344346e39110f7dd205f61fc6966c9a94bedb715a3a34927812194c75bb1a1d7cfba5ef6765e3ea99bb303f98e756c10e0fddf8048100000017061001096d00070b01000123030101055d001000000c760a015687ca730000",
+		"uncompressed": "377abcaf271c00040945bef698000000000000002100000000000000453f208be39c9b0a1296dab6396f1a4f4131b8fd8ae29a755e66f4f836e93c67460ee8e30000813307ae319962099015d61e21a57b112f16a40c006dbb5333c1ad0d362247bfd3860d5234031bfe892972db3420a749af5ece7d675418d5a783f7d45f9e3d5f64ef1dd36ac9f25ae517216c56a608ecfdaf64eb8d9eb3b0c63a4b64cb466d45d91f57071ab675b7fd4a1f7061557b6981db5500000017062001097800070b01000123030101055d001000000c80960a0126562a890000",
+	}
+	b, err := hex.DecodeString(archives[variant])
+	if err != nil {
+		t.Fatalf("error decoding 7z data: %v", err)
+	}
+	return b
+}
@@ -0,0 +1,327 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileExtensionAr is the file extension for ar archives
+const fileExtensionAr = "ar"
+
+// magicBytesAr are the magic bytes for ar archives
+//
+// https://man.freebsd.org/cgi/man.cgi?query=ar&sektion=5
+var magicBytesAr = [][]byte{
+	[]byte("!<arch>\n"),
+}
+
+const (
+	// arHeaderLength is the length of the header of each member in an ar archive
+	arHeaderLength = 60
+
+	// arMaxNameTableSize is the maximum size of the GNU long name table
+	arMaxNameTableSize = 1 << 20 // 1 MiB
+)
+
+// isAr checks if the header matches the magic bytes for ar archives. Debian packages
+// are excluded, because they are handled by the deb extractor.
+func isAr(header []byte) bool {
+	return matchesMagicBytes(header, 0, magicBytesAr) && !isDeb(header)
+}
+
+// unpackAr sets a timeout for the ctx and starts the ar extraction from src to dst.
+func unpackAr(ctx context.Context, t Target, dst string, src io.Reader, cfg *Config) error {
+	// prepare telemetry capturing
+	td := &TelemetryData{ExtractedType: fileExtensionAr}
+	defer cfg.TelemetryHook()(ctx, td)
+	defer captureExtractionDuration(td, now())
+
+	// prepare reader
+	limitedReader := newLimitErrorReader(src, cfg.MaxInputSize())
+	defer captureInputSize(td, limitedReader)
+
+	// start extraction
+	return extract(ctx, t, dst, &arWalker{r: limitedReader}, cfg.withCompressedInput(consumedInput(limitedReader)), td)
+}
+
+// walkAr returns a Walker for the ar archive in src.
+func walkAr(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return &arWalker{r: newLimitErrorReader(src, cfg.MaxInputSize())}, nil
+}
+
+// arHeader is the decoded header of a member in an ar archive
+type arHeader struct {
+	Name    string
+	ModTime time.Time
+	Uid     int
+	Gid     int
+	Mode    fs.FileMode
+	Size    int64
+}
+
+// arWalker is a walker for ar archives in the common, GNU and BSD variant
+type arWalker struct {
+	r io.Reader
+
+	// data is the content of the current member
+	data *io.LimitedReader
+
+	// padding is the number of bytes after the content of the current member
+	padding int64
+
+	// names is the GNU long name table
+	names []byte
+
+	// started is true, if the global header has been read
+	started bool
+}
+
+// Type returns the file extension for ar archives
+func (a *arWalker) Type() string {
+	return fileExtensionAr
+}
+
+// Next returns the next member in the ar archive
+func (a *arWalker) Next() (Entry, error) {
+	if !a.started {
+		magic := make([]byte, len(magicBytesAr[0]))
+		if _, err := io.ReadFull(a.r, magic); err != nil {
+			return nil, fmt.Errorf("ar: cannot read header: %w", err)
+		}
+		if !bytes.Equal(magic, magicBytesAr[0]) {
+			return nil, fmt.Errorf("ar: invalid magic bytes")
+		}
+		a.started = true
+	}
+
+	for {
+		// skip unread content of the previous member
+		if err := a.skipData(); err != nil {
+			return nil, err
+		}
+
+		hdr, err := a.readHeader()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+
+		// skip GNU and BSD symbol tables
+		case hdr.Name == "/" || hdr.Name == "/SYM64/" || strings.HasPrefix(hdr.Name, "__.SYMDEF"):
+			continue
+
+		// read GNU long name table
+		case hdr.Name == "//":
+			if hdr.Size > arMaxNameTableSize {
+				return nil, fmt.Errorf("ar: long name table exceeds maximum size")
+			}
+			if a.names, err = io.ReadAll(a.data); err != nil {
+				return nil, fmt.Errorf("ar: cannot read long name table: %w", err)
+			}
+			continue
+		}
+
+		return &arEntry{hdr: hdr, r: a.data}, nil
+	}
+}
+
+// skipData discards the unread content and padding of the current member
+func (a *arWalker) skipData() error {
+	if a.data == nil {
+		return nil
+	}
+	if _, err := io.Copy(io.Discard, a.data); err != nil {
+		return fmt.Errorf("ar: cannot skip content: %w", err)
+	}
+	if _, err := io.CopyN(io.Discard, a.r, a.padding); err != nil && err != io.EOF {
+		return fmt.Errorf("ar: cannot skip padding: %w", err)
+	}
+	a.data = nil
+	a.padding = 0
+	return nil
+}
+
+// readHeader reads and decodes the next member header, resolves long names and
+// prepares the content reader
+func (a *arWalker) readHeader() (*arHeader, error) {
+	buf := make([]byte, arHeaderLength)
+	if _, err := io.ReadFull(a.r, buf); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("ar: cannot read header: %w", err)
+	}
+	if string(buf[58:60]) != "`\n" {
+		return nil, fmt.Errorf("ar: invalid header")
+	}
+
+	field := func(start, end int) string {
+		return strings.TrimRight(string(buf[start:end]), " ")
+	}
+	number := func(start, end, base int) (int64, error) {
+		s := field(start, end)
+		if len(s) == 0 {
+			return 0, nil
+		}
+		v, err := strconv.ParseInt(s, base, 64)
+		if err != nil {
+			return 0, fmt.Errorf("ar: invalid header: %w", err)
+		}
+		return v, nil
+	}
+
+	var values [5]int64
+	for i, f := range []struct{ start, end, base int }{
+		{16, 28, 10}, // mtime
+		{28, 34, 10}, // uid
+		{34, 40, 10}, // gid
+		{40, 48, 8},  // mode
+		{48, 58, 10}, // size
+	} {
+		v, err := number(f.start, f.end, f.base)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	hdr := &arHeader{
+		Name:    field(0, 16),
+		ModTime: time.Unix(values[0], 0),
+		Uid:     int(values[1]),
+		Gid:     int(values[2]),
+		Mode:    fs.FileMode(values[3]).Perm(),
+		Size:    values[4],
+	}
+	if hdr.Size < 0 {
+		return nil, fmt.Errorf("ar: invalid size %d of %q", hdr.Size, hdr.Name)
+	}
+	a.data = &io.LimitedReader{R: a.r, N: hdr.Size}
+	a.padding = hdr.Size % 2
+
+	// resolve long names
+	switch {
+
+	// BSD: name is stored in front of the content
+	case strings.HasPrefix(hdr.Name, "#1/"):
+		n, err := strconv.ParseInt(hdr.Name[3:], 10, 64)
+		if err != nil || n < 0 || n > hdr.Size || n > arMaxNameTableSize {
+			return nil, fmt.Errorf("ar: invalid long name %q", hdr.Name)
+		}
+		name := make([]byte, n)
+		if _, err := io.ReadFull(a.data, name); err != nil {
+			return nil, fmt.Errorf("ar: cannot read long name: %w", err)
+		}
+		hdr.Name = string(bytes.TrimRight(name, "\x00"))
+		hdr.Size -= n
+
+	// GNU: name is stored in the long name table
+	case len(hdr.Name) > 1 && hdr.Name[0] == '/' && hdr.Name[1] >= '0' && hdr.Name[1] <= '9':
+		offset, err := strconv.Atoi(hdr.Name[1:])
+		if err != nil || offset < 0 || offset >= len(a.names) {
+			return nil, fmt.Errorf("ar: invalid long name reference %q", hdr.Name)
+		}
+		name := a.names[offset:]
+		if i := bytes.IndexByte(name, '\n'); i >= 0 {
+			name = name[:i]
+		}
+		hdr.Name = strings.TrimSuffix(string(name), "/")
+
+	// GNU: names are terminated with a slash
+	case hdr.Name != "/" && hdr.Name != "//" && hdr.Name != "/SYM64/":
+		hdr.Name = strings.TrimSuffix(hdr.Name, "/")
+	}
+
+	return hdr, nil
+}
+
+// arEntry is a member in an ar archive
+type arEntry struct {
+	hdr *arHeader
+	r   io.Reader
+}
+
+// Name returns the name of the member
+func (a *arEntry) Name() string {
+	return a.hdr.Name
+}
+
+// Size returns the size of the member
+func (a *arEntry) Size() int64 {
+	return a.hdr.Size
+}
+
+// Mode returns the mode of the member
+func (a *arEntry) Mode() os.FileMode {
+	return a.hdr.Mode
+}
+
+// Linkname returns an empty string, because ar archives do not contain links
+func (a *arEntry) Linkname() string {
+	return ""
+}
+
+// IsRegular returns true, because ar archives contain only regular files
+func (a *arEntry) IsRegular() bool {
+	return true
+}
+
+// IsDir returns false, because ar archives contain only regular files
+func (a *arEntry) IsDir() bool {
+	return false
+}
+
+// IsHardlink returns false, because ar archives contain only regular files
+func (a *arEntry) IsHardlink() bool {
+	return false
+}
+
+// IsSymlink returns false, because ar archives contain only regular files
+func (a *arEntry) IsSymlink() bool {
+	return false
+}
+
+// Open returns a reader for the member
+func (a *arEntry) Open() (io.ReadCloser, error) {
+	return &noopReaderCloser{a.r}, nil
+}
+
+// Type returns the type of the member
+func (a *arEntry) Type() fs.FileMode {
+	return 0
+}
+
+// AccessTime returns the modification time, because ar archives do not store access times
+func (a *arEntry) AccessTime() time.Time {
+	return a.hdr.ModTime
+}
+
+// ModTime returns the modification time of the member
+func (a *arEntry) ModTime() time.Time {
+	return a.hdr.ModTime
+}
+
+// Sys returns the decoded ar header of the member
+func (a *arEntry) Sys() interface{} {
+	return a.hdr
+}
+
+// Gid returns the group id of the member
+func (a *arEntry) Gid() int {
+	return a.hdr.Gid
+}
+
+// Uid returns the user id of the member
+func (a *arEntry) Uid() int {
+	return a.hdr.Uid
+}
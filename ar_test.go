@@ -0,0 +1,273 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestUnpackAr(t *testing.T) {
+	longName := strings.Repeat("long", 5) + ".o"
+	members := []archiveContent{
+		{Name: "short.o", Content: []byte("hello world"), Mode: 0644},
+		{Name: longName, Content: []byte("odd"), Mode: 0600},
+		{Name: "last", Content: []byte("hello ar"), Mode: 0644},
+	}
+
+	tests := []struct {
+		name        string
+		src         []byte
+		cfg         *extract.Config
+		expected    map[string]string
+		expectError bool
+	}{
+		{
+			name:     "gnu",
+			src:      packAr(t, "gnu", members),
+			expected: map[string]string{"short.o": "hello world", longName: "odd", "last": "hello ar"},
+		},
+		{
+			name:     "bsd",
+			src:      packAr(t, "bsd", members),
+			expected: map[string]string{"short.o": "hello world", longName: "odd", "last": "hello ar"},
+		},
+		{
+			name:     "patterns",
+			src:      packAr(t, "gnu", members),
+			cfg:      extract.NewConfig(extract.WithPatterns("*.o")),
+			expected: map[string]string{"short.o": "hello world", longName: "odd"},
+		},
+		{
+			name:        "truncated",
+			src:         packAr(t, "gnu", members)[:100],
+			expectError: true,
+		},
+		{
+			name: "path traversal",
+			src: packAr(t, "bsd", []archiveContent{
+				{Name: "../escape", Content: []byte("hello world"), Mode: 0644},
+			}),
+			expectError: true,
+		},
+		{
+			name:        "max extraction size",
+			src:         packAr(t, "gnu", members),
+			cfg:         extract.NewConfig(extract.WithMaxExtractionSize(12)),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			if cfg == nil {
+				cfg = extract.NewConfig()
+			}
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackTo(context.Background(), tm, "", asIoReader(t, tc.src), cfg)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkMemoryContent(t, tm, tc.expected)
+		})
+	}
+}
+
+func TestUnpackDeb(t *testing.T) {
+	data := packTar(t, []archiveContent{
+		{Name: "./usr", Mode: fs.ModeDir | 0755},
+		{Name: "./usr/bin", Mode: fs.ModeDir | 0755},
+		{Name: "./usr/bin/tool", Content: []byte("hello deb"), Mode: 0755},
+		{Name: "./usr/bin/alias", Linktarget: "tool", Mode: fs.ModeSymlink | 0777},
+	})
+	control := compressGzip(t, packTar(t, []archiveContent{
+		{Name: "./control", Content: []byte("Package: tool\n"), Mode: 0644},
+	}))
+	deb := func(dataName string, data []byte) []byte {
+		return packAr(t, "gnu", []archiveContent{
+			{Name: "debian-binary", Content: []byte("2.0\n"), Mode: 0644},
+			{Name: "control.tar.gz", Content: control, Mode: 0644},
+			{Name: dataName, Content: data, Mode: 0644},
+		})
+	}
+
+	tests := []struct {
+		name         string
+		src          []byte
+		cfg          *extract.Config
+		expectedType string
+		expected     map[string]string
+		expectError  bool
+	}{
+		{
+			name:         "data.tar",
+			src:          deb("data.tar", data),
+			expectedType: "deb",
+			expected:     map[string]string{"usr/bin/tool": "hello deb"},
+		},
+		{
+			name:         "data.tar.gz",
+			src:          deb("data.tar.gz", compressGzip(t, data)),
+			expectedType: "deb",
+			expected:     map[string]string{"usr/bin/tool": "hello deb"},
+		},
+		{
+			name:         "data.tar.xz",
+			src:          deb("data.tar.xz", compressXz(t, data)),
+			expectedType: "deb",
+			expected:     map[string]string{"usr/bin/tool": "hello deb"},
+		},
+		{
+			name:         "data.tar.zst",
+			src:          deb("data.tar.zst", compressZstd(t, data)),
+			expectedType: "deb",
+			expected:     map[string]string{"usr/bin/tool": "hello deb"},
+		},
+		{
+			name:         "data.tar.bz2",
+			src:          deb("data.tar.bz2", compressBzip2(t, data)),
+			expectedType: "deb",
+			expected:     map[string]string{"usr/bin/tool": "hello deb"},
+		},
+		{
+			name:         "members as ar archive",
+			src:          deb("data.tar.gz", compressGzip(t, data)),
+			cfg:          extract.NewConfig(extract.WithExtractType("ar")),
+			expectedType: "ar",
+			expected:     map[string]string{"debian-binary": "2.0\n", "control.tar.gz": string(control)},
+		},
+		{
+			name:        "unsupported data archive",
+			src:         deb("data.tar.lzma", data),
+			expectError: true,
+		},
+		{
+			name: "missing data archive",
+			src: packAr(t, "gnu", []archiveContent{
+				{Name: "debian-binary", Content: []byte("2.0\n"), Mode: 0644},
+			}),
+			expectError: true,
+		},
+		{
+			name: "path traversal in data archive",
+			src: deb("data.tar", packTar(t, []archiveContent{
+				{Name: "../escape", Content: []byte("hello world"), Mode: 0644},
+			})),
+			expectError: true,
+		},
+		{
+			name: "symlink traversal in data archive",
+			src: deb("data.tar", packTar(t, []archiveContent{
+				{Name: "link", Linktarget: "../../etc", Mode: fs.ModeSymlink | 0777},
+			})),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var td *extract.TelemetryData
+			cfg := tc.cfg
+			if cfg == nil {
+				cfg = extract.NewConfig()
+			}
+			extract.WithTelemetryHook(func(ctx context.Context, d *extract.TelemetryData) { td = d })(cfg)
+
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackTo(context.Background(), tm, "", asIoReader(t, tc.src), cfg)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if td.ExtractedType != tc.expectedType {
+				t.Errorf("expected type %q, got %q", tc.expectedType, td.ExtractedType)
+			}
+			checkMemoryContent(t, tm, tc.expected)
+		})
+	}
+}
+
+// checkMemoryContent checks that the memory target contains the expected files
+func checkMemoryContent(t *testing.T, tm *extract.TargetMemory, expected map[string]string) {
+	t.Helper()
+	for name, content := range expected {
+		data, err := fs.ReadFile(tm, name)
+		if err != nil {
+			t.Fatalf("error reading %q: %v", name, err)
+		}
+		if string(data) != content {
+			t.Errorf("expected content %q for %q, got %q", content, name, data)
+		}
+	}
+}
+
+// packAr creates an ar archive in the given variant (gnu, bsd) with the given content.
+func packAr(t *testing.T, variant string, content []archiveContent) []byte {
+	t.Helper()
+	b := &bytes.Buffer{}
+	b.WriteString("!<arch>\n")
+
+	// write GNU long name table
+	names := &bytes.Buffer{}
+	offsets := make(map[string]int)
+	if variant == "gnu" {
+		for _, c := range content {
+			if len(c.Name) > 15 {
+				offsets[c.Name] = names.Len()
+				names.WriteString(c.Name + "/\n")
+			}
+		}
+		if names.Len() > 0 {
+			fmt.Fprintf(b, "%-16s%-12d%-6d%-6d%-8o%-10d`\n", "//", 0, 0, 0, 0, names.Len())
+			b.Write(names.Bytes())
+			if names.Len()%2 != 0 {
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	for _, c := range content {
+		name := c.Name
+		body := c.Content
+		switch variant {
+		case "gnu":
+			if offset, found := offsets[c.Name]; found {
+				name = fmt.Sprintf("/%d", offset)
+			} else {
+				name += "/"
+			}
+		case "bsd":
+			if len(c.Name) > 16 || strings.Contains(c.Name, " ") {
+				name = fmt.Sprintf("#1/%d", len(c.Name))
+				body = append([]byte(c.Name), body...)
+			}
+		default:
+			t.Fatalf("unsupported ar variant: %s", variant)
+		}
+		fmt.Fprintf(b, "%-16s%-12d%-6d%-6d%-8o%-10d`\n", name, 0, c.Uid, c.Gid, 0100000|c.Mode.Perm(), len(body))
+		b.Write(body)
+		if len(body)%2 != 0 {
+			b.WriteString("\n")
+		}
+	}
+	return b.Bytes()
+}
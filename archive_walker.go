@@ -4,31 +4,206 @@
 package extract
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"io/fs"
+	"iter"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
-// archiveWalker is an interface that represents a file walker in an archive
-type archiveWalker interface {
+// Walker is an interface that represents a file walker in an archive
+type Walker interface {
+	// Type returns the archive type, e.g. tar or zip.
 	Type() string
-	Next() (archiveEntry, error)
+
+	// Next returns the next entry in the archive. If no more entries are
+	// available, io.EOF is returned.
+	Next() (Entry, error)
 }
 
-// archiveEntry is an interface that represents a file in an archive
-type archiveEntry interface {
+// Entry is an interface that represents a file in an archive
+type Entry interface {
+	// AccessTime returns the access time of the entry.
 	AccessTime() time.Time
+
+	// Gid returns the group id of the entry.
 	Gid() int
+
+	// IsRegular returns true if the entry is a regular file.
 	IsRegular() bool
+
+	// IsDir returns true if the entry is a directory.
 	IsDir() bool
+
+	// IsHardlink returns true if the entry is a hard link.
+	IsHardlink() bool
+
+	// IsSymlink returns true if the entry is a symlink.
 	IsSymlink() bool
+
+	// Linkname returns the link target of a symlink or hard link.
 	Linkname() string
+
+	// Mode returns the file mode of the entry.
 	Mode() fs.FileMode
+
+	// ModTime returns the modification time of the entry.
 	ModTime() time.Time
+
+	// Name returns the name of the entry in the archive.
 	Name() string
+
+	// Open returns a reader for the content of the entry. For streamed archives, e.g. tar,
+	// the reader is only valid until the next entry is requested from the [Walker].
 	Open() (io.ReadCloser, error)
+
+	// Size returns the uncompressed size of the entry, or -1 if it is unknown.
 	Size() int64
+
+	// Sys returns the underlying data source of the entry.
 	Sys() interface{}
+
+	// Type returns the type of the entry.
 	Type() fs.FileMode
+
+	// Uid returns the user id of the entry.
 	Uid() int
 }
+
+// MetadataEntry is an optional interface for entries, which provide format specific
+// metadata, e.g. the package name of an RPM package.
+type MetadataEntry interface {
+	Entry
+
+	// Metadata returns the format specific metadata of the entry.
+	Metadata() map[string]string
+}
+
+// Walk returns an iterator over the entries of the archive in src, according to the given
+// configuration. If cfg is nil, the default configuration is used.
+//
+// The archive type is determined in the same way as for [UnpackTo]. The configured maximum
+// input size and maximum number of files are enforced, and only entries that match the
+// configured patterns are returned. Entries with names or link targets that would escape the
+// destination of an extraction are reported with an [ErrUnsafeEntryName] error, after which
+// the iteration can continue. All other errors end the iteration.
+//
+// The content of an entry can be read with [Entry.Open] until the next iteration step.
+func Walk(ctx context.Context, src io.Reader, cfg *Config) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		for ae, err := range walkEntries(ctx, src, cfg) {
+			if err == nil {
+				err = checkEntryName(ae)
+				if err != nil {
+					ae = nil
+				}
+			}
+			if !yield(ae, err) {
+				return
+			}
+		}
+	}
+}
+
+// walkEntries returns an iterator over the entries of the archive in src. The iteration
+// ends after the first error.
+func walkEntries(ctx context.Context, src io.Reader, cfg *Config) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		if cfg == nil {
+			cfg = NewConfig()
+		}
+
+		// open archive
+		w, err := newWalker(ctx, src, cfg)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer closeWalker(w)
+
+		// iterate over all entries in archive
+		var fileCounter int64
+		for {
+			// check if context is canceled
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			// get next entry
+			ae, err := w.Next()
+			switch {
+			case err == io.EOF:
+				return
+			case err != nil:
+				yield(nil, fmt.Errorf("%w: %w", ErrFailedToList, err))
+				return
+			case ae == nil:
+				continue
+			}
+
+			// skip tar specific git comment file
+			if isPaxGlobalHeader(ae) {
+				continue
+			}
+
+			// check if maximum of files (including folder and symlinks) is exceeded
+			fileCounter++
+			if err := cfg.CheckMaxFiles(fileCounter); err != nil {
+				yield(nil, fmt.Errorf("%w: %w", ErrFailedToList, err))
+				return
+			}
+
+			// check if entry needs to match patterns
+			match, err := matchPatterns(cfg, ae.Name())
+			if err != nil {
+				yield(nil, fmt.Errorf("%w: %w", ErrFailedToList, err))
+				return
+			}
+			if match.skip() {
+				continue
+			}
+
+			if !yield(ae, nil) {
+				return
+			}
+		}
+	}
+}
+
+// checkEntryName checks that the name and the link target of the entry stay within
+// the destination of an extraction.
+func checkEntryName(ae Entry) error {
+	// check if a name is provided
+	if len(ae.Name()) == 0 {
+		return fmt.Errorf("%w: empty name", ErrUnsafeEntryName)
+	}
+
+	// convert name to platform specific path and check for traversal
+	name := filepath.Join(strings.Split(ae.Name(), "/")...)
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("%w: %s", ErrUnsafeEntryName, ae.Name())
+	}
+
+	// check link targets for traversal
+	switch {
+	case ae.IsSymlink():
+		if filepath.IsAbs(ae.Linkname()) {
+			return fmt.Errorf("%w: symlink with absolute path as target: %s", ErrUnsafeEntryName, ae.Name())
+		}
+		target := filepath.Join(filepath.Dir(name), filepath.Join(strings.Split(ae.Linkname(), "/")...))
+		if !filepath.IsLocal(target) {
+			return fmt.Errorf("%w: symlink target outside of archive: %s", ErrUnsafeEntryName, ae.Name())
+		}
+	case ae.IsHardlink():
+		target := filepath.Join(strings.Split(ae.Linkname(), "/")...)
+		if !filepath.IsLocal(target) {
+			return fmt.Errorf("%w: hard link target outside of archive: %s", ErrUnsafeEntryName, ae.Name())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// remover is implemented by targets, which can remove the paths that are created by a failed
+// atomic extraction.
+type remover interface {
+	Remove(name string) error
+}
+
+// renamer is implemented by targets, which can back up and restore entries that are overwritten
+// by a failed atomic extraction.
+type renamer interface {
+	Rename(oldpath, newpath string) error
+}
+
+// unpackAtomic calls unpack to extract to dst on t. If atomic extraction is enabled, a failed
+// extraction is rolled back. If dst on disk does not exist yet, the archive is extracted into a
+// staging directory next to dst, which is moved into place on success. Otherwise, t is wrapped in
+// a journal, which records the created and overwritten paths to undo them on failure. unpack
+// receives a configuration, which has atomic extraction disabled.
+func unpackAtomic(t Target, dst string, cfg *Config, unpack func(t Target, dst string, cfg *Config) error) error {
+	if !cfg.AtomicExtraction() {
+		return unpack(t, dst, cfg)
+	}
+	n := *cfg
+	n.atomicExtraction = false
+	cfg = &n
+
+	if _, ok := t.(*TargetDisk); ok && canStage(dst) {
+		return unpackStaged(t, filepath.Clean(dst), cfg, unpack)
+	}
+	return unpackJournaled(t, dst, cfg, unpack)
+}
+
+// canStage returns true if dst is a path on disk, which does not exist yet, but its parent
+// directory does, so that a staging directory can be created next to it on the same filesystem.
+func canStage(dst string) bool {
+	if len(dst) == 0 {
+		return false
+	}
+	dst = filepath.Clean(dst)
+	if _, err := os.Lstat(dst); !errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+	stat, err := os.Stat(filepath.Dir(dst))
+	return err == nil && stat.IsDir()
+}
+
+// unpackStaged extracts into a hidden staging directory next to dst and moves the extracted
+// content into place on success. The staging directory is removed in any case.
+func unpackStaged(t Target, dst string, cfg *Config, unpack func(t Target, dst string, cfg *Config) error) error {
+	staging, err := os.MkdirTemp(filepath.Dir(dst), fmt.Sprintf(".%s.staging-", filepath.Base(dst)))
+	if err != nil {
+		return fmt.Errorf("cannot create staging directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(staging); err != nil {
+			cfg.Logger().Warn("cannot remove staging directory", "path", staging, "error", err)
+		}
+	}()
+	cfg.Logger().Debug("extracting into staging directory", "path", staging)
+
+	// extract to a path inside the staging directory, which does not exist, like dst
+	out := filepath.Join(staging, filepath.Base(dst))
+	if err := unpack(t, out, cfg); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(out); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err := os.Rename(out, dst); err != nil {
+		return fmt.Errorf("cannot move extracted content into place: %w", err)
+	}
+	return nil
+}
+
+// unpackJournaled extracts with t wrapped in a journal and rolls back all recorded changes, if
+// the extraction fails.
+func unpackJournaled(t Target, dst string, cfg *Config, unpack func(t Target, dst string, cfg *Config) error) error {
+	if _, ok := t.(remover); !ok {
+		return ErrAtomicExtractionUnsupported
+	}
+	if _, ok := t.(renamer); !ok && cfg.Overwrite() {
+		return fmt.Errorf("%w: cannot back up overwritten entries", ErrAtomicExtractionUnsupported)
+	}
+
+	j := &journalTarget{Target: t}
+	if err := unpack(j, dst, cfg); err != nil {
+		if rerr := j.rollback(); rerr != nil {
+			return errors.Join(err, fmt.Errorf("rollback failed: %w", rerr))
+		}
+		cfg.Logger().Info("rolled back failed extraction", "path", dst)
+		return err
+	}
+	j.commit(cfg)
+	return nil
+}
+
+// journalEntry is a change of the target, which is recorded by the journalTarget. If backup is
+// empty, path is created by the extraction. Otherwise, path is moved to backup before it is
+// overwritten.
+type journalEntry struct {
+	path   string
+	backup string
+}
+
+// journalTarget is a [Target], which records all paths that are created by an extraction and
+// backs up all entries before they are overwritten, so that the extraction can be rolled back.
+type journalTarget struct {
+	Target
+	entries []journalEntry
+}
+
+// CreateFile records path and calls CreateFile of the wrapped target.
+func (j *journalTarget) CreateFile(path string, src io.Reader, mode fs.FileMode, overwrite bool, maxSize int64) (int64, error) {
+	if err := j.record(path, overwrite); err != nil {
+		return 0, err
+	}
+	return j.Target.CreateFile(path, src, mode, overwrite, maxSize)
+}
+
+// CreateDir records all missing directories of path and calls CreateDir of the wrapped target.
+func (j *journalTarget) CreateDir(path string, mode fs.FileMode) error {
+	var missing []string
+	for p := path; ; p = filepath.Dir(p) {
+		if _, err := j.Lstat(p); !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		missing = append(missing, p)
+		if p == filepath.Dir(p) {
+			break
+		}
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		j.entries = append(j.entries, journalEntry{path: missing[i]})
+	}
+	return j.Target.CreateDir(path, mode)
+}
+
+// CreateSymlink records newname and calls CreateSymlink of the wrapped target.
+func (j *journalTarget) CreateSymlink(oldname string, newname string, overwrite bool) error {
+	if err := j.record(newname, overwrite); err != nil {
+		return err
+	}
+	return j.Target.CreateSymlink(oldname, newname, overwrite)
+}
+
+// CreateHardlink records newname and calls CreateHardlink of the wrapped target. If the wrapped
+// target does not support hard links, an error, which wraps ErrUnsupportedFile, is returned.
+func (j *journalTarget) CreateHardlink(oldname string, newname string, overwrite bool) error {
+	hl, ok := j.Target.(HardlinkTarget)
+	if !ok {
+		return unsupportedFile(newname)
+	}
+	if err := j.record(newname, overwrite); err != nil {
+		return err
+	}
+	return hl.CreateHardlink(oldname, newname, overwrite)
+}
+
+// Remove calls Remove of the wrapped target and drops the created path from the journal, e.g.
+// if a file is rejected after it is written. The backup of an overwritten entry is kept, so that
+// it is still restored by a rollback and removed by a commit.
+func (j *journalTarget) Remove(name string) error {
+	if err := j.Target.(remover).Remove(name); err != nil {
+		return err
+	}
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		if e := j.entries[i]; e.path == name && len(e.backup) == 0 {
+			j.entries = slices.Delete(j.entries, i, i+1)
+			break
+		}
+	}
+	return nil
+}
+
+// record records that path is created. If path exists and is overwritten, it is moved to a
+// backup first. Existing directories are not backed up, because the wrapped target refuses to
+// overwrite them.
+func (j *journalTarget) record(path string, overwrite bool) error {
+	stat, err := j.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		j.entries = append(j.entries, journalEntry{path: path})
+		return nil
+	}
+	if err != nil || !overwrite || stat.IsDir() {
+		return nil
+	}
+
+	backup, err := j.backupName(path)
+	if err != nil {
+		return err
+	}
+	if err := j.Target.(renamer).Rename(path, backup); err != nil {
+		return fmt.Errorf("cannot back up overwritten entry: %w", err)
+	}
+	j.entries = append(j.entries, journalEntry{path: path, backup: backup}, journalEntry{path: path})
+	return nil
+}
+
+// backupName returns an unused, hidden name next to path for its backup.
+func (j *journalTarget) backupName(path string) (string, error) {
+	for i := 0; i < 1000; i++ {
+		backup := filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.backup-%d", filepath.Base(path), i))
+		if _, err := j.Lstat(backup); errors.Is(err, fs.ErrNotExist) {
+			return backup, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find unused name to back up %s", path)
+}
+
+// rollback removes all created paths and restores all backups in reverse order.
+func (j *journalTarget) rollback() error {
+	var errs []error
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		e := j.entries[i]
+		if len(e.backup) > 0 {
+			if err := j.Target.(renamer).Rename(e.backup, e.path); err != nil {
+				errs = append(errs, fmt.Errorf("cannot restore %s: %w", e.path, err))
+			}
+			continue
+		}
+		if err := j.Target.(remover).Remove(e.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("cannot remove %s: %w", e.path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// commit removes all backups after a successful extraction.
+func (j *journalTarget) commit(cfg *Config) {
+	for _, e := range j.entries {
+		if len(e.backup) == 0 {
+			continue
+		}
+		if err := j.Target.(remover).Remove(e.backup); err != nil {
+			cfg.Logger().Warn("cannot remove backup", "path", e.backup, "error", err)
+		}
+	}
+}
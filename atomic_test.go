@@ -0,0 +1,245 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestAtomicExtraction(t *testing.T) {
+	archive := packTar(t, []archiveContent{
+		{Name: "old", Content: []byte("new content"), Mode: 0644},
+		{Name: "dir/new", Content: []byte("new"), Mode: 0644},
+		{Name: "dir/sub/link", Linktarget: "../new", Mode: fs.ModeSymlink | 0777},
+	})
+
+	tests := []struct {
+		name        string
+		src         []byte
+		existing    bool
+		dst         string
+		cfg         *extract.Config
+		expected    map[string]string
+		expectedErr error
+	}{
+		{
+			name:     "staging directory",
+			src:      archive,
+			dst:      "out",
+			cfg:      extract.NewConfig(extract.WithAtomicExtraction(true), extract.WithCreateDestination(true)),
+			expected: map[string]string{"out/old": "new content", "out/dir/new": "new", "out/dir/sub/link": "new"},
+		},
+		{
+			name:        "staging directory removed on failure",
+			src:         archive,
+			dst:         "out",
+			cfg:         extract.NewConfig(extract.WithAtomicExtraction(true), extract.WithCreateDestination(true), extract.WithMaxFiles(2)),
+			expectedErr: extract.ErrMaxFilesExceeded,
+		},
+		{
+			name:     "staging decompressed file",
+			src:      compressGzip(t, []byte("decompressed")),
+			dst:      "out",
+			cfg:      extract.NewConfig(extract.WithAtomicExtraction(true)),
+			expected: map[string]string{"out": "decompressed"},
+		},
+		{
+			name:     "existing destination",
+			src:      archive,
+			existing: true,
+			cfg:      extract.NewConfig(extract.WithAtomicExtraction(true), extract.WithOverwrite(true)),
+			expected: map[string]string{"keep": "keep", "old": "new content", "dir/new": "new", "dir/sub/link": "new"},
+		},
+		{
+			name:        "existing destination rolled back on failure",
+			src:         archive,
+			existing:    true,
+			cfg:         extract.NewConfig(extract.WithAtomicExtraction(true), extract.WithOverwrite(true), extract.WithMaxFiles(2)),
+			expected:    map[string]string{"keep": "keep", "old": "old"},
+			expectedErr: extract.ErrMaxFilesExceeded,
+		},
+		{
+			name:        "created parents rolled back on failure",
+			src:         archive,
+			dst:         "missing/out",
+			cfg:         extract.NewConfig(extract.WithAtomicExtraction(true), extract.WithCreateDestination(true), extract.WithMaxFiles(2)),
+			expectedErr: extract.ErrMaxFilesExceeded,
+		},
+		{
+			name:        "disabled",
+			src:         archive,
+			dst:         "out",
+			cfg:         extract.NewConfig(extract.WithCreateDestination(true), extract.WithMaxFiles(2)),
+			expected:    map[string]string{"out/old": "new content", "out/dir/new": "new"},
+			expectedErr: extract.ErrMaxFilesExceeded,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.existing {
+				for name, content := range map[string]string{"keep": "keep", "old": "old"} {
+					if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+						t.Fatalf("failed to create file: %v", err)
+					}
+				}
+			}
+
+			err := extract.Unpack(context.Background(), filepath.Join(dir, tc.dst), bytes.NewReader(tc.src), tc.cfg)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+
+			// compare the whole destination, which must not contain staging directories or backups
+			found := map[string]string{}
+			if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				rel, err := filepath.Rel(dir, path)
+				found[filepath.ToSlash(rel)] = string(data)
+				return err
+			}); err != nil {
+				t.Fatalf("failed to walk destination: %v", err)
+			}
+			if len(found) != len(tc.expected) {
+				t.Errorf("expected %d files, got %v", len(tc.expected), found)
+			}
+			for name, content := range tc.expected {
+				if found[name] != content {
+					t.Errorf("expected %s to contain %q, got %q", name, content, found[name])
+				}
+			}
+			if err != nil && !tc.existing && tc.cfg.AtomicExtraction() {
+				entries, _ := os.ReadDir(dir)
+				if len(entries) != 0 {
+					t.Errorf("expected empty directory after rollback, got %d entries", len(entries))
+				}
+			}
+		})
+	}
+}
+
+func TestAtomicExtractionTargets(t *testing.T) {
+	archive := packTar(t, []archiveContent{
+		{Name: "a", Content: []byte("a"), Mode: 0644},
+		{Name: "dir/b", Content: []byte("b"), Mode: 0644},
+		{Name: "dir/c", Content: []byte("c"), Mode: 0644},
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		tm := extract.NewTargetMemory()
+		cfg := extract.NewConfig(extract.WithAtomicExtraction(true), extract.WithMaxFiles(2))
+		if err := extract.UnpackTo(context.Background(), tm, "", bytes.NewReader(archive), cfg); !errors.Is(err, extract.ErrMaxFilesExceeded) {
+			t.Fatalf("expected error %v, got %v", extract.ErrMaxFilesExceeded, err)
+		}
+		entries, err := tm.ReadDir(".")
+		if err != nil {
+			t.Fatalf("failed to read directory: %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("expected empty target after rollback, got %d entries", len(entries))
+		}
+	})
+
+	t.Run("memory with overwrite", func(t *testing.T) {
+		cfg := extract.NewConfig(extract.WithAtomicExtraction(true), extract.WithOverwrite(true))
+		err := extract.UnpackTo(context.Background(), extract.NewTargetMemory(), "", bytes.NewReader(archive), cfg)
+		if !errors.Is(err, extract.ErrAtomicExtractionUnsupported) {
+			t.Fatalf("expected error %v, got %v", extract.ErrAtomicExtractionUnsupported, err)
+		}
+	})
+
+	t.Run("memory with rejected files", func(t *testing.T) {
+		archive := packTar(t, []archiveContent{
+			{Name: "a", Content: []byte("a"), Mode: 0644},
+			{Name: "dir/secret", Content: []byte("secret"), Mode: 0644},
+		})
+		reject := extract.WithContentFilter(func(_ context.Context, _ extract.EntryInfo, r io.Reader) (io.Reader, error) {
+			return &rejectingReader{r: r}, nil
+		})
+		mismatch := extract.WithExpectedDigests(map[string]extract.Digest{"dir/secret": sha256Digest("other")})
+
+		for _, opt := range []extract.ConfigOption{reject, mismatch} {
+			// the rejected file is removed and the extraction is committed
+			tm := extract.NewTargetMemory()
+			cfg := extract.NewConfig(extract.WithAtomicExtraction(true), extract.WithContinueOnError(true), opt)
+			if err := extract.UnpackTo(context.Background(), tm, "", bytes.NewReader(archive), cfg); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := tm.Stat("dir/secret"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("expected rejected file to be removed, got %v", err)
+			}
+			checkMemoryContent(t, tm, map[string]string{"a": "a"})
+
+			// the rejected file is removed and the extraction is rolled back
+			tm = extract.NewTargetMemory()
+			cfg = extract.NewConfig(extract.WithAtomicExtraction(true), opt)
+			if err := extract.UnpackTo(context.Background(), tm, "", bytes.NewReader(archive), cfg); err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			entries, err := tm.ReadDir(".")
+			if err != nil {
+				t.Fatalf("failed to read directory: %v", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("expected empty target after rollback, got %d entries", len(entries))
+			}
+		}
+	})
+
+	t.Run("disk with content filter", func(t *testing.T) {
+		archive := packTar(t, []archiveContent{
+			{Name: "a", Content: []byte("a"), Mode: 0644},
+			{Name: "secret", Content: append(bytes.Repeat([]byte("x"), 64*1024), []byte("secret")...), Mode: 0644},
+		})
+		dst := t.TempDir()
+		cfg := extract.NewConfig(extract.WithAtomicExtraction(true), extract.WithContinueOnError(true), extract.WithContentFilter(func(_ context.Context, _ extract.EntryInfo, r io.Reader) (io.Reader, error) {
+			return &rejectingReader{r: r}, nil
+		}))
+		if err := extract.UnpackTo(context.Background(), extract.NewTargetDisk(), dst, bytes.NewReader(archive), cfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(dst, "secret")); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected rejected file to be removed, got %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(dst, "a")); err != nil {
+			t.Errorf("expected file to be extracted, got %v", err)
+		}
+	})
+
+	t.Run("root", func(t *testing.T) {
+		dir := t.TempDir()
+		tr, err := extract.NewTargetRoot(dir)
+		if err != nil {
+			t.Fatalf("failed to create target root: %v", err)
+		}
+		defer tr.Close()
+		cfg := extract.NewConfig(extract.WithAtomicExtraction(true), extract.WithCreateDestination(true), extract.WithMaxFiles(2))
+		if err := extract.UnpackTo(context.Background(), tr, "out", bytes.NewReader(archive), cfg); !errors.Is(err, extract.ErrMaxFilesExceeded) {
+			t.Fatalf("expected error %v, got %v", extract.ErrMaxFilesExceeded, err)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("failed to read directory: %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("expected empty root after rollback, got %d entries", len(entries))
+		}
+	})
+}
@@ -23,6 +23,11 @@ func unpackBrotli(ctx context.Context, t Target, dst string, src io.Reader, cfg
 	return decompress(ctx, t, dst, src, cfg, decompressBrotliStream, "br")
 }
 
+// walkBrotli opens the brotli compressed src and returns a Walker for the decompressed content.
+func walkBrotli(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return walkDecompressed(ctx, src, cfg, decompressBrotliStream, fileExtensionBrotli)
+}
+
 // decompressBrotliStream returns an io.Reader that decompresses src with brotli algorithm
 func decompressBrotliStream(src io.Reader) (io.Reader, error) {
 	return brotli.NewReader(src), nil
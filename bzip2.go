@@ -36,6 +36,11 @@ func unpackBzip2(ctx context.Context, t Target, dst string, src io.Reader, cfg *
 	return decompress(ctx, t, dst, src, cfg, decompressBz2Stream, "bz2")
 }
 
+// walkBzip2 opens the bzip2 compressed src and returns a Walker for the decompressed content.
+func walkBzip2(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return walkDecompressed(ctx, src, cfg, decompressBz2Stream, fileExtensionBzip2)
+}
+
 func decompressBz2Stream(src io.Reader) (io.Reader, error) {
 	return bzip2.NewReader(src), nil
 }
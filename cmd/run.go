@@ -5,7 +5,12 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"io/fs"
@@ -13,7 +18,11 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/alecthomas/kong"
@@ -23,47 +32,73 @@ import (
 // CLI are the cli parameters for go-extract binary
 type CLI struct {
 	Archive                    string           `arg:"" name:"archive" help:"Path to archive. (\"-\" for STDIN)" type:"existing file"`
+	Atomic                     bool             `help:"Roll back a failed extraction, so that the destination is left untouched."`
+	Checksums                  string           `optional:"" help:"Verify extracted files against checksum file in the format of sha256sum, e.g. SHA256SUMS."`
+	ChecksumsAlgorithm         string           `optional:"" default:"sha256" help:"Digest algorithm of the checksum file. (${valid_digests})"`
 	ContinueOnError            bool             `short:"C" help:"Continue extraction on error."`
 	ContinueOnUnsupportedFiles bool             `short:"S" help:"Skip extraction of unsupported files."`
 	CreateDestination          bool             `short:"c" help:"Create destination directory if it does not exist."`
 	CustomCreateDirMode        int              `optional:"" default:"750" help:"File mode for created directories, which are not listed in the archive. (respecting umask)"`
 	CustomDecompressFileMode   int              `optional:"" default:"640" help:"File mode for decompressed files. (respecting umask)"`
+	DenyMissingFiles           bool             `help:"Fail if a file of the checksum file is not extracted."`
 	DenySymlinks               bool             `short:"D" help:"Deny symlink extraction."`
+	DenyUnexpectedFiles        bool             `help:"Fail if a file, which is not listed in the checksum file, or a symlink is extracted."`
 	Destination                string           `arg:"" name:"destination" default:"." help:"Output directory/file."`
+	Digest                     []string         `optional:"" name:"digest" help:"Compute digests of extracted files. (${valid_digests})"`
 	DropFileAttributes         bool             `short:"d" help:"Drop file attributes (mode, modtime, access time)."`
+	DryRun                     bool             `help:"Print the planned operations instead of extracting the archive."`
+	DryRunFormat               string           `optional:"" default:"table" enum:"table,json" help:"Format of the planned operations. (table, json)"`
+	Exclude                    []string         `optional:"" short:"X" name:"exclude" help:"Skip objects that match shell file name pattern."`
+	ExcludeRegexp              []string         `optional:"" name:"exclude-regexp" help:"Skip objects that match regular expression."`
 	InsecureTraverseSymlinks   bool             `help:"Traverse symlinks to directories during extraction."`
+	Manifest                   string           `optional:"" help:"Write manifest with path, size, mode and digests of extracted files to file. (\"-\" for STDOUT)"`
+	ManifestFormat             string           `optional:"" default:"json" enum:"json,sha256sum" help:"Format of the manifest. (json, sha256sum)"`
+	MaxCompressionRatio        float64          `optional:"" default:"-1" help:"Maximum ratio between decompressed and compressed size of an entry or the archive. (disable check: -1)"`
 	MaxFiles                   int64            `optional:"" default:"${default_max_files}" help:"Maximum files (including folder and symlinks) that are extracted before stop. (disable check: -1)"`
 	MaxExtractionSize          int64            `optional:"" default:"${default_max_extraction_size}" help:"Maximum extraction size that allowed is (in bytes). (disable check: -1)"`
 	MaxExtractionTime          int64            `optional:"" default:"${default_max_extraction_time}" help:"Maximum time that an extraction should take (in seconds). (disable check: -1)"`
 	MaxInputSize               int64            `optional:"" default:"${default_max_input_size}" help:"Maximum input size that allowed is (in bytes). (disable check: -1)"`
 	NoUntarAfterDecompression  bool             `short:"N" optional:"" default:"false" help:"Disable combined extraction of tar.gz."`
 	Overwrite                  bool             `short:"O" help:"Overwrite if exist."`
+	Password                   string           `optional:"" help:"Password to decrypt encrypted archives."`
 	Pattern                    []string         `optional:"" short:"P" name:"pattern" help:"Extracted objects need to match shell file name pattern."`
 	PreserveOwner              bool             `short:"p" help:"Preserve owner and group of files from archive (only root/uid:0 on unix systems for tar files)."`
+	PublicKey                  string           `optional:"" type:"existingfile" help:"Public key file to verify the signature. (minisign.pub, OpenPGP keyring, PEM encoded Ed25519 key)"`
+	Regexp                     []string         `optional:"" name:"regexp" help:"Extracted objects need to match regular expression (or a pattern)."`
+	Recursive                  int              `optional:"" default:"0" help:"Maximum depth of nested archives that are extracted recursively. (disable: 0)"`
+	Signature                  string           `optional:"" type:"existingfile" help:"Detached signature of the archive, which is verified before extraction."`
+	SignatureType              string           `optional:"" default:"auto" enum:"auto,ed25519,minisign,openpgp" help:"Type of the signature. (auto, ed25519, minisign, openpgp)"`
+	StripComponents            int              `optional:"" default:"0" help:"Remove leading path components from entry names. (disable: 0)"`
 	Telemetry                  bool             `short:"T" optional:"" default:"false" help:"Print telemetry data to log after extraction."`
 	Type                       string           `short:"t" optional:"" default:"${default_type}" name:"type" help:"Type of archive. (${valid_types})"`
 	Verbose                    bool             `short:"v" optional:"" help:"Verbose logging."`
 	Version                    kong.VersionFlag `short:"V" optional:"" help:"Print release version information."`
 }
 
+// Commands are the commands of the go-extract binary
+type Commands struct {
+	Extract CLI     `cmd:"" default:"withargs" help:"Extract an archive. (default)"`
+	Test    TestCLI `cmd:"" help:"Test the integrity of an archive by decoding all entries without writing anything."`
+}
+
 // Run the entrypoint into go-extract as a cli tool
 func Run(version, commit, date string) {
-	ctx := context.Background()
-	var cli CLI
-	kong.Parse(&cli,
+	var commands Commands
+	kctx := kong.Parse(&commands,
 		kong.Description("A secure extraction utility"),
 		kong.UsageOnError(),
-		kong.Vars{
-			"version":                     fmt.Sprintf("%s (%s), commit %s, built at %s", filepath.Base(os.Args[0]), version, commit, date),
-			"valid_types":                 "7z, br, bz2, gz, lz4, rar, sz, tar, tgz, xz, zip, zst, zz",
-			"default_type":                "",                          // default is empty, but needs to be set to avoid kong error
-			"default_max_extraction_size": strconv.Itoa(1 << (10 * 3)), // 1GB
-			"default_max_files":           strconv.Itoa(100000),        // 100k files
-			"default_max_input_size":      strconv.Itoa(1 << (10 * 3)), // 1GB
-			"default_max_extraction_time": strconv.Itoa(60),            // 60 seconds
-		},
+		kongVars(version, commit, date),
 	)
 
+	// test the integrity of an archive instead of extracting it
+	if kctx.Selected() != nil && kctx.Selected().Name == "test" {
+		runTest(commands.Test)
+		return
+	}
+
+	ctx := context.Background()
+	cli := commands.Extract
+
 	// Check for verbose output
 	logLevel := slog.LevelError
 	if cli.Verbose {
@@ -76,7 +111,9 @@ func Run(version, commit, date string) {
 	}))
 
 	// setup telemetry hook
+	var telemetryData *extract.TelemetryData
 	telemetryDataToLog := func(ctx context.Context, td *extract.TelemetryData) {
+		telemetryData = td
 		if cli.Telemetry {
 			logger.Info("extraction finished", "telemetryData", td)
 		}
@@ -84,15 +121,21 @@ func Run(version, commit, date string) {
 
 	// process cli params
 	config := extract.NewConfig(
+		extract.WithAtomicExtraction(cli.Atomic && !cli.DryRun),
 		extract.WithContinueOnError(cli.ContinueOnError),
 		extract.WithContinueOnUnsupportedFiles(cli.ContinueOnUnsupportedFiles),
 		extract.WithCreateDestination(cli.CreateDestination),
 		extract.WithCustomCreateDirMode(toFileMode(cli.CustomCreateDirMode)),
 		extract.WithCustomDecompressFileMode(toFileMode(cli.CustomDecompressFileMode)),
+		extract.WithDenyMissingFiles(cli.DenyMissingFiles),
 		extract.WithDenySymlinkExtraction(cli.DenySymlinks),
+		extract.WithDenyUnexpectedFiles(cli.DenyUnexpectedFiles),
+		extract.WithDigests(cli.Digest...),
+		extract.WithExcludePatterns(cli.Exclude...),
 		extract.WithExtractType(cli.Type),
 		extract.WithInsecureTraverseSymlinks(cli.InsecureTraverseSymlinks),
 		extract.WithLogger(logger),
+		extract.WithMaxCompressionRatio(cli.MaxCompressionRatio),
 		extract.WithMaxExtractionSize(cli.MaxExtractionSize),
 		extract.WithMaxFiles(cli.MaxFiles),
 		extract.WithMaxInputSize(cli.MaxInputSize),
@@ -101,8 +144,54 @@ func Run(version, commit, date string) {
 		extract.WithOverwrite(cli.Overwrite),
 		extract.WithPatterns(cli.Pattern...),
 		extract.WithPreserveOwner(cli.PreserveOwner),
+		extract.WithRecursiveExtraction(cli.Recursive),
+		extract.WithStripComponents(cli.StripComponents),
 		extract.WithTelemetryHook(telemetryDataToLog),
 	)
+	for _, expr := range cli.Regexp {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			logger.Error("invalid regular expression", "err", err)
+			os.Exit(-1)
+		}
+		extract.WithRegexpPatterns(re)(config)
+	}
+	for _, expr := range cli.ExcludeRegexp {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			logger.Error("invalid regular expression", "err", err)
+			os.Exit(-1)
+		}
+		extract.WithExcludeRegexpPatterns(re)(config)
+	}
+	if cli.Manifest != "" && (cli.ManifestFormat == "sha256sum" || len(cli.Digest) == 0) && !slices.Contains(cli.Digest, extract.DigestSHA256) {
+		extract.WithDigests(extract.DigestSHA256)(config)
+	}
+	if cli.Checksums != "" {
+		f, err := os.Open(cli.Checksums)
+		if err != nil {
+			logger.Error("opening checksum file failed", "err", err)
+			os.Exit(-1)
+		}
+		digests, err := extract.ParseChecksums(f, cli.ChecksumsAlgorithm)
+		f.Close()
+		if err != nil {
+			logger.Error("parsing checksum file failed", "err", err)
+			os.Exit(-1)
+		}
+		extract.WithExpectedDigests(digests)(config)
+	}
+	if cli.Signature != "" {
+		verifier, err := newSignatureVerifier(cli.Signature, cli.SignatureType, cli.PublicKey)
+		if err != nil {
+			logger.Error("preparing signature verification failed", "err", err)
+			os.Exit(-1)
+		}
+		extract.WithSignatureVerifier(verifier)(config)
+	}
+	if cli.Password != "" {
+		extract.WithPassword(cli.Password)(config)
+	}
 
 	// open archive
 	var archive io.Reader
@@ -124,11 +213,165 @@ func Run(version, commit, date string) {
 		defer cancel()
 	}
 
+	// record the planned operations instead of extracting the archive
+	if cli.DryRun {
+		target := extract.NewTargetDryRun(extract.NewTargetDisk())
+		err := extract.UnpackTo(ctx, target, cli.Destination, archive, config)
+		if werr := writePlan(cli.DryRunFormat, target.Plan()); werr != nil {
+			log.Println(fmt.Errorf("error writing plan: %w", werr))
+			os.Exit(-1)
+		}
+		if err != nil {
+			log.Println(fmt.Errorf("error during dry run: %w", err))
+			os.Exit(-1)
+		}
+		return
+	}
+
 	// extract archive
 	if err := extract.Unpack(ctx, cli.Destination, archive, config); err != nil {
 		log.Println(fmt.Errorf("error during extraction: %w", err))
 		os.Exit(-1)
 	}
+
+	// write manifest
+	if cli.Manifest != "" && telemetryData != nil {
+		if err := writeManifest(cli.Manifest, cli.ManifestFormat, telemetryData.Manifest); err != nil {
+			log.Println(fmt.Errorf("error writing manifest: %w", err))
+			os.Exit(-1)
+		}
+	}
+}
+
+// kongVars returns the variables, which are used in the cli parameters.
+func kongVars(version, commit, date string) kong.Vars {
+	return kong.Vars{
+		"version":                     fmt.Sprintf("%s (%s), commit %s, built at %s", filepath.Base(os.Args[0]), version, commit, date),
+		"valid_types":                 strings.Join(extract.Formats(), ", "),
+		"valid_digests":               strings.Join(extract.DigestAlgorithms(), ", "),
+		"default_type":                "",                          // default is empty, but needs to be set to avoid kong error
+		"default_max_extraction_size": strconv.Itoa(1 << (10 * 3)), // 1GB
+		"default_max_files":           strconv.Itoa(100000),        // 100k files
+		"default_max_input_size":      strconv.Itoa(1 << (10 * 3)), // 1GB
+		"default_max_extraction_time": strconv.Itoa(60),            // 60 seconds
+	}
+}
+
+// newSignatureVerifier returns the verifier for the signature file with the public key file. If
+// the type is auto, it is determined by the extension of the signature file.
+func newSignatureVerifier(signatureFile string, signatureType string, publicKeyFile string) (extract.SignatureVerifier, error) {
+	if publicKeyFile == "" {
+		return nil, fmt.Errorf("public key is required to verify the signature")
+	}
+	signature, err := os.ReadFile(signatureFile)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := os.ReadFile(publicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if signatureType == "auto" {
+		switch filepath.Ext(signatureFile) {
+		case ".minisig":
+			signatureType = "minisign"
+		case ".sig", ".asc", ".gpg":
+			signatureType = "openpgp"
+		default:
+			return nil, fmt.Errorf("cannot determine signature type of %s", signatureFile)
+		}
+	}
+
+	switch signatureType {
+	case "ed25519":
+		block, _ := pem.Decode(publicKey)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM encoded public key found")
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is not an ed25519 key")
+		}
+		return extract.NewEd25519Verifier(edKey, signature)
+	case "minisign":
+		return extract.NewMinisignVerifier(string(publicKey), signature)
+	default:
+		return extract.NewOpenPGPVerifier(bytes.NewReader(publicKey), signature)
+	}
+}
+
+// writeManifest writes the manifest in format to the file name, or to STDOUT if name is "-".
+// The sha256sum format can be verified with "sha256sum -c" in the destination directory.
+func writeManifest(name string, format string, manifest []extract.ManifestEntry) error {
+	var b bytes.Buffer
+	switch format {
+	case "sha256sum":
+		for _, e := range manifest {
+			// escape names like sha256sum does
+			p, prefix := e.Path, ""
+			if strings.ContainsAny(p, "\\\n\r") {
+				p = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(p)
+				prefix = "\\"
+			}
+			fmt.Fprintf(&b, "%s%s  %s\n", prefix, e.Digests[extract.DigestSHA256], p)
+		}
+	default:
+		if manifest == nil {
+			manifest = []extract.ManifestEntry{}
+		}
+		enc := json.NewEncoder(&b)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(manifest); err != nil {
+			return err
+		}
+	}
+
+	if name == "-" {
+		_, err := os.Stdout.Write(b.Bytes())
+		return err
+	}
+	return os.WriteFile(name, b.Bytes(), 0644)
+}
+
+// writePlan writes the planned operations of a dry run in format to STDOUT.
+func writePlan(format string, plan []extract.PlannedOperation) error {
+	if format == "json" {
+		if plan == nil {
+			plan = []extract.PlannedOperation{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OPERATION\tMODE\tSIZE\tPATH")
+	for _, op := range plan {
+		mode, size, path := "", "", op.Path
+		switch op.Type {
+		case extract.OperationCreateFile, extract.OperationCreateDir, extract.OperationChmod:
+			mode = op.Mode.String()
+		}
+		if op.Type == extract.OperationCreateFile {
+			size = strconv.FormatInt(op.Size, 10)
+		}
+		switch {
+		case op.Linkname != "":
+			path = fmt.Sprintf("%s -> %s", path, op.Linkname)
+		case op.Type == extract.OperationChown:
+			path = fmt.Sprintf("%s (uid=%d, gid=%d)", path, op.Uid, op.Gid)
+		}
+		if op.Overwrite {
+			path = fmt.Sprintf("%s (overwrite)", path)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", op.Type, mode, size, path)
+	}
+	return w.Flush()
 }
 
 // asFileMode interprets the given decimal value as fs.FileMode
@@ -0,0 +1,105 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/alecthomas/kong"
+	extract "github.com/hashicorp/go-extract"
+)
+
+// TestCLI are the cli parameters for testing the integrity of an archive with go-extract
+type TestCLI struct {
+	Archive           string           `arg:"" name:"archive" help:"Path to archive. (\"-\" for STDIN)" type:"existing file"`
+	Exclude           []string         `optional:"" short:"X" name:"exclude" help:"Skip objects that match shell file name pattern."`
+	MaxFiles          int64            `optional:"" default:"${default_max_files}" help:"Maximum files (including folder and symlinks) that are tested before stop. (disable check: -1)"`
+	MaxExtractionSize int64            `optional:"" default:"${default_max_extraction_size}" help:"Maximum size of the decoded content that allowed is (in bytes). (disable check: -1)"`
+	MaxExtractionTime int64            `optional:"" default:"${default_max_extraction_time}" help:"Maximum time that a test should take (in seconds). (disable check: -1)"`
+	MaxInputSize      int64            `optional:"" default:"${default_max_input_size}" help:"Maximum input size that allowed is (in bytes). (disable check: -1)"`
+	Password          string           `optional:"" help:"Password to decrypt encrypted archives."`
+	Pattern           []string         `optional:"" short:"P" name:"pattern" help:"Tested objects need to match shell file name pattern."`
+	Type              string           `short:"t" optional:"" default:"${default_type}" name:"type" help:"Type of archive. (${valid_types})"`
+	Verbose           bool             `short:"v" optional:"" help:"Verbose logging."`
+	Version           kong.VersionFlag `short:"V" optional:"" help:"Print release version information."`
+}
+
+// runTest tests the integrity of an archive, like "unzip -t", and prints the result of each entry
+func runTest(cli TestCLI) {
+	ctx := context.Background()
+
+	// Check for verbose output
+	logLevel := slog.LevelError
+	if cli.Verbose {
+		logLevel = slog.LevelDebug
+	}
+
+	// setup logger
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: logLevel,
+	}))
+
+	// process cli params
+	config := extract.NewConfig(
+		extract.WithExcludePatterns(cli.Exclude...),
+		extract.WithExtractType(cli.Type),
+		extract.WithLogger(logger),
+		extract.WithMaxExtractionSize(cli.MaxExtractionSize),
+		extract.WithMaxFiles(cli.MaxFiles),
+		extract.WithMaxInputSize(cli.MaxInputSize),
+		extract.WithPatterns(cli.Pattern...),
+	)
+	if cli.Password != "" {
+		extract.WithPassword(cli.Password)(config)
+	}
+
+	// open archive
+	var archive io.Reader
+	if cli.Archive == "-" {
+		archive = bufio.NewReader(os.Stdin)
+	} else {
+		f, err := os.Open(cli.Archive)
+		if err != nil {
+			logger.Error("opening archive failed", "err", err)
+			os.Exit(-1)
+		}
+		defer f.Close()
+		archive = f
+	}
+
+	if cli.MaxExtractionTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), (time.Second * time.Duration(cli.MaxExtractionTime)))
+		defer cancel()
+	}
+
+	// test archive
+	results, err := extract.Test(ctx, archive, config)
+	var failed int
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("FAILED  %s: %v\n", r.Name, r.Err)
+			continue
+		}
+		fmt.Printf("OK      %s\n", r.Name)
+	}
+	if err != nil {
+		log.Println(fmt.Errorf("error during test: %w", err))
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d entries failed in %s\n", failed, len(results), cli.Archive)
+	}
+	if err != nil || failed > 0 {
+		os.Exit(-1)
+	}
+	fmt.Printf("No errors detected in %s (%d entries tested)\n", cli.Archive, len(results))
+}
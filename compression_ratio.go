@@ -0,0 +1,88 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import "io"
+
+// compressionRatioThreshold is the number of decompressed bytes, up to which the compression
+// ratio is not checked.
+const compressionRatioThreshold = 1 << 20 // 1 MiB
+
+// compressedSizer is implemented by entries, which know the size of their compressed content.
+type compressedSizer interface {
+	compressedSize() int64
+}
+
+// compressedSize returns the compressed size of ae, or -1 if the entry does not know its
+// compressed size, e.g. 7zip entries, which share a compressed stream.
+func compressedSize(ae Entry) int64 {
+	if cs, ok := ae.(compressedSizer); ok {
+		return cs.compressedSize()
+	}
+	return -1
+}
+
+// compressionRatioReader is a reader that checks the compression ratio of the decompressed
+// content of an entry, which is read from R, both for the entry and for the whole archive.
+type compressionRatioReader struct {
+	R          io.Reader    // underlying reader
+	cfg        *Config      // configuration with the maximum compression ratio
+	compressed int64        // compressed size of the entry, -1 if unknown
+	input      func() int64 // compressed bytes, which are consumed from the archive input
+	offset     int64        // decompressed bytes of the archive before the entry
+	N          int64        // number of bytes read
+}
+
+// Read reads from the underlying reader and returns [ErrCompressionRatioExceeded], as soon as the
+// compression ratio of the entry or the archive is exceeded.
+func (r *compressionRatioReader) Read(p []byte) (int, error) {
+	n, err := r.R.Read(p)
+	r.N += int64(n)
+
+	// check ratio of the entry
+	if r.compressed >= 0 {
+		if err := r.cfg.CheckCompressionRatio(r.compressed, r.N); err != nil {
+			return n, err
+		}
+	}
+
+	// check ratio of the archive
+	if err := r.cfg.CheckCompressionRatio(r.input(), r.offset+r.N); err != nil {
+		return n, err
+	}
+	return n, err
+}
+
+// newCompressionRatioReader returns src, wrapped in a compressionRatioReader, if a maximum
+// compression ratio is configured. compressed is the compressed size of the entry or -1, input
+// returns the consumed compressed bytes of the archive and offset is the number of decompressed
+// bytes of the archive before the entry.
+func newCompressionRatioReader(src io.Reader, cfg *Config, compressed int64, input func() int64, offset int64) io.Reader {
+	if cfg.MaxCompressionRatio() <= 0 {
+		return src
+	}
+	return &compressionRatioReader{R: src, cfg: cfg, compressed: compressed, input: input, offset: offset}
+}
+
+// withCompressedInput returns a copy of the configuration, which uses input to determine the
+// compressed bytes, which are consumed from the input of an archive that is read as stream.
+func (c *Config) withCompressedInput(input func() int64) *Config {
+	n := *c
+	n.compressedInput = input
+	return &n
+}
+
+// consumedInput returns a function, which returns the bytes that are read by ler so far.
+func consumedInput(ler *limitErrorReader) func() int64 {
+	return func() int64 { return int64(ler.ReadBytes()) }
+}
+
+// inputCounter returns a function, which returns the compressed bytes that are consumed from
+// the input of the archive. If the input is not read as stream, the input size of td is used.
+func (c *Config) inputCounter(td *TelemetryData) func() int64 {
+	if c.compressedInput != nil {
+		return c.compressedInput
+	}
+	return func() int64 { return td.InputSize }
+}
@@ -0,0 +1,137 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestCompressionRatio(t *testing.T) {
+	zeros := bytes.Repeat([]byte{0}, 2<<20)
+
+	tests := []struct {
+		name        string
+		src         []byte
+		cfg         *extract.Config
+		expected    map[string]string
+		expectedErr error
+	}{
+		{
+			name: "disabled by default",
+			src:  compressGzip(t, zeros),
+			cfg:  extract.NewConfig(),
+		},
+		{
+			name:        "gzip",
+			src:         compressGzip(t, zeros),
+			cfg:         extract.NewConfig(extract.WithMaxCompressionRatio(100)),
+			expectedErr: extract.ErrCompressionRatioExceeded,
+		},
+		{
+			name: "gzip below maximum",
+			src:  compressGzip(t, zeros),
+			cfg:  extract.NewConfig(extract.WithMaxCompressionRatio(10000)),
+		},
+		{
+			name:        "tar.gz",
+			src:         compressGzip(t, packTar(t, []archiveContent{{Name: "zeros", Content: zeros, Mode: 0644}})),
+			cfg:         extract.NewConfig(extract.WithMaxCompressionRatio(100)),
+			expectedErr: extract.ErrCompressionRatioExceeded,
+		},
+		{
+			name: "tar below maximum",
+			src:  packTar(t, []archiveContent{{Name: "zeros", Content: zeros, Mode: 0644}}),
+			cfg:  extract.NewConfig(extract.WithMaxCompressionRatio(2)),
+		},
+		{
+			name:        "zip entry",
+			src:         packDeflatedZip(t, map[string][]byte{"zeros": zeros}),
+			cfg:         extract.NewConfig(extract.WithMaxCompressionRatio(100)),
+			expectedErr: extract.ErrCompressionRatioExceeded,
+		},
+		{
+			name:        "zip archive",
+			src:         packDeflatedZip(t, map[string][]byte{"a": zeros[:1<<20], "b": zeros[:1<<20], "c": zeros[:1<<20]}),
+			cfg:         extract.NewConfig(extract.WithMaxCompressionRatio(100)),
+			expectedErr: extract.ErrCompressionRatioExceeded,
+		},
+		{
+			name:     "zip entry with continue on error",
+			src:      packDeflatedZip(t, map[string][]byte{"text": []byte("hello world"), "zeros": zeros}),
+			cfg:      extract.NewConfig(extract.WithMaxCompressionRatio(100), extract.WithContinueOnError(true)),
+			expected: map[string]string{"text": "hello world"},
+		},
+		{
+			name:        "7zip",
+			src:         pack7zZeros(t),
+			cfg:         extract.NewConfig(extract.WithMaxCompressionRatio(100)),
+			expectedErr: extract.ErrCompressionRatioExceeded,
+		},
+		{
+			name: "7zip below maximum",
+			src:  pack7zZeros(t),
+			cfg:  extract.NewConfig(extract.WithMaxCompressionRatio(10000)),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackTo(context.Background(), tm, "", bytes.NewReader(tc.src), tc.cfg)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkMemoryContent(t, tm, tc.expected)
+		})
+	}
+}
+
+// packDeflatedZip creates a zip archive with the deflate compressed files in content, sorted by name.
+func packDeflatedZip(t *testing.T, content map[string][]byte) []byte {
+	t.Helper()
+	names := make([]string, 0, len(content))
+	for name := range content {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	b := new(bytes.Buffer)
+	w := zip.NewWriter(b)
+	for _, name := range names {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			t.Fatalf("error creating zip header: %v", err)
+		}
+		if _, err := f.Write(content[name]); err != nil {
+			t.Fatalf("error writing zip data: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("error closing zip writer: %v", err)
+	}
+	return b.Bytes()
+}
+
+// pack7zZeros creates always the same 7z archive with the file zeros, which contains 2 MiB of zeros.
+func pack7zZeros(t *testing.T) []byte {
+	t.Helper()
+	b, err := hex.DecodeString("377abcaf271c0003ab63de5974010000000000006600000000000000d831208900006ffdffffa3b7ff473e481572396151b89228e6a38607f9eee41e82d32fc53a3c014bb17ec98a8a4d2fa30dd97fa6e38c231153e05918c5758ae277f8b6947f0c6ac0de744964e2e95c53b204d8f7440cab5f0d6d46e9e5c37688b79657acb64de1691d6ffb4b88106c42cb883f5c008fd04eaf262894711f3d8f24e1709ea7235fec28cb85d195988a7e2a91f22775f719c006984d98fdd8afd5900fc42553f8f591363105a5b0ee6fc1704d470cd19111aaad601dbaceb127185c5986e9665258bee976ac59e4e55b0508f9c7daadfcfb522b74cd1e5b2042f9dd533df82964093b80cb2a6cdfb53bf0c4bd2e5faa0f3e4b664290130eff1093f8717859f80bcdff9528460fa9fc7cdefb9a302e56c08f85f38381c065c42553f8f591363105a5b0ee6fc1704d470cd19111aaad601dbaceb127185c5986e9665258bee976ac59e4e55b0508f9c7daadfcfb522b74cd1e5b2042f9dd533df82964093b80cb2a6cdfb53bf0c4bc640d25d258ffff9a080000010406000109817400070b01000123030101055d000080000ce000002000080a017e87898d00000501110d007a00650072006f0073000000140a010050f87ef33b5ddd01120a010050f87ef33b5ddd01130a0100b24d7ef33b5ddd01150601002080a4810000")
+	if err != nil {
+		t.Fatalf("error decoding 7z data: %v", err)
+	}
+	return b
+}
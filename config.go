@@ -5,14 +5,26 @@ package extract
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"io/fs"
 	"log/slog"
+	"regexp"
+	"strings"
 )
 
 // ConfigOption is a function pointer to implement the option pattern
 type ConfigOption func(*Config)
 
+// PathRewriter is a function, which returns the rewritten name of the entry with name. If false
+// is returned, the entry is skipped. The rewritten name is still subject to all security checks.
+type PathRewriter func(name string) (string, bool)
+
+// PasswordProvider is a function, which returns the password to decrypt the encrypted
+// entry with entryName. A returned error aborts the decryption of the entry. For 7zip
+// and rar archives, which are decrypted as a whole, entryName is empty.
+type PasswordProvider func(entryName string) (string, error)
+
 // Config provides a configuration struct and options to adjust the configuration.
 //
 // The configuration struct holds all configuration options for the extraction process.
@@ -21,10 +33,17 @@ type ConfigOption func(*Config)
 // The default configuration is designed to be secure by default and prevent exhaustion,
 // path traversal and symlink attacks.
 type Config struct {
+	// atomicExtraction offers the option to roll back a failed extraction, so that the
+	// destination is either completely extracted or left untouched
+	atomicExtraction bool
+
 	// cacheInMemory offers the option to enable/disable caching in memory. This applies only
 	// to the extraction of zip archives, which are provided as a stream.
 	cacheInMemory bool
 
+	// contentFilter inspects or transforms the content of each extracted file
+	contentFilter ContentFilter
+
 	// continueOnError decides if the extraction should be continued even if an error occurred
 	continueOnError bool
 
@@ -40,21 +59,57 @@ type Config struct {
 	// customDecompressFileMode is the file mode for a decompressed file (respecting umask)
 	customDecompressFileMode fs.FileMode
 
+	// depth is the nesting depth of the archive, which is extracted with this configuration
+	depth int
+
+	// denyMissingFiles fails the extraction, if a file with an expected digest is not extracted
+	denyMissingFiles bool
+
 	// denySymlinkExtraction offers the option to enable/disable the extraction of symlinks
 	denySymlinkExtraction bool
 
+	// digests are the algorithms of the digests, which are computed for each extracted file
+	digests []string
+
+	// denyUnexpectedFiles fails the extraction of files without an expected digest
+	denyUnexpectedFiles bool
+
 	// dropFileAttributes is a flag drop the file attributes of the extracted files
 	dropFileAttributes bool
 
+	// entryHook decides for each entry, if it is extracted, skipped, renamed or if the extraction is aborted
+	entryHook EntryHook
+
+	// excludePatterns is a list of file patterns to exclude files from the extraction
+	excludePatterns []string
+
+	// excludeRegexpPatterns is a list of regular expressions to exclude files from the extraction
+	excludeRegexpPatterns []*regexp.Regexp
+
+	// expectedDigests are the expected digests of the extracted files by path
+	expectedDigests map[string]Digest
+
 	// extractionType is the type of extraction algorithm
 	extractionType string
 
+	// formats are additional formats, which are only available for this configuration, in the
+	// order of their registration
+	formats []namedFormat
+
 	// traverseSymlinks traverses symlinks to directories during extraction
 	traverseSymlinks bool
 
 	// logger stream for extraction
 	logger logger
 
+	// compressedInput returns the number of compressed bytes, which are consumed from the input
+	// of the archive so far. If nil, the input size of the telemetry data is used.
+	compressedInput func() int64
+
+	// maxCompressionRatio is the maximum ratio between the decompressed and the compressed size.
+	// Set value to -1 to disable the check.
+	maxCompressionRatio float64
+
 	// maxExtractionSize is the maximum size of a file after decompression.
 	// Set value to -1 to disable the check.
 	maxExtractionSize int64
@@ -71,17 +126,50 @@ type Config struct {
 	// Important: do not adjust this value after extraction started
 	telemetryHook TelemetryHook
 
+	// nestedDir is the path of the directory of a nested archive relative to the destination
+	// of the outermost archive
+	nestedDir string
+
 	// noUntarAfterDecompression offers the option to enable/disable combined tar.gz extraction
 	noUntarAfterDecompression bool
 
 	// Define if files should be overwritten in the destination
 	overwrite bool
 
+	// passwordProvider returns the password to decrypt encrypted entries
+	passwordProvider PasswordProvider
+
+	// pathRewriter rewrites the names of the entries before they are extracted
+	pathRewriter PathRewriter
+
 	// patterns is a list of file patterns to match files to extract
 	patterns []string
 
 	// preserveOwner is a flag to preserve the owner of the extracted files
 	preserveOwner bool
+
+	// regexpPatterns is a list of regular expressions to match files to extract
+	regexpPatterns []*regexp.Regexp
+
+	// recursiveExtraction is the maximum depth of nested archives, which are extracted
+	// recursively. Set value to 0 to disable the recursive extraction.
+	recursiveExtraction int
+
+	// signatureVerifier verifies the signature of the archive before it is extracted
+	signatureVerifier SignatureVerifier
+
+	// stripComponents is the number of leading path components, which are removed from the
+	// names of the entries before they are extracted
+	stripComponents int
+
+	// verifier verifies the extracted files against the expected digests
+	verifier *digestVerifier
+}
+
+// ContentFilter returns the filter, which inspects or transforms the content of each extracted
+// file, or nil if no content filter is configured.
+func (c *Config) ContentFilter() ContentFilter {
+	return c.contentFilter
 }
 
 // ContinueOnError returns true if the extraction should continue on error.
@@ -89,6 +177,12 @@ func (c *Config) ContinueOnError() bool {
 	return c.continueOnError
 }
 
+// AtomicExtraction returns true if a failed extraction is rolled back, so that the destination
+// is either completely extracted or left untouched.
+func (c *Config) AtomicExtraction() bool {
+	return c.atomicExtraction
+}
+
 // CacheInMemory returns true if caching in memory is enabled. This applies only to
 // the extraction of zip archives, which are provided as a stream.
 //
@@ -113,6 +207,24 @@ func (c *Config) CheckMaxFiles(counter int64) error {
 	return nil
 }
 
+// CheckCompressionRatio checks if the ratio between the decompressed and the compressed size
+// exceeds the configured maximum. The ratio is only checked, after more than 1 MiB are
+// decompressed, because small files, e.g. a file with zeros, can have a high ratio as well. If
+// the maximum is exceeded, a [ErrCompressionRatioExceeded] error is returned.
+func (c *Config) CheckCompressionRatio(compressed int64, decompressed int64) error {
+
+	// check if disabled or below threshold
+	if c.MaxCompressionRatio() <= 0 || decompressed <= compressionRatioThreshold {
+		return nil
+	}
+
+	// check value
+	if float64(decompressed) > c.MaxCompressionRatio()*float64(max(compressed, 1)) {
+		return ErrCompressionRatioExceeded
+	}
+	return nil
+}
+
 // CheckExtractionSize checks if fileSize exceeds configured maximum. If the maximum is exceeded,
 // a [ErrMaxExtractionSizeExceeded] error is returned.
 func (c *Config) CheckExtractionSize(fileSize int64) error {
@@ -156,11 +268,27 @@ func (c *Config) CustomDecompressFileMode() fs.FileMode {
 	return c.customDecompressFileMode
 }
 
+// DenyMissingFiles returns true if the extraction fails, when a file with an expected digest
+// is not extracted.
+func (c *Config) DenyMissingFiles() bool {
+	return c.denyMissingFiles
+}
+
 // DenySymlinkExtraction returns true if symlinks are NOT allowed.
 func (c *Config) DenySymlinkExtraction() bool {
 	return c.denySymlinkExtraction
 }
 
+// DenyUnexpectedFiles returns true if the extraction of files without an expected digest fails.
+func (c *Config) DenyUnexpectedFiles() bool {
+	return c.denyUnexpectedFiles
+}
+
+// Digests returns the algorithms of the digests, which are computed for each extracted file.
+func (c *Config) Digests() []string {
+	return c.digests
+}
+
 // DropFileAttributes returns true if the file attributes should be dropped.
 func (c *Config) DropFileAttributes() bool {
 	return c.dropFileAttributes
@@ -181,6 +309,11 @@ func (c *Config) Logger() logger {
 	return c.logger
 }
 
+// MaxCompressionRatio returns the maximum ratio between the decompressed and the compressed size.
+func (c *Config) MaxCompressionRatio() float64 {
+	return c.maxCompressionRatio
+}
+
 // MaxExtractionSize returns the maximum size over all decompressed and extracted files.
 func (c *Config) MaxExtractionSize() int64 {
 	return c.maxExtractionSize
@@ -196,7 +329,7 @@ func (c *Config) MaxInputSize() int64 {
 	return c.maxInputSize
 }
 
-// NoUntarAfterDecompression returns true if tar.gz should NOT be untared after decompression.
+// NoUntarAfterDecompression returns true if tar.gz or cpio.gz should NOT be unpacked after decompression.
 func (c *Config) NoUntarAfterDecompression() bool {
 	return c.noUntarAfterDecompression
 }
@@ -206,12 +339,72 @@ func (c *Config) Overwrite() bool {
 	return c.overwrite
 }
 
+// PasswordProvider returns the password provider to decrypt encrypted entries, or
+// nil if no password is configured.
+func (c *Config) PasswordProvider() PasswordProvider {
+	return c.passwordProvider
+}
+
+// password returns the password to decrypt the encrypted entry with name. If no
+// password is configured, a [ErrPasswordRequired] error is returned.
+func (c *Config) password(name string) (string, error) {
+	if c.passwordProvider == nil {
+		return "", fmt.Errorf("%w: %s", ErrPasswordRequired, name)
+	}
+	return c.passwordProvider(name)
+}
+
+// archivePassword returns the password to decrypt archives, which are encrypted as a
+// whole, e.g. 7zip and rar archives. The configured [PasswordProvider] is called with
+// an empty entry name. The returned bool reports if a password is configured.
+func (c *Config) archivePassword() (string, bool, error) {
+	if c.passwordProvider == nil {
+		return "", false, nil
+	}
+	password, err := c.passwordProvider("")
+	return password, true, err
+}
+
+// PathRewriter returns the function, which rewrites the names of the entries before they are
+// extracted, or nil if no path rewriter is configured.
+func (c *Config) PathRewriter() PathRewriter {
+	return c.pathRewriter
+}
+
 // Patterns returns a list of unix-filepath patterns to match files to extract
-// Patterns are matched using [filepath.Match](https://golang.org/pkg/path/filepath/#Match).
+// Patterns are matched per path component using [path.Match], "**" matches zero or more
+// path components, and a pattern with a trailing slash matches everything below the directory.
 func (c *Config) Patterns() []string {
 	return c.patterns
 }
 
+// EntryHook returns the hook, which decides for each entry, if it is extracted, skipped, renamed
+// or if the extraction is aborted, or nil if no entry hook is configured.
+func (c *Config) EntryHook() EntryHook {
+	return c.entryHook
+}
+
+// ExpectedDigests returns the expected digests of the extracted files by path.
+func (c *Config) ExpectedDigests() map[string]Digest {
+	return c.expectedDigests
+}
+
+// ExcludePatterns returns a list of unix-filepath patterns to exclude files from the extraction.
+// Exclude patterns are matched like [Config.Patterns].
+func (c *Config) ExcludePatterns() []string {
+	return c.excludePatterns
+}
+
+// RegexpPatterns returns a list of regular expressions to match files to extract.
+func (c *Config) RegexpPatterns() []*regexp.Regexp {
+	return c.regexpPatterns
+}
+
+// ExcludeRegexpPatterns returns a list of regular expressions to exclude files from the extraction.
+func (c *Config) ExcludeRegexpPatterns() []*regexp.Regexp {
+	return c.excludeRegexpPatterns
+}
+
 // PreserveOwner returns true if the owner of the extracted files should
 // be preserved. This option is only available on Unix systems requiring
 // root privileges and tar archives as input.
@@ -219,12 +412,30 @@ func (c *Config) PreserveOwner() bool {
 	return c.preserveOwner
 }
 
-// SetNoUntarAfterDecompression sets the noUntarAfterDecompression flag. If true, tar.gz files
-// are not untared after decompression.
+// SetNoUntarAfterDecompression sets the noUntarAfterDecompression flag. If true, tar.gz and cpio.gz
+// files are not unpacked after decompression.
 func (c *Config) SetNoUntarAfterDecompression(b bool) {
 	c.noUntarAfterDecompression = b
 }
 
+// RecursiveExtraction returns the maximum depth of nested archives, which are extracted
+// recursively. If the recursive extraction is disabled, 0 is returned.
+func (c *Config) RecursiveExtraction() int {
+	return c.recursiveExtraction
+}
+
+// SignatureVerifier returns the verifier of the signature of the archive, or nil if the
+// signature is not verified.
+func (c *Config) SignatureVerifier() SignatureVerifier {
+	return c.signatureVerifier
+}
+
+// StripComponents returns the number of leading path components, which are removed from the
+// names of the entries before they are extracted.
+func (c *Config) StripComponents() int {
+	return c.stripComponents
+}
+
 // TelemetryHook returns the  telemetry hook.
 func (c *Config) TelemetryHook() TelemetryHook {
 	if c.telemetryHook == nil {
@@ -236,6 +447,7 @@ func (c *Config) TelemetryHook() TelemetryHook {
 }
 
 const (
+	defaultAtomicExtraction           = false         // leave partial extraction behind on failure
 	defaultCacheInMemory              = false         // cache on disk
 	defaultContinueOnError            = false         // stop on error and return error
 	defaultContinueOnUnsupportedFiles = false         // stop on unsupported files and return error
@@ -245,6 +457,7 @@ const (
 	defaultDenySymlinkExtraction      = false         // allow symlink extraction
 	defaultDropFileAttributes         = false         // drop file attributes from archive
 	defaultExtractionType             = ""            // don't limit extraction type
+	defaultMaxCompressionRatio        = -1            // don't limit compression ratio
 	defaultMaxFiles                   = 100000        // 100k files
 	defaultMaxExtractionSize          = 1 << (10 * 3) // 1 Gb
 	defaultMaxInputSize               = 1 << (10 * 3) // 1 Gb
@@ -270,6 +483,7 @@ func NewConfig(opts ...ConfigOption) *Config {
 
 	// setup default values
 	config := &Config{
+		atomicExtraction:           defaultAtomicExtraction,
 		cacheInMemory:              defaultCacheInMemory,
 		continueOnError:            defaultContinueOnError,
 		continueOnUnsupportedFiles: defaultContinueOnUnsupportedFiles,
@@ -280,6 +494,7 @@ func NewConfig(opts ...ConfigOption) *Config {
 		dropFileAttributes:         defaultDropFileAttributes,
 		extractionType:             defaultExtractionType,
 		logger:                     defaultLogger,
+		maxCompressionRatio:        defaultMaxCompressionRatio,
 		maxFiles:                   defaultMaxFiles,
 		maxExtractionSize:          defaultMaxExtractionSize,
 		maxInputSize:               defaultMaxInputSize,
@@ -299,6 +514,18 @@ func NewConfig(opts ...ConfigOption) *Config {
 	return config
 }
 
+// WithAtomicExtraction options pattern function to enable/disable atomic extraction. If enabled and
+// the extraction fails, e.g. due to an exceeded limit, a corrupt entry or a canceled context, the
+// destination is left as it was before the extraction. If the destination on disk does not exist yet,
+// the archive is extracted into a staging directory next to it, which is moved into place on success.
+// Otherwise, all created paths are recorded and removed on failure, and entries, which are overwritten,
+// are backed up and restored.
+func WithAtomicExtraction(atomic bool) ConfigOption {
+	return func(c *Config) {
+		c.atomicExtraction = atomic
+	}
+}
+
 // WithCacheInMemory options pattern function to enable/disable caching in memory.
 // This applies only to the extraction of zip archives, which are provided as a stream.
 //
@@ -309,6 +536,17 @@ func WithCacheInMemory(cache bool) ConfigOption {
 	}
 }
 
+// WithContentFilter options pattern function to set a filter, which is called with the content of
+// each extracted file and returns the reader, from which the file is written. A filter can inspect
+// the content while it is streamed or transform it. If the filter or a read from the returned reader
+// fails, the file is removed and the error, which wraps [ErrContentRejected], is handled like any
+// other extraction error.
+func WithContentFilter(filter ContentFilter) ConfigOption {
+	return func(c *Config) {
+		c.contentFilter = filter
+	}
+}
+
 // WithContinueOnError options pattern function to continue on error during extraction. If set to true,
 // the error is logged and the extraction continues. If set to false, the extraction stops and returns the error.
 func WithContinueOnError(yes bool) ConfigOption {
@@ -351,6 +589,14 @@ func WithCustomDecompressFileMode(mode fs.FileMode) ConfigOption {
 	}
 }
 
+// WithDenyMissingFiles options pattern function to fail the extraction, if a file with an
+// expected digest is not extracted, with [ErrExpectedFileMissing].
+func WithDenyMissingFiles(deny bool) ConfigOption {
+	return func(c *Config) {
+		c.denyMissingFiles = deny
+	}
+}
+
 // WithDenySymlinkExtraction options pattern function to deny symlink extraction.
 func WithDenySymlinkExtraction(deny bool) ConfigOption {
 	return func(c *Config) {
@@ -358,6 +604,25 @@ func WithDenySymlinkExtraction(deny bool) ConfigOption {
 	}
 }
 
+// WithDenyUnexpectedFiles options pattern function to fail the extraction of files without an
+// expected digest with [ErrUnexpectedFile]. Symlinks are denied as unexpected files as well,
+// because they have no content, which can be verified. Directories are not checked and are
+// created as required.
+func WithDenyUnexpectedFiles(deny bool) ConfigOption {
+	return func(c *Config) {
+		c.denyUnexpectedFiles = deny
+	}
+}
+
+// WithDigests options pattern function to compute the digests with the given algorithms for each
+// extracted file, while it is written. The supported algorithms are returned by [DigestAlgorithms].
+// The digests are reported with the path, size and mode of each file in [TelemetryData.Manifest].
+func WithDigests(algorithms ...string) ConfigOption {
+	return func(c *Config) {
+		c.digests = append(c.digests, algorithms...)
+	}
+}
+
 // WithDropFileAttributes options pattern function to drop the
 // file attributes of the extracted files.
 func WithDropFileAttributes(drop bool) ConfigOption {
@@ -366,6 +631,16 @@ func WithDropFileAttributes(drop bool) ConfigOption {
 	}
 }
 
+// WithExpectedDigests options pattern function to verify the extracted files against the expected
+// digests, e.g. from a checksum file parsed by [ParseChecksums]. The digests are mapped by the slash
+// separated path of the file relative to the destination. The digest of each file is computed, while
+// it is written. If the digest does not match, the file is removed and [ErrDigestMismatch] is returned.
+func WithExpectedDigests(digests map[string]Digest) ConfigOption {
+	return func(c *Config) {
+		c.expectedDigests = digests
+	}
+}
+
 // WithExtractType options pattern function to set the extraction type in the [Config].
 func WithExtractType(extractionType string) ConfigOption {
 	return func(c *Config) {
@@ -375,6 +650,16 @@ func WithExtractType(extractionType string) ConfigOption {
 	}
 }
 
+// WithFormat options pattern function to register the format f under name for this configuration
+// only. Formats registered with this option take precedence over globally registered formats with
+// the same name, see [RegisterFormat]. An invalid format causes an [ErrInvalidFormat] error
+// when the configuration is used.
+func WithFormat(name string, f Format) ConfigOption {
+	return func(c *Config) {
+		c.formats = append(c.formats, namedFormat{name: strings.ToLower(name), format: f})
+	}
+}
+
 // WithInsecureTraverseSymlinks options pattern function to traverse symlinks during extraction.
 func WithInsecureTraverseSymlinks(traverse bool) ConfigOption {
 	return func(c *Config) {
@@ -389,6 +674,15 @@ func WithLogger(logger logger) ConfigOption {
 	}
 }
 
+// WithMaxCompressionRatio options pattern function to set the maximum ratio between the decompressed
+// and the compressed size. The ratio is checked continuously for every entry and for the whole archive,
+// while the content is decompressed. (-1 to disable check)
+func WithMaxCompressionRatio(maxCompressionRatio float64) ConfigOption {
+	return func(c *Config) {
+		c.maxCompressionRatio = maxCompressionRatio
+	}
+}
+
 // WithMaxExtractionSize options pattern function to set maximum size over all decompressed
 //
 //	and extracted files. (-1 to disable check)
@@ -413,7 +707,7 @@ func WithMaxInputSize(maxInputSize int64) ConfigOption {
 	}
 }
 
-// WithNoUntarAfterDecompression options pattern function to enable/disable combined tar.gz extraction.
+// WithNoUntarAfterDecompression options pattern function to enable/disable combined tar.gz and cpio.gz extraction.
 func WithNoUntarAfterDecompression(disable bool) ConfigOption {
 	return func(c *Config) {
 		c.noUntarAfterDecompression = disable
@@ -427,14 +721,75 @@ func WithOverwrite(enable bool) ConfigOption {
 	}
 }
 
+// WithPassword options pattern function to set the password to decrypt encrypted entries.
+func WithPassword(password string) ConfigOption {
+	return WithPasswordProvider(func(string) (string, error) {
+		return password, nil
+	})
+}
+
+// WithPasswordProvider options pattern function to set a [PasswordProvider], which is called
+// with the name of every encrypted entry to obtain the password for its decryption.
+func WithPasswordProvider(provider PasswordProvider) ConfigOption {
+	return func(c *Config) {
+		c.passwordProvider = provider
+	}
+}
+
+// WithPathRewriter options pattern function to rewrite the names of the entries before they are
+// matched against the patterns and extracted. If rewriter returns false, the entry is skipped.
+// The rewriter is applied after the leading path components are stripped and also to the targets
+// of links, so that they point to the rewritten entries. The rewritten name is still subject to
+// all security checks.
+func WithPathRewriter(rewriter PathRewriter) ConfigOption {
+	return func(c *Config) {
+		c.pathRewriter = rewriter
+	}
+}
+
 // WithPatterns options pattern function to set filepath pattern, that files need to match to be extracted.
-// Patterns are matched using [pkg/path/filepath.Match].
+// Patterns are matched per path component using [path.Match] and "**" matches zero or more path components,
+// e.g. "**/*.go". A pattern with a trailing slash, e.g. "docs/", matches the directory and everything below it.
 func WithPatterns(pattern ...string) ConfigOption {
 	return func(c *Config) {
 		c.patterns = append(c.patterns, pattern...)
 	}
 }
 
+// WithEntryHook options pattern function to set a hook, which is called for each entry before it is
+// extracted, and decides if the entry is extracted, skipped, renamed or if the extraction is aborted.
+// The hook is also called for the entries of nested archives, see [WithRecursiveExtraction].
+func WithEntryHook(hook EntryHook) ConfigOption {
+	return func(c *Config) {
+		c.entryHook = hook
+	}
+}
+
+// WithExcludePatterns options pattern function to set filepath patterns, that exclude matching files from
+// the extraction, e.g. "**/node_modules/" or "*.exe". Exclude patterns are matched like the patterns of
+// [WithPatterns] and take precedence over them.
+func WithExcludePatterns(pattern ...string) ConfigOption {
+	return func(c *Config) {
+		c.excludePatterns = append(c.excludePatterns, pattern...)
+	}
+}
+
+// WithRegexpPatterns options pattern function to set regular expressions, that files need to match to be
+// extracted. A file is extracted if it matches any pattern of [WithPatterns] or any regular expression.
+func WithRegexpPatterns(re ...*regexp.Regexp) ConfigOption {
+	return func(c *Config) {
+		c.regexpPatterns = append(c.regexpPatterns, re...)
+	}
+}
+
+// WithExcludeRegexpPatterns options pattern function to set regular expressions, that exclude matching
+// files from the extraction.
+func WithExcludeRegexpPatterns(re ...*regexp.Regexp) ConfigOption {
+	return func(c *Config) {
+		c.excludeRegexpPatterns = append(c.excludeRegexpPatterns, re...)
+	}
+}
+
 // WithPreserveOwner options pattern function to preserve the owner of
 // the extracted files. This option is only available on Unix systems
 // requiring root privileges and tar archives as input.
@@ -444,6 +799,36 @@ func WithPreserveOwner(preserve bool) ConfigOption {
 	}
 }
 
+// WithRecursiveExtraction options pattern function to extract nested archives recursively up to
+// maxDepth levels below the archive. A nested archive is extracted into a directory next to it,
+// which is named after the archive with the suffix ".d". The maximum extraction size and the
+// maximum number of files apply to all levels together. (0 to disable)
+func WithRecursiveExtraction(maxDepth int) ConfigOption {
+	return func(c *Config) {
+		c.recursiveExtraction = maxDepth
+	}
+}
+
+// WithSignatureVerifier options pattern function to verify the detached signature of the archive
+// with verifier, e.g. from [NewMinisignVerifier] or [NewOpenPGPVerifier], before it is extracted.
+// The archive is read once, while it is cached and passed to the verifier. Nothing is extracted,
+// unless the signature is valid; otherwise [ErrSignatureVerificationFailed] is returned. The
+// signature verification is not supported for multi-volume archives.
+func WithSignatureVerifier(verifier SignatureVerifier) ConfigOption {
+	return func(c *Config) {
+		c.signatureVerifier = verifier
+	}
+}
+
+// WithStripComponents options pattern function to remove n leading path components from the
+// names of the entries before they are extracted, like tar --strip-components. Entries with n or
+// less path components are skipped. (0 to disable)
+func WithStripComponents(n int) ConfigOption {
+	return func(c *Config) {
+		c.stripComponents = n
+	}
+}
+
 // WithTelemetryHook options pattern function to set a [telemetry.TelemetryHook], which is called after extraction.
 func WithTelemetryHook(hook TelemetryHook) ConfigOption {
 	return func(c *Config) {
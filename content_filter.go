@@ -0,0 +1,67 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ContentFilter is a function, which is called with the content of each extracted file and
+// returns the reader, from which the file is written. The returned reader can inspect the
+// content while it is streamed, e.g. to scan for malware or secrets, or transform it, e.g. to
+// normalize line endings. If the filter or a read from the returned reader fails, the file is
+// rejected with [ErrContentRejected] and removed from the target.
+type ContentFilter func(ctx context.Context, e EntryInfo, r io.Reader) (io.Reader, error)
+
+// sourceReader is a reader, which records the last error of the content of an entry, so that
+// errors of the content are not reported as rejection by the content filter.
+type sourceReader struct {
+	r   io.Reader
+	err error
+}
+
+// Read reads from the underlying reader and records its error.
+func (s *sourceReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err != nil && err != io.EOF {
+		s.err = err
+	}
+	return n, err
+}
+
+// contentFilterReader is a reader, which reads from the reader of a content filter and wraps
+// its errors with [ErrContentRejected].
+type contentFilterReader struct {
+	r    io.Reader
+	src  *sourceReader
+	name string
+}
+
+// Read reads from the reader of the content filter. Errors, which do not originate from the
+// content of the entry, are returned as rejection.
+func (f *contentFilterReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err != nil && err != io.EOF && (f.src.err == nil || !errors.Is(err, f.src.err)) {
+		err = fmt.Errorf("%w: %s: %w", ErrContentRejected, f.name, err)
+	}
+	return n, err
+}
+
+// filterContent returns src wrapped by the content filter of cfg, or src if no content filter
+// is configured.
+func filterContent(ctx context.Context, cfg *Config, ei EntryInfo, src io.Reader) (io.Reader, error) {
+	filter := cfg.ContentFilter()
+	if filter == nil {
+		return src, nil
+	}
+	sr := &sourceReader{r: src}
+	r, err := filter(ctx, ei, sr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrContentRejected, ei.Name, err)
+	}
+	return &contentFilterReader{r: r, src: sr, name: ei.Name}, nil
+}
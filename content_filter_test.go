@@ -0,0 +1,142 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+// rejectingReader returns an error, once the content contains the word "secret".
+type rejectingReader struct {
+	r    io.Reader
+	seen []byte
+}
+
+func (r *rejectingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.seen = append(r.seen, p[:n]...)
+	if bytes.Contains(r.seen, []byte("secret")) {
+		return n, errors.New("secret found")
+	}
+	return n, err
+}
+
+func TestContentFilter(t *testing.T) {
+	archive := packTar(t, []archiveContent{
+		{Name: "crlf.txt", Content: []byte("a\r\nb\r\n"), Mode: 0644},
+		{Name: "secret.txt", Content: append(bytes.Repeat([]byte("x"), 64*1024), []byte("secret")...), Mode: 0644},
+		{Name: "plain.txt", Content: []byte("plain"), Mode: 0644},
+	})
+
+	normalize := func(_ context.Context, e extract.EntryInfo, r io.Reader) (io.Reader, error) {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(strings.ReplaceAll(string(b), "\r\n", "\n")), nil
+	}
+	redact := func(_ context.Context, e extract.EntryInfo, r io.Reader) (io.Reader, error) {
+		return &rejectingReader{r: r}, nil
+	}
+
+	tests := []struct {
+		name        string
+		cfg         *extract.Config
+		expected    map[string]string
+		notExpected []string
+		errors      int64
+		expectedErr error
+	}{
+		{
+			name:     "transform",
+			cfg:      extract.NewConfig(extract.WithContentFilter(normalize)),
+			expected: map[string]string{"crlf.txt": "a\nb\n", "plain.txt": "plain"},
+		},
+		{
+			name:        "reject",
+			cfg:         extract.NewConfig(extract.WithContentFilter(redact)),
+			expectedErr: extract.ErrContentRejected,
+		},
+		{
+			name:        "reject with continue on error",
+			cfg:         extract.NewConfig(extract.WithContinueOnError(true), extract.WithContentFilter(redact)),
+			expected:    map[string]string{"crlf.txt": "a\r\nb\r\n", "plain.txt": "plain"},
+			notExpected: []string{"secret.txt"},
+			errors:      1,
+		},
+		{
+			name: "reject before reading",
+			cfg: extract.NewConfig(extract.WithContinueOnError(true), extract.WithContentFilter(func(_ context.Context, e extract.EntryInfo, r io.Reader) (io.Reader, error) {
+				if e.Name == "plain.txt" {
+					return nil, errors.New("not allowed")
+				}
+				return r, nil
+			})),
+			expected:    map[string]string{"crlf.txt": "a\r\nb\r\n"},
+			notExpected: []string{"plain.txt"},
+			errors:      1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var td *extract.TelemetryData
+			extract.WithTelemetryHook(func(_ context.Context, d *extract.TelemetryData) { td = d })(tc.cfg)
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackTo(context.Background(), tm, "", bytes.NewReader(archive), tc.cfg)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+				if _, err := tm.Lstat("secret.txt"); err == nil {
+					t.Errorf("expected rejected file to be removed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkMemoryContent(t, tm, tc.expected)
+			for _, name := range tc.notExpected {
+				if _, err := tm.Lstat(name); err == nil {
+					t.Errorf("expected %q not to exist", name)
+				}
+			}
+			if td.ExtractionErrors != tc.errors {
+				t.Errorf("expected %d errors, got %d", tc.errors, td.ExtractionErrors)
+			}
+		})
+	}
+}
+
+func TestContentFilterDecompress(t *testing.T) {
+	b := new(bytes.Buffer)
+	zw := gzip.NewWriter(b)
+	if _, err := zw.Write([]byte("a\r\nb\r\n")); err != nil {
+		t.Fatalf("error writing gzip data: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("error closing gzip writer: %v", err)
+	}
+
+	var name string
+	cfg := extract.NewConfig(extract.WithContentFilter(func(_ context.Context, e extract.EntryInfo, r io.Reader) (io.Reader, error) {
+		name = e.Name
+		b, err := io.ReadAll(r)
+		return bytes.NewReader(bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))), err
+	}))
+	tm := extract.NewTargetMemory()
+	if err := extract.UnpackTo(context.Background(), tm, "", bytes.NewReader(b.Bytes()), cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkMemoryContent(t, tm, map[string]string{name: "a\nb\n"})
+}
@@ -0,0 +1,598 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileExtensionCpio is the file extension for cpio archives
+const fileExtensionCpio = "cpio"
+
+// magicBytesCpio are the magic bytes for cpio archives in the portable ASCII formats
+// newc, crc and odc, and in the old binary format in both byte orders.
+//
+// https://man.freebsd.org/cgi/man.cgi?query=cpio&sektion=5
+var magicBytesCpio = [][]byte{
+	[]byte(cpioMagicNewc),
+	[]byte(cpioMagicCrc),
+	[]byte(cpioMagicOdc),
+	{0xc7, 0x71}, // binary, little endian
+	{0x71, 0xc7}, // binary, big endian
+}
+
+const (
+	// cpioMagicNewc is the magic of the new portable format (SVR4)
+	cpioMagicNewc = "070701"
+
+	// cpioMagicCrc is the magic of the new portable format (SVR4) with checksum
+	cpioMagicCrc = "070702"
+
+	// cpioMagicOdc is the magic of the old portable format (POSIX.1)
+	cpioMagicOdc = "070707"
+
+	// cpioMagicBinary is the magic of the old binary format
+	cpioMagicBinary = 070707
+
+	// cpioTrailer is the name of the last entry in a cpio archive
+	cpioTrailer = "TRAILER!!!"
+
+	// cpioMaxNameLength is the maximum length of names and link targets in a cpio archive
+	cpioMaxNameLength = 4096
+)
+
+// file type bits of the unix file mode, which is used in cpio headers and Rock Ridge extensions
+const (
+	unixModeTypeMask = 0170000
+	unixModeSocket   = 0140000
+	unixModeSymlink  = 0120000
+	unixModeRegular  = 0100000
+	unixModeBlock    = 0060000
+	unixModeDir      = 0040000
+	unixModeChar     = 0020000
+	unixModeFifo     = 0010000
+)
+
+// isCpio checks if the header matches the magic bytes for cpio archives and starts with a
+// valid cpio header. The magic bytes of the binary format are only two bytes long, so that the
+// name size, the file type and the name of the first entry are checked as well, as far as they
+// are part of the header.
+func isCpio(header []byte) bool {
+	if !matchesMagicBytes(header, 0, magicBytesCpio) {
+		return false
+	}
+	c := &cpioWalker{r: bytes.NewReader(header)}
+	hdr, nameSize, _, headerLen, err := c.readFixedHeader()
+	if err != nil || nameSize < 1 || nameSize > cpioMaxNameLength {
+		return false
+	}
+
+	// the name is terminated by a NUL byte and must not contain further NUL bytes
+	name := header[headerLen:min(int64(len(header)), headerLen+nameSize)]
+	complete := int64(len(name)) == nameSize
+	if complete {
+		if name[nameSize-1] != 0 {
+			return false
+		}
+		name = name[:nameSize-1]
+	}
+	if bytes.IndexByte(name, 0) >= 0 {
+		return false
+	}
+
+	switch hdr.Mode & unixModeTypeMask {
+	case unixModeRegular, unixModeDir, unixModeSymlink, unixModeFifo, unixModeChar, unixModeBlock, unixModeSocket:
+		return true
+	case 0:
+		// the trailer of an empty archive has no file type
+		return complete && string(name) == cpioTrailer
+	default:
+		return false
+	}
+}
+
+// unpackCpio sets a timeout for the ctx and starts the cpio extraction from src to dst.
+func unpackCpio(ctx context.Context, t Target, dst string, src io.Reader, cfg *Config) error {
+	// prepare telemetry capturing
+	td := &TelemetryData{ExtractedType: fileExtensionCpio}
+	defer cfg.TelemetryHook()(ctx, td)
+	defer captureExtractionDuration(td, now())
+
+	// prepare reader
+	limitedReader := newLimitErrorReader(src, cfg.MaxInputSize())
+	defer captureInputSize(td, limitedReader)
+
+	// start extraction
+	return processCpio(ctx, t, limitedReader, dst, cfg.withCompressedInput(consumedInput(limitedReader)), td)
+}
+
+// processCpio extracts the cpio archive from src to dst
+func processCpio(ctx context.Context, t Target, src io.Reader, dst string, c *Config, td *TelemetryData) error {
+	return extract(ctx, t, dst, newCpioWalker(src, c), c, td)
+}
+
+// walkCpio returns a Walker for the cpio archive in src.
+func walkCpio(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return newCpioWalker(newLimitErrorReader(src, cfg.MaxInputSize()), cfg), nil
+}
+
+// newCpioWalker returns a walker for the cpio archive in src, which holds back hard links
+// without content according to the maximum of files in cfg.
+func newCpioWalker(src io.Reader, cfg *Config) *cpioWalker {
+	return &cpioWalker{
+		r:       src,
+		cfg:     cfg,
+		links:   make(map[cpioLinkKey]string),
+		pending: make(map[cpioLinkKey][]*cpioHeader),
+	}
+}
+
+// cpioHeader is the decoded header of an entry in a cpio archive
+type cpioHeader struct {
+	// Magic is the magic of the header, which identifies the format
+	Magic    string
+	Dev      uint64
+	Ino      uint64
+	Mode     uint32
+	Uid      int
+	Gid      int
+	Nlink    int
+	ModTime  time.Time
+	Size     int64
+	Name     string
+	Checksum uint32
+}
+
+// cpioLinkKey identifies the inode of an entry to detect hard links
+type cpioLinkKey struct {
+	dev uint64
+	ino uint64
+}
+
+// cpioWalker is a walker for cpio archives
+type cpioWalker struct {
+	r   io.Reader
+	cfg *Config
+
+	// entries is the number of entries, which have been read from the archive
+	entries int64
+
+	// data is the content of the current entry
+	data *io.LimitedReader
+
+	// padding is the number of bytes after the content of the current entry
+	padding int64
+
+	// links maps inodes to the name of the first extracted entry
+	links map[cpioLinkKey]string
+
+	// pending contains hard links without content in the newc format, which
+	// are stored before the entry that carries the content. The pending links
+	// are returned later on, so they count towards the maximum of files when
+	// they are read.
+	pending      map[cpioLinkKey][]*cpioHeader
+	pendingOrder []cpioLinkKey
+
+	// queue contains entries, which are returned before the next header is read
+	queue []*cpioEntry
+	done  bool
+}
+
+// Type returns the file extension for cpio archives
+func (c *cpioWalker) Type() string {
+	return fileExtensionCpio
+}
+
+// Next returns the next entry in the cpio archive
+func (c *cpioWalker) Next() (Entry, error) {
+	for {
+		if len(c.queue) > 0 {
+			ce := c.queue[0]
+			c.queue = c.queue[1:]
+			return ce, nil
+		}
+		if c.done {
+			return nil, io.EOF
+		}
+
+		// skip unread content of the previous entry
+		if err := c.skipData(); err != nil {
+			return nil, err
+		}
+
+		hdr, err := c.readHeader()
+		if err != nil {
+			return nil, err
+		}
+
+		// end of archive, return remaining hard links without content
+		if hdr.Name == cpioTrailer {
+			c.done = true
+			c.flushPending()
+			continue
+		}
+		c.entries++
+
+		ce := &cpioEntry{hdr: hdr, r: c.data}
+		switch hdr.Mode & unixModeTypeMask {
+		case unixModeSymlink:
+			if hdr.Size > cpioMaxNameLength {
+				return nil, fmt.Errorf("cpio: link target of %q exceeds maximum length", hdr.Name)
+			}
+			target, err := io.ReadAll(c.data)
+			if err != nil {
+				return nil, fmt.Errorf("cpio: cannot read link target of %q: %w", hdr.Name, err)
+			}
+			ce.linkname = string(target)
+
+		case unixModeRegular:
+			if hdr.Nlink <= 1 {
+				break
+			}
+			key := cpioLinkKey{dev: hdr.Dev, ino: hdr.Ino}
+
+			// inode has already been returned, the entry is a hard link
+			if target, found := c.links[key]; found {
+				ce.linkname = target
+				ce.hardlink = true
+				break
+			}
+
+			// the newc and crc format store the content with the last link
+			if hdr.Size == 0 && (hdr.Magic == cpioMagicNewc || hdr.Magic == cpioMagicCrc) {
+				if err := c.cfg.CheckMaxFiles(c.entries); err != nil {
+					return nil, fmt.Errorf("cpio: cannot hold back hard link %q: %w", hdr.Name, err)
+				}
+				if _, found := c.pending[key]; !found {
+					c.pendingOrder = append(c.pendingOrder, key)
+				}
+				c.pending[key] = append(c.pending[key], hdr)
+				continue
+			}
+
+			// entry carries the content, pending links refer to it
+			c.links[key] = hdr.Name
+			for _, p := range c.pending[key] {
+				c.queue = append(c.queue, &cpioEntry{hdr: p, linkname: hdr.Name, hardlink: true})
+			}
+			delete(c.pending, key)
+		}
+
+		if hdr.Magic == cpioMagicCrc && ce.IsRegular() {
+			ce.r = &cpioChecksumReader{r: c.data, name: hdr.Name, expected: hdr.Checksum}
+		}
+		return ce, nil
+	}
+}
+
+// flushPending queues hard links, for which no entry with content has been found.
+// The first link of each inode is returned as empty file.
+func (c *cpioWalker) flushPending() {
+	for _, key := range c.pendingOrder {
+		links := c.pending[key]
+		if len(links) == 0 {
+			continue
+		}
+		c.queue = append(c.queue, &cpioEntry{hdr: links[0], r: strings.NewReader("")})
+		for _, p := range links[1:] {
+			c.queue = append(c.queue, &cpioEntry{hdr: p, linkname: links[0].Name, hardlink: true})
+		}
+		delete(c.pending, key)
+	}
+	c.pendingOrder = nil
+}
+
+// skipData discards the unread content and padding of the current entry
+func (c *cpioWalker) skipData() error {
+	if c.data == nil {
+		return nil
+	}
+	if _, err := io.Copy(io.Discard, c.data); err != nil {
+		return fmt.Errorf("cpio: cannot skip content: %w", err)
+	}
+	if _, err := io.CopyN(io.Discard, c.r, c.padding); err != nil {
+		return fmt.Errorf("cpio: cannot skip padding: %w", err)
+	}
+	c.data = nil
+	c.padding = 0
+	return nil
+}
+
+// readHeader reads and decodes the next header and prepares the content reader
+func (c *cpioWalker) readHeader() (*cpioHeader, error) {
+	hdr, nameSize, alignment, headerLen, err := c.readFixedHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	// read name, which is terminated by a NUL byte and padded
+	if nameSize < 1 || nameSize > cpioMaxNameLength {
+		return nil, fmt.Errorf("cpio: invalid name size %d", nameSize)
+	}
+	name := make([]byte, nameSize+cpioPadding(headerLen+nameSize, alignment))
+	if _, err := io.ReadFull(c.r, name); err != nil {
+		return nil, fmt.Errorf("cpio: cannot read name: %w", err)
+	}
+	hdr.Name = string(bytes.TrimRight(name[:nameSize], "\x00"))
+
+	// prepare content reader
+	if hdr.Size < 0 {
+		return nil, fmt.Errorf("cpio: invalid size %d of %q", hdr.Size, hdr.Name)
+	}
+	c.data = &io.LimitedReader{R: c.r, N: hdr.Size}
+	c.padding = cpioPadding(hdr.Size, alignment)
+	return hdr, nil
+}
+
+// readFixedHeader reads and decodes the next header without the name. It returns the header,
+// the size of the name, the alignment of the format and the length of the header.
+func (c *cpioWalker) readFixedHeader() (hdr *cpioHeader, nameSize int64, alignment int64, headerLen int64, err error) {
+	magic := make([]byte, 6)
+	if _, err := io.ReadFull(c.r, magic); err != nil {
+		if err == io.EOF {
+			return nil, 0, 0, 0, io.ErrUnexpectedEOF
+		}
+		return nil, 0, 0, 0, fmt.Errorf("cpio: cannot read header: %w", err)
+	}
+
+	switch string(magic) {
+	case cpioMagicNewc, cpioMagicCrc:
+		hdr, nameSize, err = c.readNewcHeader(string(magic))
+		alignment, headerLen = 4, 110
+	case cpioMagicOdc:
+		hdr, nameSize, err = c.readOdcHeader()
+		alignment, headerLen = 1, 76
+	default:
+		hdr, nameSize, err = c.readBinaryHeader(magic)
+		alignment, headerLen = 2, 26
+	}
+	return hdr, nameSize, alignment, headerLen, err
+}
+
+// readNewcHeader decodes the remaining header in the newc and crc format, which
+// consists of 13 fields with 8 hexadecimal digits each.
+func (c *cpioWalker) readNewcHeader(magic string) (*cpioHeader, int64, error) {
+	buf := make([]byte, 13*8)
+	if _, err := io.ReadFull(c.r, buf); err != nil {
+		return nil, 0, fmt.Errorf("cpio: cannot read header: %w", err)
+	}
+	var fields [13]uint64
+	for i := range fields {
+		v, err := strconv.ParseUint(string(buf[i*8:(i+1)*8]), 16, 32)
+		if err != nil {
+			return nil, 0, fmt.Errorf("cpio: invalid header: %w", err)
+		}
+		fields[i] = v
+	}
+	return &cpioHeader{
+		Magic:    magic,
+		Ino:      fields[0],
+		Mode:     uint32(fields[1]),
+		Uid:      int(fields[2]),
+		Gid:      int(fields[3]),
+		Nlink:    int(fields[4]),
+		ModTime:  time.Unix(int64(fields[5]), 0),
+		Size:     int64(fields[6]),
+		Dev:      fields[7]<<32 | fields[8],
+		Checksum: uint32(fields[12]),
+	}, int64(fields[11]), nil
+}
+
+// readOdcHeader decodes the remaining header in the odc format, which consists
+// of octal numbers.
+func (c *cpioWalker) readOdcHeader() (*cpioHeader, int64, error) {
+	buf := make([]byte, 70)
+	if _, err := io.ReadFull(c.r, buf); err != nil {
+		return nil, 0, fmt.Errorf("cpio: cannot read header: %w", err)
+	}
+	var (
+		widths = []int{6, 6, 6, 6, 6, 6, 6, 11, 6, 11} // dev, ino, mode, uid, gid, nlink, rdev, mtime, namesize, filesize
+		fields [10]uint64
+		offset int
+	)
+	for i, w := range widths {
+		v, err := strconv.ParseUint(string(buf[offset:offset+w]), 8, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("cpio: invalid header: %w", err)
+		}
+		fields[i] = v
+		offset += w
+	}
+	return &cpioHeader{
+		Magic:   cpioMagicOdc,
+		Dev:     fields[0],
+		Ino:     fields[1],
+		Mode:    uint32(fields[2]),
+		Uid:     int(fields[3]),
+		Gid:     int(fields[4]),
+		Nlink:   int(fields[5]),
+		ModTime: time.Unix(int64(fields[7]), 0),
+		Size:    int64(fields[9]),
+	}, int64(fields[8]), nil
+}
+
+// readBinaryHeader decodes the header in the old binary format, which consists of
+// 13 16-bit words in the byte order of the creating machine.
+func (c *cpioWalker) readBinaryHeader(magic []byte) (*cpioHeader, int64, error) {
+	var order binary.ByteOrder
+	switch {
+	case binary.LittleEndian.Uint16(magic) == cpioMagicBinary:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint16(magic) == cpioMagicBinary:
+		order = binary.BigEndian
+	default:
+		return nil, 0, fmt.Errorf("cpio: invalid magic bytes %x", magic)
+	}
+	buf := make([]byte, 26)
+	copy(buf, magic)
+	if _, err := io.ReadFull(c.r, buf[len(magic):]); err != nil {
+		return nil, 0, fmt.Errorf("cpio: cannot read header: %w", err)
+	}
+	var words [13]uint32
+	for i := range words {
+		words[i] = uint32(order.Uint16(buf[i*2:]))
+	}
+	return &cpioHeader{
+		Magic:   string(magic),
+		Dev:     uint64(words[1]),
+		Ino:     uint64(words[2]),
+		Mode:    words[3],
+		Uid:     int(words[4]),
+		Gid:     int(words[5]),
+		Nlink:   int(words[6]),
+		ModTime: time.Unix(int64(words[8]<<16|words[9]), 0),
+		Size:    int64(words[11]<<16 | words[12]),
+	}, int64(words[10]), nil
+}
+
+// cpioPadding returns the number of bytes to align n to the given alignment
+func cpioPadding(n int64, alignment int64) int64 {
+	return (alignment - n%alignment) % alignment
+}
+
+// cpioChecksumReader verifies the checksum of the content of an entry in the crc format
+type cpioChecksumReader struct {
+	r        io.Reader
+	name     string
+	sum      uint32
+	expected uint32
+}
+
+// Read reads from the content and verifies the checksum at the end of the content
+func (c *cpioChecksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	for _, b := range p[:n] {
+		c.sum += uint32(b)
+	}
+	if err == io.EOF && c.sum != c.expected {
+		return n, fmt.Errorf("cpio: checksum mismatch for %q", c.name)
+	}
+	return n, err
+}
+
+// cpioEntry is an entry in a cpio archive
+type cpioEntry struct {
+	hdr      *cpioHeader
+	r        io.Reader
+	linkname string
+	hardlink bool
+}
+
+// Name returns the name of the entry
+func (c *cpioEntry) Name() string {
+	return c.hdr.Name
+}
+
+// Size returns the size of the entry
+func (c *cpioEntry) Size() int64 {
+	if c.hardlink {
+		return 0
+	}
+	return c.hdr.Size
+}
+
+// Mode returns the mode of the entry
+func (c *cpioEntry) Mode() fs.FileMode {
+	return unixFileMode(c.hdr.Mode)
+}
+
+// unixFileMode converts a unix file mode into a [fs.FileMode]
+func unixFileMode(m uint32) fs.FileMode {
+	mode := fs.FileMode(m & 0777)
+	if m&04000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if m&02000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if m&01000 != 0 {
+		mode |= fs.ModeSticky
+	}
+	switch m & unixModeTypeMask {
+	case unixModeDir:
+		mode |= fs.ModeDir
+	case unixModeSymlink:
+		mode |= fs.ModeSymlink
+	case unixModeFifo:
+		mode |= fs.ModeNamedPipe
+	case unixModeChar:
+		mode |= fs.ModeDevice | fs.ModeCharDevice
+	case unixModeBlock:
+		mode |= fs.ModeDevice
+	case unixModeSocket:
+		mode |= fs.ModeSocket
+	}
+	return mode
+}
+
+// Linkname returns the link target of a symlink or hard link
+func (c *cpioEntry) Linkname() string {
+	return c.linkname
+}
+
+// IsRegular returns true if the entry is a regular file
+func (c *cpioEntry) IsRegular() bool {
+	return c.hdr.Mode&unixModeTypeMask == unixModeRegular && !c.hardlink
+}
+
+// IsDir returns true if the entry is a directory
+func (c *cpioEntry) IsDir() bool {
+	return c.hdr.Mode&unixModeTypeMask == unixModeDir
+}
+
+// IsHardlink returns true if the entry is a hard link
+func (c *cpioEntry) IsHardlink() bool {
+	return c.hardlink
+}
+
+// IsSymlink returns true if the entry is a symlink
+func (c *cpioEntry) IsSymlink() bool {
+	return c.hdr.Mode&unixModeTypeMask == unixModeSymlink
+}
+
+// Open returns a reader for the entry
+func (c *cpioEntry) Open() (io.ReadCloser, error) {
+	if c.r == nil {
+		return &noopReaderCloser{strings.NewReader("")}, nil
+	}
+	return &noopReaderCloser{c.r}, nil
+}
+
+// Type returns the type of the entry
+func (c *cpioEntry) Type() fs.FileMode {
+	return c.Mode().Type()
+}
+
+// AccessTime returns the modification time, because cpio archives do not store access times
+func (c *cpioEntry) AccessTime() time.Time {
+	return c.hdr.ModTime
+}
+
+// ModTime returns the modification time of the entry
+func (c *cpioEntry) ModTime() time.Time {
+	return c.hdr.ModTime
+}
+
+// Sys returns the decoded cpio header of the entry
+func (c *cpioEntry) Sys() interface{} {
+	return c.hdr
+}
+
+// Gid returns the group id of the entry
+func (c *cpioEntry) Gid() int {
+	return c.hdr.Gid
+}
+
+// Uid returns the user id of the entry
+func (c *cpioEntry) Uid() int {
+	return c.hdr.Uid
+}
@@ -0,0 +1,302 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestUnpackCpio(t *testing.T) {
+	contents := []archiveContent{
+		{Name: "dir", Mode: fs.ModeDir | 0755},
+		{Name: "dir/file", Content: []byte("hello world"), Mode: 0644},
+		{Name: "dir/link", Linktarget: "file", Mode: fs.ModeSymlink | 0777},
+		{Name: "hardlink", Linktarget: "dir/file", Hardlink: true, Mode: 0644},
+		{Name: "odd", Content: []byte("odd"), Mode: 0600},
+	}
+
+	tests := []struct {
+		name         string
+		src          []byte
+		cfg          *extract.Config
+		expectedType string
+		expectError  bool
+	}{
+		{name: "newc", src: packCpio(t, "newc", contents), expectedType: "cpio"},
+		{name: "crc", src: packCpio(t, "crc", contents), expectedType: "cpio"},
+		{name: "odc", src: packCpio(t, "odc", contents), expectedType: "cpio"},
+		{name: "binary little endian", src: packCpio(t, "bin-le", contents), expectedType: "cpio"},
+		{name: "binary big endian", src: packCpio(t, "bin-be", contents), expectedType: "cpio"},
+		{name: "newc gzip", src: compressGzip(t, packCpio(t, "newc", contents)), expectedType: "cpio.gz"},
+		{name: "newc xz", src: compressXz(t, packCpio(t, "newc", contents)), expectedType: "cpio.xz"},
+		{name: "newc zstd", src: compressZstd(t, packCpio(t, "newc", contents)), expectedType: "cpio.zst"},
+		{
+			name: "hard link with content on last link",
+			src: packCpio(t, "newc", []archiveContent{
+				{Name: "dir", Mode: fs.ModeDir | 0755},
+				{Name: "hardlink", Linktarget: "dir/file", Hardlink: true, Mode: 0644},
+				{Name: "dir/link", Linktarget: "file", Mode: fs.ModeSymlink | 0777},
+				{Name: "dir/file", Content: []byte("hello world"), Mode: 0644},
+				{Name: "odd", Content: []byte("odd"), Mode: 0600},
+			}),
+			expectedType: "cpio",
+		},
+		{
+			name:        "checksum mismatch",
+			src:         corruptCpioChecksum(t, packCpio(t, "crc", contents)),
+			expectError: true,
+		},
+		{
+			name:        "truncated",
+			src:         packCpio(t, "newc", contents)[:200],
+			expectError: true,
+		},
+		{
+			name: "path traversal",
+			src: packCpio(t, "newc", []archiveContent{
+				{Name: "../escape", Content: []byte("hello world"), Mode: 0644},
+			}),
+			expectError: true,
+		},
+		{
+			name:        "max files",
+			src:         packCpio(t, "newc", contents),
+			cfg:         extract.NewConfig(extract.WithMaxFiles(2)),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var td *extract.TelemetryData
+			cfg := tc.cfg
+			if cfg == nil {
+				cfg = extract.NewConfig()
+			}
+			extract.WithTelemetryHook(func(ctx context.Context, d *extract.TelemetryData) { td = d })(cfg)
+
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackTo(context.Background(), tm, "", asIoReader(t, tc.src), cfg)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if td.ExtractedType != tc.expectedType {
+				t.Errorf("expected type %q, got %q", tc.expectedType, td.ExtractedType)
+			}
+			if td.ExtractedFiles != 2 || td.ExtractedDirs != 1 || td.ExtractedSymlinks != 1 || td.ExtractedHardlinks != 1 {
+				t.Errorf("unexpected telemetry data: %v", td)
+			}
+			for name, expected := range map[string]string{"dir/file": "hello world", "hardlink": "hello world", "odd": "odd"} {
+				data, err := fs.ReadFile(tm, name)
+				if err != nil {
+					t.Fatalf("error reading %q: %v", name, err)
+				}
+				if string(data) != expected {
+					t.Errorf("expected content %q for %q, got %q", expected, name, data)
+				}
+			}
+			target, err := tm.Readlink("dir/link")
+			if err != nil || target != "file" {
+				t.Errorf("expected link target %q, got %q (%v)", "file", target, err)
+			}
+		})
+	}
+}
+
+func TestUnpackCpioPendingHardlinks(t *testing.T) {
+	var contents []archiveContent
+	for i := range 10 {
+		contents = append(contents, archiveContent{Name: fmt.Sprintf("link%d", i), Linktarget: "file", Hardlink: true, Mode: 0644})
+	}
+	contents = append(contents, archiveContent{Name: "file", Content: []byte("hello world"), Mode: 0644})
+
+	// drop the entry with content and the trailer, so that the hard links are held back until the end
+	src := packCpio(t, "newc", contents)
+	src = src[:bytes.Index(src, []byte("file\x00"))-110]
+
+	cfg := extract.NewConfig(extract.WithMaxFiles(5))
+	err := extract.UnpackTo(context.Background(), extract.NewTargetMemory(), "", asIoReader(t, src), cfg)
+	if !errors.Is(err, extract.ErrMaxFilesExceeded) {
+		t.Fatalf("expected error %v, got %v", extract.ErrMaxFilesExceeded, err)
+	}
+}
+
+func TestCpioDetection(t *testing.T) {
+	valid := packCpio(t, "bin-le", []archiveContent{{Name: "file", Content: []byte("hello world"), Mode: 0644}})
+	invalidNameSize := bytes.Clone(valid)
+	binary.LittleEndian.PutUint16(invalidNameSize[20:], 0)
+	invalidMode := bytes.Clone(valid)
+	binary.LittleEndian.PutUint16(invalidMode[6:], 0170644)
+	unterminatedName := bytes.Clone(valid)
+	unterminatedName[30] = 'x'
+
+	tests := []struct {
+		name     string
+		src      []byte
+		expected bool
+	}{
+		{name: "binary", src: valid, expected: true},
+		{name: "empty archive", src: packCpio(t, "bin-be", nil), expected: true},
+		{name: "random data with binary magic", src: append([]byte{0xc7, 0x71}, bytes.Repeat([]byte{0xff}, 510)...)},
+		{name: "binary magic in text", src: []byte("\xc7q" + strings.Repeat("some text, ", 20))},
+		{name: "invalid name size", src: invalidNameSize},
+		{name: "invalid file type", src: invalidMode},
+		{name: "unterminated name", src: unterminatedName},
+		{name: "too short", src: valid[:20]},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := extract.List(context.Background(), asIoReader(t, tc.src), nil)
+			if detected := !errors.Is(err, extract.ErrNoExtractorFound); detected != tc.expected {
+				t.Errorf("expected detection %v, got error %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestListCpio(t *testing.T) {
+	src := packCpio(t, "newc", []archiveContent{
+		{Name: "hardlink", Linktarget: "file", Hardlink: true, Mode: 0644},
+		{Name: "file", Content: []byte("hello world"), Mode: 0644, Uid: 1000, Gid: 100},
+	})
+	entries, err := extract.List(context.Background(), asIoReader(t, src), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d: %v", len(entries), entries)
+	}
+	if entries[0].Name != "file" || entries[0].Type != extract.EntryTypeRegular || entries[0].Size != 11 || entries[0].Uid != 1000 || entries[0].Gid != 100 {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+	if entries[1].Name != "hardlink" || entries[1].Type != extract.EntryTypeHardlink || entries[1].Linkname != "file" {
+		t.Errorf("unexpected entry: %+v", entries[1])
+	}
+}
+
+// packCpio creates a cpio archive in the given format (newc, crc, odc, bin-le, bin-be)
+// with the given content. Hard links share the inode of their target. In the newc and crc
+// format, the content of hard linked files is stored with the last link, otherwise with
+// every link.
+func packCpio(t *testing.T, format string, content []archiveContent) []byte {
+	t.Helper()
+
+	// assign inodes and count links
+	inodes := make(map[string]int)
+	nlinks := make(map[int]int)
+	data := make(map[int][]byte)
+	lastLink := make(map[int]string)
+	for i, c := range content {
+		ino := i + 1
+		if c.Hardlink {
+			ino = inodes[c.Linktarget]
+		}
+		if ino == 0 {
+			// target follows the link
+			for j, o := range content {
+				if o.Name == c.Linktarget {
+					ino = j + 1
+				}
+			}
+		}
+		inodes[c.Name] = ino
+		nlinks[ino]++
+		lastLink[ino] = c.Name
+		if !c.Hardlink && c.Mode.IsRegular() {
+			data[ino] = c.Content
+		}
+	}
+
+	b := &bytes.Buffer{}
+	write := func(name string, mode uint32, ino, nlink, uid, gid int, body []byte) {
+		nameSize := len(name) + 1
+		switch format {
+		case "newc", "crc":
+			magic := "070701"
+			var checksum uint32
+			if format == "crc" {
+				magic = "070702"
+				for _, v := range body {
+					checksum += uint32(v)
+				}
+			}
+			fmt.Fprintf(b, "%s%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+				magic, ino, mode, uid, gid, nlink, 0, len(body), 0, 0, 0, 0, nameSize, checksum)
+			b.WriteString(name + "\x00")
+			b.Write(make([]byte, (4-(110+nameSize)%4)%4))
+			b.Write(body)
+			b.Write(make([]byte, (4-len(body)%4)%4))
+		case "odc":
+			fmt.Fprintf(b, "070707%06o%06o%06o%06o%06o%06o%06o%011o%06o%011o",
+				0, ino, mode, uid, gid, nlink, 0, 0, nameSize, len(body))
+			b.WriteString(name + "\x00")
+			b.Write(body)
+		case "bin-le", "bin-be":
+			var order binary.AppendByteOrder = binary.LittleEndian
+			if format == "bin-be" {
+				order = binary.BigEndian
+			}
+			words := []uint16{070707, 0, uint16(ino), uint16(mode), uint16(uid), uint16(gid), uint16(nlink), 0, 0, 0, uint16(nameSize), uint16(len(body) >> 16), uint16(len(body))}
+			for _, w := range words {
+				b.Write(order.AppendUint16(nil, w))
+			}
+			b.WriteString(name + "\x00")
+			b.Write(make([]byte, nameSize%2))
+			b.Write(body)
+			b.Write(make([]byte, len(body)%2))
+		default:
+			t.Fatalf("unsupported cpio format: %s", format)
+		}
+	}
+
+	for _, c := range content {
+		ino := inodes[c.Name]
+		mode := uint32(c.Mode.Perm())
+		var body []byte
+		switch {
+		case c.Mode.IsDir():
+			mode |= 0040000
+		case c.Mode&fs.ModeSymlink != 0:
+			mode |= 0120000
+			body = []byte(c.Linktarget)
+		case c.Mode.IsRegular():
+			mode |= 0100000
+			if (format != "newc" && format != "crc") || lastLink[ino] == c.Name {
+				body = data[ino]
+			}
+		default:
+			t.Fatalf("unsupported file mode: %v", c.Mode)
+		}
+		write(c.Name, mode, ino, nlinks[ino], c.Uid, c.Gid, body)
+	}
+	write("TRAILER!!!", 0, 0, 1, 0, 0, nil)
+	return b.Bytes()
+}
+
+// corruptCpioChecksum changes the checksum of the first regular file in a cpio archive in crc format
+func corruptCpioChecksum(t *testing.T, src []byte) []byte {
+	t.Helper()
+	src = bytes.Clone(src)
+	idx := bytes.Index(src, []byte("hello world"))
+	if idx < 0 {
+		t.Fatal(errors.New("content not found"))
+	}
+	src[idx] = 'H'
+	return src
+}
@@ -0,0 +1,128 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// fileExtensionDeb is the file extension for Debian packages
+const fileExtensionDeb = "deb"
+
+// magicBytesDeb are the magic bytes for Debian packages, which are ar archives
+// with debian-binary as first member.
+//
+// https://man7.org/linux/man-pages/man5/deb.5.html
+var magicBytesDeb = [][]byte{
+	[]byte("!<arch>\ndebian-binary"),
+}
+
+// debDataArchive is the name prefix of the member that contains the installed files
+const debDataArchive = "data.tar"
+
+// debDecompressors maps the file extensions of the data archive to decompression functions.
+// An uncompressed data archive has no file extension.
+var debDecompressors = map[string]decompressionFunc{
+	"":                       nil,
+	"." + fileExtensionBzip2: decompressBz2Stream,
+	"." + fileExtensionGZip:  decompressGZipStream,
+	"." + fileExtensionXz:    decompressXzStream,
+	"." + fileExtensionZstd:  decompressZstdStream,
+}
+
+// isDeb checks if the header matches the magic bytes for Debian packages
+func isDeb(header []byte) bool {
+	return matchesMagicBytes(header, 0, magicBytesDeb)
+}
+
+// unpackDeb sets a timeout for the ctx and extracts the content of the data archive
+// of the Debian package in src to dst.
+func unpackDeb(ctx context.Context, t Target, dst string, src io.Reader, cfg *Config) error {
+	// prepare telemetry capturing
+	td := &TelemetryData{ExtractedType: fileExtensionDeb}
+	defer cfg.TelemetryHook()(ctx, td)
+	defer captureExtractionDuration(td, now())
+
+	// prepare reader
+	limitedReader := newLimitErrorReader(src, cfg.MaxInputSize())
+	defer captureInputSize(td, limitedReader)
+
+	// open data archive
+	w, err := newDebWalker(limitedReader)
+	if err != nil {
+		return handleError(cfg, td, "cannot open deb package", err)
+	}
+	defer w.Close()
+
+	// start extraction
+	return extract(ctx, t, dst, w, cfg.withCompressedInput(consumedInput(limitedReader)), td)
+}
+
+// walkDeb returns a Walker for the data archive of the Debian package in src.
+func walkDeb(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return newDebWalker(newLimitErrorReader(src, cfg.MaxInputSize()))
+}
+
+// newDebWalker reads the members of the Debian package in src until the data archive
+// is found and returns a walker for the content of the data archive.
+func newDebWalker(src io.Reader) (*debWalker, error) {
+	aw := &arWalker{r: src}
+	for i := 0; ; i++ {
+		ae, err := aw.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("deb: %s archive not found", debDataArchive)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// first member must be debian-binary
+		if i == 0 && ae.Name() != "debian-binary" {
+			return nil, fmt.Errorf("deb: invalid package, first member is %q", ae.Name())
+		}
+
+		// skip debian-binary and control archive
+		name := ae.Name()
+		if !strings.HasPrefix(name, debDataArchive) {
+			continue
+		}
+
+		// prepare decompression of data archive
+		decFunc, found := debDecompressors[strings.TrimPrefix(name, debDataArchive)]
+		if !found {
+			return nil, fmt.Errorf("deb: unsupported data archive %q", name)
+		}
+		fin, err := ae.Open()
+		if err != nil {
+			return nil, err
+		}
+		var stream io.Reader = fin
+		if decFunc != nil {
+			if stream, err = decFunc(fin); err != nil {
+				return nil, fmt.Errorf("deb: cannot decompress %q: %w", name, err)
+			}
+		}
+		return &debWalker{tarWalker: tarWalker{tr: tar.NewReader(stream)}, stream: stream}, nil
+	}
+}
+
+// debWalker is a walker for the data archive of a Debian package
+type debWalker struct {
+	tarWalker
+	stream io.Reader
+}
+
+// Type returns the file extension for Debian packages
+func (d *debWalker) Type() string {
+	return fileExtensionDeb
+}
+
+// Close closes the decompressed stream of the data archive.
+func (d *debWalker) Close() error {
+	return closeStream(d.stream)
+}
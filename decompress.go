@@ -4,14 +4,17 @@
 package extract
 
 import (
+	"archive/tar"
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -27,6 +30,11 @@ func decompress(ctx context.Context, t Target, dst string, src io.Reader, cfg *C
 	defer cfg.TelemetryHook()(ctx, m)
 	defer captureExtractionDuration(m, now())
 
+	// check the digest algorithms before any file is created
+	if err := checkDigests(cfg.Digests()); err != nil {
+		return err
+	}
+
 	// limit input size
 	limitedReader := newLimitErrorReader(src, cfg.MaxInputSize())
 	defer captureInputSize(m, limitedReader)
@@ -47,7 +55,11 @@ func decompress(ctx context.Context, t Target, dst string, src io.Reader, cfg *C
 	}
 
 	// convert to peek header
-	headerReader, err := newHeaderReader(decompressedStream, maxHeaderLength)
+	available, err := cfg.extractors()
+	if err != nil {
+		return handleError(cfg, m, "cannot determine available extractors", err)
+	}
+	headerReader, err := newHeaderReader(decompressedStream, available.maxHeaderLength())
 	if err != nil {
 		return handleError(cfg, m, "cannot read uncompressed header", err)
 	}
@@ -57,14 +69,20 @@ func decompress(ctx context.Context, t Target, dst string, src io.Reader, cfg *C
 		return handleError(cfg, m, "context error", err)
 	}
 
-	// check if uncompressed stream is tar
+	// check if uncompressed stream is tar or cpio
 	headerBytes := headerReader.PeekHeader()
 
 	// check for tar header
 	checkUntar := !cfg.NoUntarAfterDecompression()
 	if checkUntar && isTar(headerBytes) {
 		m.ExtractedType = fmt.Sprintf("tar.%s", fileExt) // combine types
-		return processTar(ctx, t, headerReader, dst, cfg, m)
+		return processTar(ctx, t, headerReader, dst, cfg.withCompressedInput(consumedInput(limitedReader)), m)
+	}
+
+	// check for cpio header
+	if checkUntar && isCpio(headerBytes) {
+		m.ExtractedType = fmt.Sprintf("%s.%s", fileExtensionCpio, fileExt) // combine types
+		return processCpio(ctx, t, headerReader, dst, cfg.withCompressedInput(consumedInput(limitedReader)), m)
 	}
 
 	// determine name and decompress content
@@ -74,18 +92,238 @@ func decompress(ctx context.Context, t Target, dst string, src io.Reader, cfg *C
 	}
 	dst, outputName := determineOutputName(t, dst, inputName, fmt.Sprintf(".%s", fileExt))
 	cfg.Logger().Debug("determined output name", "name", outputName)
-	n, err := createFile(t, dst, outputName, headerReader, cfg.CustomDecompressFileMode(), cfg.MaxExtractionSize(), cfg)
+	ratioReader := newCompressionRatioReader(headerReader, cfg, -1, consumedInput(limitedReader), 0)
+	cfg, verifier, err := verifyDigests(cfg)
+	if err != nil {
+		return err
+	}
+	ei := EntryInfo{Name: outputName, Size: -1, Mode: cfg.CustomDecompressFileMode(), Type: EntryTypeRegular}
+	n, err := createFile(ctx, t, dst, ei, ratioReader, cfg.MaxExtractionSize(), cfg, m)
 	m.ExtractionSize = n
 	if err != nil {
 		return handleError(cfg, m, "cannot create file", err)
 	}
 	m.ExtractedFiles++
 
+	// check that all files with an expected digest are extracted
+	if err := verifier.checkMissing(cfg); err != nil {
+		return handleError(cfg, m, "expected files missing", err)
+	}
+
 	// finished
 	return nil
 
 }
 
+// walkDecompressed decompresses src with decFunc and returns a Walker for the decompressed
+// content. If the decompressed content is a tar archive, a walker for the tar archive is returned.
+// Otherwise, the walker returns the decompressed content as single entry.
+func walkDecompressed(ctx context.Context, src io.Reader, cfg *Config, decFunc decompressionFunc, fileExt string) (Walker, error) {
+	// limit input size
+	limitedReader := newLimitErrorReader(src, cfg.MaxInputSize())
+
+	// start decompression
+	decompressedStream, err := decFunc(limitedReader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot start decompression: %w", err)
+	}
+
+	// check if context is canceled
+	if err := ctx.Err(); err != nil {
+		closeStream(decompressedStream)
+		return nil, err
+	}
+
+	// convert to peek header
+	available, err := cfg.extractors()
+	if err != nil {
+		closeStream(decompressedStream)
+		return nil, err
+	}
+	headerReader, err := newHeaderReader(decompressedStream, available.maxHeaderLength())
+	if err != nil {
+		closeStream(decompressedStream)
+		return nil, fmt.Errorf("cannot read uncompressed header: %w", err)
+	}
+
+	// check for tar or cpio header
+	if !cfg.NoUntarAfterDecompression() {
+		switch header := headerReader.PeekHeader(); {
+		case isTar(header):
+			return &decompressedArchiveWalker{
+				Walker:  &tarWalker{tr: tar.NewReader(headerReader)},
+				stream:  decompressedStream,
+				fileExt: fileExt,
+			}, nil
+		case isCpio(header):
+			return &decompressedArchiveWalker{
+				Walker:  newCpioWalker(headerReader, cfg),
+				stream:  decompressedStream,
+				fileExt: fileExt,
+			}, nil
+		}
+	}
+
+	// determine name of the decompressed content
+	inputName := ""
+	if f, ok := src.(*os.File); ok {
+		inputName = filepath.Base(f.Name())
+	}
+	_, outputName := determineOutputName(nil, "", inputName, fmt.Sprintf(".%s", fileExt))
+
+	return &decompressedWalker{
+		entry: &decompressedEntry{
+			name:   outputName,
+			mode:   cfg.CustomDecompressFileMode(),
+			reader: headerReader,
+		},
+		stream:  decompressedStream,
+		fileExt: fileExt,
+	}, nil
+}
+
+// closeStream closes the decompressed stream, if it implements io.Closer.
+func closeStream(stream io.Reader) error {
+	if closer, ok := stream.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// decompressedArchiveWalker is a walker for tar or cpio archives in a compressed stream.
+type decompressedArchiveWalker struct {
+	Walker
+	stream  io.Reader
+	fileExt string
+}
+
+// Type returns the combined file extension, e.g. tar.gz
+func (d *decompressedArchiveWalker) Type() string {
+	return fmt.Sprintf("%s.%s", d.Walker.Type(), d.fileExt)
+}
+
+// Close closes the decompressed stream.
+func (d *decompressedArchiveWalker) Close() error {
+	return closeStream(d.stream)
+}
+
+// remainingStream returns the decompressed stream, which is not read by the walker, so that
+// the trailer of the compressed stream can be verified.
+func (d *decompressedArchiveWalker) remainingStream() io.Reader {
+	return d.stream
+}
+
+// decompressedWalker is a walker for a decompressed stream, which
+// returns the decompressed content as single entry.
+type decompressedWalker struct {
+	entry   *decompressedEntry
+	stream  io.Reader
+	fileExt string
+	done    bool
+}
+
+// Type returns the file extension of the compression format
+func (d *decompressedWalker) Type() string {
+	return d.fileExt
+}
+
+// Next returns the decompressed content on first call and io.EOF afterwards.
+func (d *decompressedWalker) Next() (Entry, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+	d.done = true
+	return d.entry, nil
+}
+
+// Close closes the decompressed stream.
+func (d *decompressedWalker) Close() error {
+	return closeStream(d.stream)
+}
+
+// decompressedEntry is the single entry of a decompressed stream
+type decompressedEntry struct {
+	name   string
+	mode   fs.FileMode
+	reader io.Reader
+}
+
+// Name returns the name of the decompressed content
+func (d *decompressedEntry) Name() string {
+	return d.name
+}
+
+// Size returns -1, because the size of the decompressed content is unknown
+// before the stream is fully decompressed
+func (d *decompressedEntry) Size() int64 {
+	return -1
+}
+
+// Mode returns the configured file mode for decompressed files
+func (d *decompressedEntry) Mode() fs.FileMode {
+	return d.mode
+}
+
+// Linkname returns an empty string, because the entry is a regular file
+func (d *decompressedEntry) Linkname() string {
+	return ""
+}
+
+// IsRegular returns always true
+func (d *decompressedEntry) IsRegular() bool {
+	return true
+}
+
+// IsDir returns always false
+func (d *decompressedEntry) IsDir() bool {
+	return false
+}
+
+// IsHardlink returns always false
+func (d *decompressedEntry) IsHardlink() bool {
+	return false
+}
+
+// IsSymlink returns always false
+func (d *decompressedEntry) IsSymlink() bool {
+	return false
+}
+
+// Open returns a reader for the decompressed content
+func (d *decompressedEntry) Open() (io.ReadCloser, error) {
+	return &noopReaderCloser{d.reader}, nil
+}
+
+// Type returns the type of the entry
+func (d *decompressedEntry) Type() fs.FileMode {
+	return d.mode.Type()
+}
+
+// AccessTime returns the zero time, because compressed streams do not provide it
+func (d *decompressedEntry) AccessTime() time.Time {
+	return time.Time{}
+}
+
+// ModTime returns the zero time, because compressed streams do not provide it
+func (d *decompressedEntry) ModTime() time.Time {
+	return time.Time{}
+}
+
+// Sys returns nil
+func (d *decompressedEntry) Sys() interface{} {
+	return nil
+}
+
+// Gid returns the group ID of the current process.
+func (d *decompressedEntry) Gid() int {
+	return os.Getegid()
+}
+
+// Uid returns the user ID of the current process.
+func (d *decompressedEntry) Uid() int {
+	return os.Getuid()
+}
+
 // init initializes the	extractor package and prepares the filename restriction regex
 func init() {
 	namingRestrictions = []nameRestriction{
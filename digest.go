@@ -0,0 +1,117 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/fs"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// DigestSHA256 is the name of the SHA-256 digest algorithm.
+	DigestSHA256 = "sha256"
+
+	// DigestSHA512 is the name of the SHA-512 digest algorithm.
+	DigestSHA512 = "sha512"
+
+	// DigestBLAKE2b is the name of the BLAKE2b-512 digest algorithm.
+	DigestBLAKE2b = "blake2b"
+
+	// DigestCRC32 is the name of the CRC-32 (IEEE) checksum algorithm.
+	DigestCRC32 = "crc32"
+)
+
+// DigestAlgorithms returns the names of all supported digest algorithms.
+func DigestAlgorithms() []string {
+	return []string{DigestSHA256, DigestSHA512, DigestBLAKE2b, DigestCRC32}
+}
+
+// ManifestEntry describes a file, which is extracted with [WithDigests].
+type ManifestEntry struct {
+	// Path is the slash separated path of the file relative to the destination
+	Path string `json:"path"`
+
+	// Size is the number of bytes written to the file
+	Size int64 `json:"size"`
+
+	// Mode is the file mode of the file from the archive
+	Mode fs.FileMode `json:"mode"`
+
+	// Digests holds the hex encoded digests of the written bytes by algorithm
+	Digests map[string]string `json:"digests"`
+}
+
+// newHash returns a new hash for algorithm.
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case DigestSHA256:
+		return sha256.New(), nil
+	case DigestSHA512:
+		return sha512.New(), nil
+	case DigestBLAKE2b:
+		return blake2b.New512(nil)
+	case DigestCRC32:
+		return crc32.NewIEEE(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDigest, algorithm)
+	}
+}
+
+// checkDigests returns an error, if one of the algorithms is not supported.
+func checkDigests(algorithms []string) error {
+	for _, a := range algorithms {
+		if _, err := newHash(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// digester computes the digests of the content of a file, while it is written.
+type digester struct {
+	algorithms []string
+	hashes     []hash.Hash
+}
+
+// newDigester returns a digester for algorithms, or nil if no algorithm is given.
+func newDigester(algorithms []string) (*digester, error) {
+	if len(algorithms) == 0 {
+		return nil, nil
+	}
+	d := &digester{algorithms: algorithms}
+	for _, a := range algorithms {
+		h, err := newHash(a)
+		if err != nil {
+			return nil, err
+		}
+		d.hashes = append(d.hashes, h)
+	}
+	return d, nil
+}
+
+// reader returns a reader, which reads from r and adds the read bytes to the digests.
+func (d *digester) reader(r io.Reader) io.Reader {
+	w := make([]io.Writer, len(d.hashes))
+	for i, h := range d.hashes {
+		w[i] = h
+	}
+	return io.TeeReader(r, io.MultiWriter(w...))
+}
+
+// sums returns the hex encoded digests by algorithm.
+func (d *digester) sums() map[string]string {
+	sums := make(map[string]string, len(d.hashes))
+	for i, h := range d.hashes {
+		sums[d.algorithms[i]] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums
+}
@@ -0,0 +1,141 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestDigests(t *testing.T) {
+	inner := packZip(t, []archiveContent{{Name: "nested", Content: []byte("nested"), Mode: 0644}})
+	archive := packTar(t, []archiveContent{
+		{Name: "dir/", Mode: fs.ModeDir | 0755},
+		{Name: "dir/a.txt", Content: []byte("hello"), Mode: 0640},
+		{Name: "inner.zip", Content: inner, Mode: 0644},
+		{Name: "link", Linktarget: "dir/a.txt", Mode: fs.ModeSymlink | 0777},
+	})
+
+	sha256sum := func(b []byte) string { s := sha256.Sum256(b); return hex.EncodeToString(s[:]) }
+	sha512sum := func(b []byte) string { s := sha512.Sum512(b); return hex.EncodeToString(s[:]) }
+	blake2bsum := func(b []byte) string { s := blake2b.Sum512(b); return hex.EncodeToString(s[:]) }
+	crc32sum := func(b []byte) string {
+		h := crc32.NewIEEE()
+		h.Write(b)
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	tests := []struct {
+		name        string
+		opts        []extract.ConfigOption
+		expected    []extract.ManifestEntry
+		expectedErr error
+	}{
+		{
+			name: "no digests",
+		},
+		{
+			name: "all algorithms",
+			opts: []extract.ConfigOption{extract.WithDigests(extract.DigestAlgorithms()...)},
+			expected: []extract.ManifestEntry{
+				{Path: "dir/a.txt", Size: 5, Mode: 0640, Digests: map[string]string{
+					"sha256": sha256sum([]byte("hello")), "sha512": sha512sum([]byte("hello")),
+					"blake2b": blake2bsum([]byte("hello")), "crc32": crc32sum([]byte("hello")),
+				}},
+				{Path: "inner.zip", Size: int64(len(inner)), Mode: 0644, Digests: map[string]string{
+					"sha256": sha256sum(inner), "sha512": sha512sum(inner),
+					"blake2b": blake2bsum(inner), "crc32": crc32sum(inner),
+				}},
+			},
+		},
+		{
+			name: "nested archives",
+			opts: []extract.ConfigOption{extract.WithDigests(extract.DigestSHA256), extract.WithRecursiveExtraction(1)},
+			expected: []extract.ManifestEntry{
+				{Path: "dir/a.txt", Size: 5, Mode: 0640, Digests: map[string]string{"sha256": sha256sum([]byte("hello"))}},
+				{Path: "inner.zip", Size: int64(len(inner)), Mode: 0644, Digests: map[string]string{"sha256": sha256sum(inner)}},
+				{Path: "inner.zip.d/nested", Size: 6, Mode: 0644, Digests: map[string]string{"sha256": sha256sum([]byte("nested"))}},
+			},
+		},
+		{
+			name: "digests of filtered content",
+			opts: []extract.ConfigOption{extract.WithDigests(extract.DigestSHA256), extract.WithPatterns("dir/*"), extract.WithContentFilter(
+				func(_ context.Context, _ extract.EntryInfo, r io.Reader) (io.Reader, error) {
+					b, err := io.ReadAll(r)
+					return strings.NewReader(strings.ToUpper(string(b))), err
+				}),
+			},
+			expected: []extract.ManifestEntry{
+				{Path: "dir/a.txt", Size: 5, Mode: 0640, Digests: map[string]string{"sha256": sha256sum([]byte("HELLO"))}},
+			},
+		},
+		{
+			name:        "unsupported algorithm",
+			opts:        []extract.ConfigOption{extract.WithDigests("md5")},
+			expectedErr: extract.ErrUnsupportedDigest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var td *extract.TelemetryData
+			opts := append([]extract.ConfigOption{extract.WithTelemetryHook(func(_ context.Context, d *extract.TelemetryData) { td = d })}, tc.opts...)
+			err := extract.Unpack(context.Background(), t.TempDir(), bytes.NewReader(archive), extract.NewConfig(opts...))
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(td.Manifest) != len(tc.expected) {
+				t.Fatalf("expected %d manifest entries, got %d: %v", len(tc.expected), len(td.Manifest), td.Manifest)
+			}
+			for i, e := range tc.expected {
+				got := td.Manifest[i]
+				if got.Path != e.Path || got.Size != e.Size || got.Mode != e.Mode {
+					t.Errorf("expected entry %s (%d bytes, %s), got %s (%d bytes, %s)", e.Path, e.Size, e.Mode, got.Path, got.Size, got.Mode)
+				}
+				for alg, sum := range e.Digests {
+					if got.Digests[alg] != sum {
+						t.Errorf("expected %s digest of %s to be %s, got %s", alg, e.Path, sum, got.Digests[alg])
+					}
+				}
+				if len(got.Digests) != len(e.Digests) {
+					t.Errorf("expected %d digests for %s, got %d", len(e.Digests), e.Path, len(got.Digests))
+				}
+			}
+		})
+	}
+}
+
+func TestDigestsDecompress(t *testing.T) {
+	var td *extract.TelemetryData
+	cfg := extract.NewConfig(
+		extract.WithDigests(extract.DigestSHA256),
+		extract.WithTelemetryHook(func(_ context.Context, d *extract.TelemetryData) { td = d }),
+	)
+	tm := extract.NewTargetMemory()
+	if err := extract.UnpackTo(context.Background(), tm, "", bytes.NewReader(compressGzip(t, []byte("hello"))), cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sum := sha256.Sum256([]byte("hello"))
+	if len(td.Manifest) != 1 || td.Manifest[0].Size != 5 || td.Manifest[0].Digests[extract.DigestSHA256] != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected manifest: %v", td.Manifest)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"context"
+	"fmt"
+	"path"
+)
+
+// EntryHook is a function, which decides for each entry of an archive, if it is extracted,
+// skipped, renamed or if the extraction is aborted. The hook is called before the entry is
+// created in the target, after the path rewrite and the patterns are applied. A returned
+// error is handled like any other extraction error.
+type EntryHook func(ctx context.Context, e EntryInfo) (Decision, error)
+
+// Action is the action of a [Decision].
+type Action int
+
+const (
+	// ActionExtract extracts the entry.
+	ActionExtract Action = iota
+
+	// ActionSkip skips the entry.
+	ActionSkip
+
+	// ActionRename extracts the entry with the name of the [Decision].
+	ActionRename
+
+	// ActionAbort aborts the extraction with [ErrAbortedByEntryHook].
+	ActionAbort
+)
+
+// String returns a string representation of [Action].
+func (a Action) String() string {
+	switch a {
+	case ActionExtract:
+		return "extract"
+	case ActionSkip:
+		return "skip"
+	case ActionRename:
+		return "rename"
+	case ActionAbort:
+		return "abort"
+	default:
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+}
+
+// Decision is returned by an [EntryHook] to decide how an entry is handled. The zero value
+// extracts the entry.
+type Decision struct {
+	// Action is the action, which is performed for the entry.
+	Action Action
+
+	// Name is the new name of the entry for [ActionRename]. The new name is still subject
+	// to all security checks.
+	Name string
+}
+
+// entryDecisions applies the decisions of an [EntryHook] to the entries of an archive and keeps
+// track of renamed entries, so that links to them are adjusted.
+type entryDecisions struct {
+	hook    EntryHook
+	renamed map[string]string
+}
+
+// newEntryDecisions returns the entryDecisions for the entry hook of cfg, or nil if no entry
+// hook is configured.
+func newEntryDecisions(cfg *Config) *entryDecisions {
+	if cfg.EntryHook() == nil {
+		return nil
+	}
+	return &entryDecisions{hook: cfg.EntryHook(), renamed: map[string]string{}}
+}
+
+// decide calls the entry hook for ae and returns the entry, which is extracted, or nil and
+// the action, if the entry is skipped or the extraction is aborted.
+func (d *entryDecisions) decide(ctx context.Context, ae Entry) (Entry, Action, error) {
+	decision, err := d.hook(ctx, newEntryInfo(ae))
+	if err != nil {
+		return nil, ActionSkip, fmt.Errorf("entry hook failed for %s: %w", ae.Name(), err)
+	}
+
+	switch decision.Action {
+	case ActionExtract:
+		if len(d.renamed) > 0 && (ae.IsHardlink() || ae.IsSymlink()) {
+			ae, err = renameEntry(ae, ae.Name(), d.rename)
+		}
+		return ae, ActionExtract, err
+
+	case ActionSkip:
+		return nil, ActionSkip, nil
+
+	case ActionRename:
+		if len(decision.Name) == 0 {
+			return nil, ActionSkip, fmt.Errorf("entry hook returned empty name for %s", ae.Name())
+		}
+		renamed, err := renameEntry(ae, decision.Name, d.rename)
+		if err != nil {
+			return nil, ActionSkip, err
+		}
+		d.renamed[path.Clean(ae.Name())] = path.Clean(decision.Name)
+		return renamed, ActionRename, nil
+
+	case ActionAbort:
+		return nil, ActionAbort, fmt.Errorf("%w: %s", ErrAbortedByEntryHook, ae.Name())
+
+	default:
+		return nil, ActionSkip, fmt.Errorf("entry hook returned invalid action %s for %s", decision.Action, ae.Name())
+	}
+}
+
+// rename returns the new name of a renamed entry, or name, if the entry is not renamed.
+func (d *entryDecisions) rename(name string) (string, bool) {
+	if renamed, ok := d.renamed[path.Clean(name)]; ok {
+		return renamed, true
+	}
+	return name, true
+}
@@ -0,0 +1,149 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestEntryHook(t *testing.T) {
+	b := new(bytes.Buffer)
+	w := tar.NewWriter(b)
+	for _, e := range []struct {
+		hdr     tar.Header
+		content []byte
+	}{
+		{hdr: tar.Header{Name: "README", Mode: 0644, Typeflag: tar.TypeReg}, content: []byte("readme")},
+		{hdr: tar.Header{Name: "big.bin", Mode: 0755, Typeflag: tar.TypeReg}, content: bytes.Repeat([]byte{1}, 1024)},
+		{hdr: tar.Header{Name: "setuid", Mode: 04755, Typeflag: tar.TypeReg}, content: []byte("setuid")},
+		{hdr: tar.Header{Name: "link", Linkname: "README", Mode: 0777, Typeflag: tar.TypeSymlink}},
+		{hdr: tar.Header{Name: "hardlink", Linkname: "README", Mode: 0644, Typeflag: tar.TypeLink}},
+	} {
+		e.hdr.Size = int64(len(e.content))
+		if err := w.WriteHeader(&e.hdr); err != nil {
+			t.Fatalf("error writing tar header: %v", err)
+		}
+		if _, err := w.Write(e.content); err != nil {
+			t.Fatalf("error writing tar data: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("error closing tar writer: %v", err)
+	}
+	archive := b.Bytes()
+
+	policy := func(_ context.Context, e extract.EntryInfo) (extract.Decision, error) {
+		switch {
+		case e.Mode&fs.ModeSetuid != 0:
+			return extract.Decision{Action: extract.ActionSkip}, nil
+		case e.Size > 512:
+			return extract.Decision{Action: extract.ActionSkip}, nil
+		case e.Name == "README":
+			return extract.Decision{Action: extract.ActionRename, Name: "README.txt"}, nil
+		}
+		return extract.Decision{}, nil
+	}
+
+	tests := []struct {
+		name          string
+		cfg           *extract.Config
+		expected      map[string]string
+		expectedLinks map[string]string
+		notExpected   []string
+		skips         int64
+		renames       int64
+		errors        int64
+		expectedErr   error
+	}{
+		{
+			name:          "policy",
+			cfg:           extract.NewConfig(extract.WithEntryHook(policy)),
+			expected:      map[string]string{"README.txt": "readme", "link": "readme", "hardlink": "readme"},
+			expectedLinks: map[string]string{"link": "README.txt"},
+			notExpected:   []string{"README", "big.bin", "setuid"},
+			skips:         2,
+			renames:       1,
+		},
+		{
+			name: "abort",
+			cfg: extract.NewConfig(extract.WithContinueOnError(true), extract.WithEntryHook(func(_ context.Context, e extract.EntryInfo) (extract.Decision, error) {
+				if e.Mode&fs.ModeSetuid != 0 {
+					return extract.Decision{Action: extract.ActionAbort}, nil
+				}
+				return extract.Decision{}, nil
+			})),
+			expectedErr: extract.ErrAbortedByEntryHook,
+		},
+		{
+			name: "error",
+			cfg: extract.NewConfig(extract.WithEntryHook(func(_ context.Context, e extract.EntryInfo) (extract.Decision, error) {
+				return extract.Decision{}, errors.New("policy violation")
+			})),
+			expectedErr: extract.ErrFailedToUnpack,
+		},
+		{
+			name: "error with continue on error",
+			cfg: extract.NewConfig(extract.WithContinueOnError(true), extract.WithEntryHook(func(_ context.Context, e extract.EntryInfo) (extract.Decision, error) {
+				if e.Name == "big.bin" {
+					return extract.Decision{}, errors.New("policy violation")
+				}
+				return extract.Decision{}, nil
+			})),
+			expected:    map[string]string{"README": "readme", "setuid": "setuid"},
+			notExpected: []string{"big.bin"},
+			errors:      1,
+		},
+		{
+			name: "rename is security checked",
+			cfg: extract.NewConfig(extract.WithEntryHook(func(_ context.Context, e extract.EntryInfo) (extract.Decision, error) {
+				return extract.Decision{Action: extract.ActionRename, Name: "../" + e.Name}, nil
+			})),
+			expectedErr: extract.ErrFailedToUnpack,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var td *extract.TelemetryData
+			extract.WithTelemetryHook(func(_ context.Context, d *extract.TelemetryData) { td = d })(tc.cfg)
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackTo(context.Background(), tm, "", bytes.NewReader(archive), tc.cfg)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkMemoryContent(t, tm, tc.expected)
+			for name, target := range tc.expectedLinks {
+				link, err := tm.Readlink(name)
+				if err != nil {
+					t.Fatalf("failed to read link %s: %v", name, err)
+				}
+				if link != target {
+					t.Errorf("expected link %s to point to %q, got %q", name, target, link)
+				}
+			}
+			for _, name := range tc.notExpected {
+				if _, err := tm.Lstat(name); err == nil {
+					t.Errorf("expected %q not to exist", name)
+				}
+			}
+			if td.EntryHookSkips != tc.skips || td.EntryHookRenames != tc.renames || td.ExtractionErrors != tc.errors {
+				t.Errorf("expected %d skips, %d renames and %d errors, got %d, %d and %d",
+					tc.skips, tc.renames, tc.errors, td.EntryHookSkips, td.EntryHookRenames, td.ExtractionErrors)
+			}
+		})
+	}
+}
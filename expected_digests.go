@@ -0,0 +1,176 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Digest is the expected digest of a file.
+type Digest struct {
+	// Algorithm is the name of the digest algorithm, e.g. [DigestSHA256]
+	Algorithm string
+
+	// Sum is the hex encoded digest
+	Sum string
+}
+
+// String returns the digest in the form "algorithm:sum".
+func (d Digest) String() string {
+	return fmt.Sprintf("%s:%s", d.Algorithm, d.Sum)
+}
+
+// ParseChecksums parses a checksum file in the format of sha256sum and similar tools, like a
+// SHA256SUMS file, with digests of the given algorithm. Each line consists of the hex encoded
+// digest, a space, a space or an asterisk and the path of the file. Empty lines and lines, which
+// start with "#", are ignored. The returned digests can be passed to [WithExpectedDigests].
+func ParseChecksums(r io.Reader, algorithm string) (map[string]Digest, error) {
+	h, err := newHash(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make(map[string]Digest)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(strings.TrimSpace(line)) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// names with backslash or newline are escaped and the line starts with a backslash
+		escaped := strings.HasPrefix(line, "\\")
+		line = strings.TrimPrefix(line, "\\")
+
+		sum, name, ok := strings.Cut(line, " ")
+		if !ok || len(name) < 2 || (name[0] != ' ' && name[0] != '*') {
+			return nil, fmt.Errorf("invalid checksum in line %d", n)
+		}
+		name = name[1:]
+		if escaped {
+			name = strings.NewReplacer("\\\\", "\\", "\\n", "\n", "\\r", "\r").Replace(name)
+		}
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != h.Size() {
+			return nil, fmt.Errorf("invalid %s digest in line %d", algorithm, n)
+		}
+		digests[name] = Digest{Algorithm: algorithm, Sum: strings.ToLower(sum)}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read checksums: %w", err)
+	}
+	return digests, nil
+}
+
+// digestVerifier verifies the extracted files against the expected digests and keeps track of
+// the verified files. It is shared with the configurations of nested archives.
+type digestVerifier struct {
+	expected       map[string]Digest
+	verified       map[string]bool
+	denyUnexpected bool
+}
+
+// verifyDigests returns cfg with a new digest verifier and the verifier, if expected digests
+// are configured and cfg has no verifier yet. Otherwise, cfg and nil are returned.
+func verifyDigests(cfg *Config) (*Config, *digestVerifier, error) {
+	if cfg.ExpectedDigests() == nil || cfg.verifier != nil {
+		return cfg, nil, nil
+	}
+	v := &digestVerifier{
+		expected:       make(map[string]Digest, len(cfg.ExpectedDigests())),
+		verified:       make(map[string]bool),
+		denyUnexpected: cfg.DenyUnexpectedFiles(),
+	}
+	for name, d := range cfg.ExpectedDigests() {
+		if _, err := newHash(d.Algorithm); err != nil {
+			return nil, nil, err
+		}
+		v.expected[path.Clean(name)] = Digest{Algorithm: d.Algorithm, Sum: strings.ToLower(d.Sum)}
+	}
+	n := *cfg
+	n.verifier = v
+	return &n, v, nil
+}
+
+// lookup returns the expected digest of the file name. If no digest is expected for name and
+// unexpected files are denied, an error is returned.
+func (v *digestVerifier) lookup(name string) (Digest, bool, error) {
+	if v == nil {
+		return Digest{}, false, nil
+	}
+	d, ok := v.expected[name]
+	if !ok && v.denyUnexpected {
+		return Digest{}, false, fmt.Errorf("%w: %s", ErrUnexpectedFile, name)
+	}
+	return d, ok, nil
+}
+
+// checkSymlink returns an error for the symlink name, if unexpected files are denied, because
+// a symlink has no content, which can be verified against an expected digest.
+func (v *digestVerifier) checkSymlink(name string) error {
+	if v == nil || !v.denyUnexpected {
+		return nil
+	}
+	return fmt.Errorf("%w: symlink %s", ErrUnexpectedFile, name)
+}
+
+// verify compares the digest sum of the extracted file name with its expected digest.
+func (v *digestVerifier) verify(name string, expected Digest, sum string) error {
+	if sum != expected.Sum {
+		return fmt.Errorf("%w: %s: expected %s, got %s:%s", ErrDigestMismatch, name, expected, expected.Algorithm, sum)
+	}
+	v.verified[name] = true
+	return nil
+}
+
+// verifyLink verifies a hard link name to the extracted file target. The link is verified, if
+// it is expected with the same digest as its verified target.
+func (v *digestVerifier) verifyLink(name string, target string) error {
+	expected, ok, err := v.lookup(name)
+	if err != nil || !ok {
+		return err
+	}
+	if !v.verified[target] || v.expected[target] != expected {
+		return fmt.Errorf("%w: %s: expected %s, got content of %s", ErrDigestMismatch, name, expected, target)
+	}
+	v.verified[name] = true
+	return nil
+}
+
+// missing returns the sorted names of the expected files, which are not verified.
+func (v *digestVerifier) missing() []string {
+	if v == nil {
+		return nil
+	}
+	var missing []string
+	for name := range v.expected {
+		if !v.verified[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// checkMissing returns an error, if missing files are denied and an expected file is not verified.
+func (v *digestVerifier) checkMissing(cfg *Config) error {
+	if !cfg.DenyMissingFiles() {
+		return nil
+	}
+	if missing := v.missing(); len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrExpectedFileMissing, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// entryPath returns the slash separated path of the file name, which is extracted with c,
+// relative to the destination of the outermost archive.
+func (c *Config) entryPath(name string) string {
+	return path.Join(c.nestedDir, name)
+}
@@ -0,0 +1,261 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func sha256Digest(content string) extract.Digest {
+	sum := sha256.Sum256([]byte(content))
+	return extract.Digest{Algorithm: extract.DigestSHA256, Sum: hex.EncodeToString(sum[:])}
+}
+
+func TestExpectedDigests(t *testing.T) {
+	inner := packZip(t, []archiveContent{{Name: "nested", Content: []byte("nested"), Mode: 0644}})
+	archive := packTar(t, []archiveContent{
+		{Name: "dir/", Mode: fs.ModeDir | 0755},
+		{Name: "dir/a.txt", Content: []byte("hello"), Mode: 0644},
+		{Name: "b.txt", Content: []byte("world"), Mode: 0644},
+		{Name: "hardlink", Linktarget: "dir/a.txt", Hardlink: true, Mode: 0644},
+		{Name: "inner.zip", Content: inner, Mode: 0644},
+	})
+	all := map[string]extract.Digest{
+		"dir/a.txt": sha256Digest("hello"),
+		"b.txt":     sha256Digest("world"),
+		"hardlink":  sha256Digest("hello"),
+		"inner.zip": sha256Digest(string(inner)),
+	}
+	without := func(name string) map[string]extract.Digest {
+		m := map[string]extract.Digest{}
+		for k, v := range all {
+			if k != name {
+				m[k] = v
+			}
+		}
+		return m
+	}
+	with := func(name string, d extract.Digest) map[string]extract.Digest {
+		m := without(name)
+		m[name] = d
+		return m
+	}
+
+	tests := []struct {
+		name        string
+		opts        []extract.ConfigOption
+		expected    []string
+		notExpected []string
+		errors      int64
+		expectedErr error
+	}{
+		{
+			name:     "all digests match",
+			opts:     []extract.ConfigOption{extract.WithExpectedDigests(all), extract.WithDenyMissingFiles(true), extract.WithDenyUnexpectedFiles(true)},
+			expected: []string{"dir/a.txt", "b.txt", "hardlink", "inner.zip"},
+		},
+		{
+			name:        "digest mismatch",
+			opts:        []extract.ConfigOption{extract.WithExpectedDigests(with("b.txt", sha256Digest("other")))},
+			notExpected: []string{"b.txt"},
+			expectedErr: extract.ErrDigestMismatch,
+		},
+		{
+			name:        "digest mismatch with continue on error",
+			opts:        []extract.ConfigOption{extract.WithContinueOnError(true), extract.WithExpectedDigests(with("b.txt", sha256Digest("other")))},
+			expected:    []string{"dir/a.txt", "hardlink", "inner.zip"},
+			notExpected: []string{"b.txt"},
+			errors:      1,
+		},
+		{
+			name:        "hard link mismatch",
+			opts:        []extract.ConfigOption{extract.WithExpectedDigests(with("hardlink", sha256Digest("world")))},
+			notExpected: []string{"hardlink"},
+			expectedErr: extract.ErrDigestMismatch,
+		},
+		{
+			name:     "unexpected file allowed",
+			opts:     []extract.ConfigOption{extract.WithExpectedDigests(without("b.txt"))},
+			expected: []string{"dir/a.txt", "b.txt", "hardlink", "inner.zip"},
+		},
+		{
+			name:        "unexpected file denied",
+			opts:        []extract.ConfigOption{extract.WithExpectedDigests(without("b.txt")), extract.WithDenyUnexpectedFiles(true)},
+			notExpected: []string{"b.txt"},
+			expectedErr: extract.ErrUnexpectedFile,
+		},
+		{
+			name:     "missing file allowed",
+			opts:     []extract.ConfigOption{extract.WithExpectedDigests(with("missing", sha256Digest("missing")))},
+			expected: []string{"dir/a.txt", "b.txt", "hardlink", "inner.zip"},
+		},
+		{
+			name:        "missing file denied",
+			opts:        []extract.ConfigOption{extract.WithExpectedDigests(with("missing", sha256Digest("missing"))), extract.WithDenyMissingFiles(true)},
+			expectedErr: extract.ErrExpectedFileMissing,
+		},
+		{
+			name: "nested archive",
+			opts: []extract.ConfigOption{
+				extract.WithRecursiveExtraction(1),
+				extract.WithExpectedDigests(with("inner.zip.d/nested", sha256Digest("nested"))),
+				extract.WithDenyMissingFiles(true),
+				extract.WithDenyUnexpectedFiles(true),
+			},
+			expected: []string{"inner.zip.d/nested"},
+		},
+		{
+			name:        "unsupported algorithm",
+			opts:        []extract.ConfigOption{extract.WithExpectedDigests(with("b.txt", extract.Digest{Algorithm: "md5", Sum: "00"}))},
+			expectedErr: extract.ErrUnsupportedDigest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var td *extract.TelemetryData
+			dir := t.TempDir()
+			opts := append([]extract.ConfigOption{extract.WithTelemetryHook(func(_ context.Context, d *extract.TelemetryData) { td = d })}, tc.opts...)
+			err := extract.Unpack(context.Background(), dir, bytes.NewReader(archive), extract.NewConfig(opts...))
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, name := range tc.expected {
+				if _, err := os.Lstat(filepath.Join(dir, name)); err != nil {
+					t.Errorf("expected %s to be extracted: %v", name, err)
+				}
+			}
+			for _, name := range tc.notExpected {
+				if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+					t.Errorf("expected %s not to exist", name)
+				}
+			}
+			if tc.expectedErr == nil && td.ExtractionErrors != tc.errors {
+				t.Errorf("expected %d errors, got %d", tc.errors, td.ExtractionErrors)
+			}
+		})
+	}
+}
+
+func TestExpectedDigestsSymlink(t *testing.T) {
+	archive := packTar(t, []archiveContent{
+		{Name: "dir", Mode: fs.ModeDir | 0755},
+		{Name: "dir/a.txt", Content: []byte("hello"), Mode: 0644},
+		{Name: "link", Linktarget: "dir/a.txt", Mode: fs.ModeSymlink | 0777},
+	})
+	digests := map[string]extract.Digest{"dir/a.txt": sha256Digest("hello")}
+
+	tests := []struct {
+		name        string
+		opts        []extract.ConfigOption
+		expected    []string
+		notExpected []string
+		expectedErr error
+	}{
+		{
+			name:     "symlink allowed",
+			opts:     []extract.ConfigOption{extract.WithExpectedDigests(digests)},
+			expected: []string{"dir", "dir/a.txt", "link"},
+		},
+		{
+			name:        "symlink denied",
+			opts:        []extract.ConfigOption{extract.WithExpectedDigests(digests), extract.WithDenyUnexpectedFiles(true)},
+			notExpected: []string{"link"},
+			expectedErr: extract.ErrUnexpectedFile,
+		},
+		{
+			name:        "symlink denied with continue on error",
+			opts:        []extract.ConfigOption{extract.WithExpectedDigests(digests), extract.WithDenyUnexpectedFiles(true), extract.WithContinueOnError(true)},
+			expected:    []string{"dir", "dir/a.txt"},
+			notExpected: []string{"link"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			err := extract.Unpack(context.Background(), dir, bytes.NewReader(archive), extract.NewConfig(tc.opts...))
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, name := range tc.expected {
+				if _, err := os.Lstat(filepath.Join(dir, name)); err != nil {
+					t.Errorf("expected %s to be extracted: %v", name, err)
+				}
+			}
+			for _, name := range tc.notExpected {
+				if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+					t.Errorf("expected %s not to exist", name)
+				}
+			}
+		})
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	hello, world := sha256Digest("hello"), sha256Digest("world")
+	sums := "# checksums\n" +
+		hello.Sum + "  ./dir/a.txt\n" +
+		"\n" +
+		strings.ToUpper(world.Sum) + " *b.txt\r\n" +
+		"\\" + hello.Sum + "  back\\\\slash\n"
+
+	digests, err := extract.ParseChecksums(strings.NewReader(sums), extract.DigestSHA256)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]extract.Digest{"./dir/a.txt": hello, "b.txt": world, "back\\slash": hello}
+	if len(digests) != len(expected) {
+		t.Fatalf("expected %d digests, got %d: %v", len(expected), len(digests), digests)
+	}
+	for name, d := range expected {
+		if digests[name] != d {
+			t.Errorf("expected digest %s for %q, got %s", d, name, digests[name])
+		}
+	}
+
+	// the parsed digests verify an archive
+	archive := packTar(t, []archiveContent{
+		{Name: "dir/a.txt", Content: []byte("hello"), Mode: 0644},
+		{Name: "b.txt", Content: []byte("world"), Mode: 0644},
+	})
+	delete(digests, "back\\slash")
+	cfg := extract.NewConfig(extract.WithExpectedDigests(digests), extract.WithDenyMissingFiles(true), extract.WithDenyUnexpectedFiles(true))
+	if err := extract.Unpack(context.Background(), t.TempDir(), bytes.NewReader(archive), cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, invalid := range []string{
+		hello.Sum + " a.txt\n",
+		hello.Sum + "\n",
+		"xyz  a.txt\n",
+		hello.Sum[:10] + "  a.txt\n",
+	} {
+		if _, err := extract.ParseChecksums(strings.NewReader(invalid), extract.DigestSHA256); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+	if _, err := extract.ParseChecksums(strings.NewReader(""), "md5"); !errors.Is(err, extract.ErrUnsupportedDigest) {
+		t.Errorf("expected error %v, got %v", extract.ErrUnsupportedDigest, err)
+	}
+}
@@ -6,11 +6,15 @@ package extract
 import (
 	"archive/tar"
 	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -25,25 +29,6 @@ type seekerReaderAt interface {
 	io.Seeker
 }
 
-// checkPatterns checks if the given path matches any of the given patterns.
-// If no patterns are given, the function returns true.
-func checkPatterns(patterns []string, path string) (bool, error) {
-	// no patterns given
-	if len(patterns) == 0 {
-		return true, nil
-	}
-
-	// check if path matches any pattern
-	for _, pattern := range patterns {
-		if match, err := filepath.Match(pattern, path); err != nil {
-			return false, fmt.Errorf("failed to match pattern: %w", err)
-		} else if match {
-			return true, nil
-		}
-	}
-	return false, nil
-}
-
 // captureExtractionDuration captures the duration of the extraction
 func captureExtractionDuration(m *TelemetryData, start time.Time) {
 	stop := now()
@@ -61,30 +46,60 @@ type unpackFunc func(context.Context, Target, string, io.Reader, *Config) error
 // headerCheckFunc is a function that checks if the given header matches the expected magic bytes.
 type headerCheckFunc func([]byte) bool
 
+// walkerFunc is a function that opens the archive in src and returns a Walker to iterate
+// over its entries. If the returned walker implements io.Closer, it must be closed after usage.
+type walkerFunc func(context.Context, io.Reader, *Config) (Walker, error)
+
+// precedence of extractors in the format detection. Extractors with a higher precedence
+// are checked first.
+const (
+	precedenceBuiltin = iota
+	precedenceRegistered
+	precedenceConfig
+)
+
 type extractor struct {
 	Unpacker    unpackFunc
+	Walker      walkerFunc
 	HeaderCheck headerCheckFunc
 	MagicBytes  [][]byte
 	Offset      int
+
+	// precedence and sequence of the registration determine the order of the format detection
+	precedence int
+	sequence   int
 }
 
 type extractors map[string]extractor
 
-// getUnpackFunction identifies the correct extractor based on magic bytes.
-func (e extractors) getUnpackFunction(data []byte) unpackFunc {
-	// find extractor with longest suffix match
-	for _, ex := range e {
-		if ex.HeaderCheck(data) {
-			return ex.Unpacker
+// getExtractorByHeader identifies the correct extractor based on magic bytes.
+func (e extractors) getExtractorByHeader(data []byte) (extractor, bool) {
+	// find first extractor that matches in detection order
+	for _, name := range e.detectionOrder() {
+		if ex := e[name]; ex.HeaderCheck(data) {
+			return ex, true
 		}
 	}
 
-	// no matching reader found
-	return nil
+	// no matching extractor found
+	return extractor{}, false
 }
 
-// GetUnpackFunctionByFileName identifies the correct extractor based on file extension.
-func (e extractors) GetUnpackFunctionByFileName(name string) unpackFunc {
+// detectionOrder returns the names of the extractors in the order, in which they are checked
+// by the format detection: formats of the configuration first, then globally registered formats,
+// both in the order of their registration, and finally the built-in formats sorted by name.
+func (e extractors) detectionOrder() []string {
+	return slices.SortedFunc(maps.Keys(e), func(a, b string) int {
+		return cmp.Or(
+			cmp.Compare(e[b].precedence, e[a].precedence),
+			cmp.Compare(e[a].sequence, e[b].sequence),
+			strings.Compare(a, b),
+		)
+	})
+}
+
+// getExtractorByFileName identifies the correct extractor based on file extension.
+func (e extractors) getExtractorByFileName(name string) (extractor, bool) {
 	// get file extension from file name
 	ext := strings.ToLower(name)
 	if strings.Contains(ext, ".") {
@@ -92,7 +107,25 @@ func (e extractors) GetUnpackFunctionByFileName(name string) unpackFunc {
 		ext = strings.Replace(ext, ".", "", -1) // remove leading dot if the file extension is the only part of the file name (e.g. ".tar")
 	}
 
-	if ae, found := e[ext]; found {
+	ae, found := e[ext]
+	return ae, found
+}
+
+// getExtractor identifies the correct extractor based on magic bytes and, if
+// no magic bytes match, based on the file extension.
+func (e extractors) getExtractor(header []byte, ext string) (extractor, bool) {
+	// find extractor by header
+	if ae, found := e.getExtractorByHeader(header); found {
+		return ae, true
+	}
+
+	// find extractor by file extension
+	return e.getExtractorByFileName(ext)
+}
+
+// GetUnpackFunctionByFileName identifies the correct extractor based on file extension.
+func (e extractors) GetUnpackFunctionByFileName(name string) unpackFunc {
+	if ae, found := e.getExtractorByFileName(name); found {
 		return ae.Unpacker
 	}
 
@@ -102,14 +135,8 @@ func (e extractors) GetUnpackFunctionByFileName(name string) unpackFunc {
 
 // GetUnpackFunction identifies the correct extractor based on magic bytes.
 func (e extractors) GetUnpackFunction(header []byte, ext string) unpackFunc {
-	// find extractor by header
-	if unpacker := e.getUnpackFunction(header); unpacker != nil {
-		return unpacker
-	}
-
-	// find extractor by file extension
-	if unpacker := e.GetUnpackFunctionByFileName(ext); unpacker != nil {
-		return unpacker
+	if ae, found := e.getExtractor(header, ext); found {
+		return ae.Unpacker
 	}
 
 	// no matching reader found
@@ -128,89 +155,145 @@ func (e extractors) Extensions() string {
 
 // availableExtractors is collection of new extractor functions with
 // the required magic bytes and potential offset
-var availableExtractors = extractors{
-	fileExtension7zip: {
-		Unpacker:    unpack7Zip,
-		HeaderCheck: is7zip,
-		MagicBytes:  magicBytes7zip,
-	},
-	fileExtensionBrotli: {
-		Unpacker:    unpackBrotli,
-		HeaderCheck: isBrotli,
-	},
-	fileExtensionBzip2: {
-		Unpacker:    unpackBzip2,
-		HeaderCheck: isBzip2,
-		MagicBytes:  magicBytesBzip2,
-	},
-	fileExtensionGZip: {
-		Unpacker:    unpackGZip,
-		HeaderCheck: isGZip,
-		MagicBytes:  magicBytesGZip,
-	},
-	fileExtensionLZ4: {
-		Unpacker:    unpackLZ4,
-		HeaderCheck: isLZ4,
-		MagicBytes:  magicBytesLZ4,
-	},
-	fileExtensionSnappy: {
-		Unpacker:    unpackSnappy,
-		HeaderCheck: isSnappy,
-		MagicBytes:  magicBytesSnappy,
-	},
-	fileExtensionTar: {
-		Unpacker:    unpackTar,
-		HeaderCheck: isTar,
-		MagicBytes:  magicBytesTar,
-		Offset:      offsetTar,
-	},
-	fileExtensionTarGZip: {
-		Unpacker:    unpackGZip,
-		HeaderCheck: isGZip,
-		MagicBytes:  magicBytesGZip,
-	},
-	fileExtensionXz: {
-		Unpacker:    unpackXz,
-		HeaderCheck: isXz,
-		MagicBytes:  magicBytesXz,
-	},
-	fileExtensionZip: {
-		Unpacker:    unpackZip,
-		HeaderCheck: isZip,
-		MagicBytes:  magicBytesZip,
-	},
-	fileExtensionZlib: {
-		Unpacker:    unpackZlib,
-		HeaderCheck: isZlib,
-		MagicBytes:  magicBytesZlib,
-	},
-	fileExtensionZstd: {
-		Unpacker:    unpackZstd,
-		HeaderCheck: isZstd,
-		MagicBytes:  magicBytesZstd,
-	},
-	fileExtensionRar: {
-		Unpacker:    unpackRar,
-		HeaderCheck: isRar,
-		MagicBytes:  magicBytesRar,
-	},
-}
-
-var maxHeaderLength int
+var availableExtractors extractors
 
-// init calculates the maximum header length
+// init initializes the built-in extractors. The initialization is deferred to
+// avoid an initialization cycle with the format registry.
 func init() {
-	for _, ex := range availableExtractors {
-		needs := ex.Offset
-		for _, mb := range ex.MagicBytes {
-			if len(mb)+ex.Offset > needs {
-				needs = len(mb) + ex.Offset
-			}
-		}
-		if needs > maxHeaderLength {
-			maxHeaderLength = needs
+	availableExtractors = extractors{
+		fileExtension7zip: {
+			Unpacker:    unpack7Zip,
+			Walker:      walk7Zip,
+			HeaderCheck: is7zip,
+			MagicBytes:  magicBytes7zip,
+		},
+		fileExtensionAr: {
+			Unpacker:    unpackAr,
+			Walker:      walkAr,
+			HeaderCheck: isAr,
+			MagicBytes:  magicBytesAr,
+		},
+		fileExtensionBrotli: {
+			Unpacker:    unpackBrotli,
+			Walker:      walkBrotli,
+			HeaderCheck: isBrotli,
+		},
+		fileExtensionBzip2: {
+			Unpacker:    unpackBzip2,
+			Walker:      walkBzip2,
+			HeaderCheck: isBzip2,
+			MagicBytes:  magicBytesBzip2,
+		},
+		fileExtensionCpio: {
+			Unpacker:    unpackCpio,
+			Walker:      walkCpio,
+			HeaderCheck: isCpio,
+			MagicBytes:  magicBytesCpio,
+		},
+		fileExtensionDeb: {
+			Unpacker:    unpackDeb,
+			Walker:      walkDeb,
+			HeaderCheck: isDeb,
+			MagicBytes:  magicBytesDeb,
+		},
+		fileExtensionGZip: {
+			Unpacker:    unpackGZip,
+			Walker:      walkGZip,
+			HeaderCheck: isGZip,
+			MagicBytes:  magicBytesGZip,
+		},
+		fileExtensionIso: {
+			Unpacker:    unpackWalker(walkIso, fileExtensionIso),
+			Walker:      walkIso,
+			HeaderCheck: isIso,
+			MagicBytes:  magicBytesIso,
+			Offset:      offsetIso,
+		},
+		fileExtensionLZ4: {
+			Unpacker:    unpackLZ4,
+			Walker:      walkLZ4,
+			HeaderCheck: isLZ4,
+			MagicBytes:  magicBytesLZ4,
+		},
+		fileExtensionRpm: {
+			Unpacker:    unpackRpm,
+			Walker:      walkRpm,
+			HeaderCheck: isRpm,
+			MagicBytes:  magicBytesRpm,
+		},
+		fileExtensionSnappy: {
+			Unpacker:    unpackSnappy,
+			Walker:      walkSnappy,
+			HeaderCheck: isSnappy,
+			MagicBytes:  magicBytesSnappy,
+		},
+		fileExtensionTar: {
+			Unpacker:    unpackTar,
+			Walker:      walkTar,
+			HeaderCheck: isTar,
+			MagicBytes:  magicBytesTar,
+			Offset:      offsetTar,
+		},
+		fileExtensionTarGZip: {
+			Unpacker:    unpackGZip,
+			Walker:      walkGZip,
+			HeaderCheck: isGZip,
+			MagicBytes:  magicBytesGZip,
+		},
+		fileExtensionXz: {
+			Unpacker:    unpackXz,
+			Walker:      walkXz,
+			HeaderCheck: isXz,
+			MagicBytes:  magicBytesXz,
+		},
+		fileExtensionZip: {
+			Unpacker:    unpackZip,
+			Walker:      walkZip,
+			HeaderCheck: isZip,
+			MagicBytes:  magicBytesZip,
+		},
+		fileExtensionZlib: {
+			Unpacker:    unpackZlib,
+			Walker:      walkZlib,
+			HeaderCheck: isZlib,
+			MagicBytes:  magicBytesZlib,
+		},
+		fileExtensionZstd: {
+			Unpacker:    unpackZstd,
+			Walker:      walkZstd,
+			HeaderCheck: isZstd,
+			MagicBytes:  magicBytesZstd,
+		},
+		fileExtensionRar: {
+			Unpacker:    unpackRar,
+			Walker:      walkRar,
+			HeaderCheck: isRar,
+			MagicBytes:  magicBytesRar,
+		},
+	}
+}
+
+// maxHeaderLength calculates the maximum header length that is required to
+// identify all extractors in streams. Magic bytes at offsets of headerPrefixLength
+// or more are not included, they are only read from inputs with random access.
+func (e extractors) maxHeaderLength() int {
+	var maxHeaderLength int
+	for _, ex := range e {
+		if ex.Offset < headerPrefixLength {
+			maxHeaderLength = max(maxHeaderLength, ex.headerLength())
 		}
 	}
+	return maxHeaderLength
+}
+
+// headerLength returns the header length that is required to check the magic bytes
+// of the extractor.
+func (ex extractor) headerLength() int {
+	needs := ex.Offset
+	for _, mb := range ex.MagicBytes {
+		needs = max(needs, ex.Offset+len(mb))
+	}
+	return needs
 }
 
 func matchesMagicBytes(data []byte, offset int, magicBytes [][]byte) bool {
@@ -231,6 +314,15 @@ func matchesMagicBytes(data []byte, offset int, magicBytes [][]byte) bool {
 	return false
 }
 
+// passwordError wraps err, which is caused by an encrypted archive or entry, with
+// [ErrWrongPassword] if a password is configured, or [ErrPasswordRequired] otherwise.
+func passwordError(err error, hasPassword bool) error {
+	if hasPassword {
+		return fmt.Errorf("%w: %w", ErrWrongPassword, err)
+	}
+	return fmt.Errorf("%w: %w", ErrPasswordRequired, err)
+}
+
 // handleError increases the error counter, sets the latest error and
 // decides if extraction should continue.
 func handleError(cfg *Config, td *TelemetryData, msg string, err error) error {
@@ -263,7 +355,7 @@ func handleError(cfg *Config, td *TelemetryData, msg string, err error) error {
 }
 
 // extract checks ctx for cancellation, while it reads a tar file from src and extracts the contents to dst.
-func extract(ctx context.Context, t Target, dst string, src archiveWalker, cfg *Config, td *TelemetryData) error {
+func extract(ctx context.Context, t Target, dst string, src Walker, cfg *Config, td *TelemetryData) error {
 
 	// start extraction
 	cfg.Logger().Info("start extraction", "type", src.Type())
@@ -272,14 +364,40 @@ func extract(ctx context.Context, t Target, dst string, src archiveWalker, cfg *
 
 	// collect extracted entries if file attributes should be preserved
 	collectEntries := (!cfg.DropFileAttributes()) || cfg.PreserveOwner()
-	var extractedEntries []archiveEntry
+	var extractedEntries []Entry
 
 	if cfg.PreserveOwner() && src.Type() != fileExtensionTar {
 		cfg.Logger().Info("owner preservation is only supported for tar archives", "type", src.Type())
 	}
 
+	// check the digest algorithms before any file is created
+	if err := checkDigests(cfg.Digests()); err != nil {
+		return err
+	}
+
+	// verify the extracted files against the expected digests
+	cfg, verifier, err := verifyDigests(cfg)
+	if err != nil {
+		return err
+	}
+
+	// prepare recursive extraction of nested archives
+	recursive, err := newRecursiveExtraction(cfg)
+	if err != nil {
+		return handleError(cfg, td, "cannot prepare recursive extraction", err)
+	}
+	if recursive != nil {
+		defer recursive.report(td)
+	}
+
+	// determine compressed bytes, which are consumed from the input
+	input := cfg.inputCounter(td)
+
+	// prepare decisions of the entry hook
+	decisions := newEntryDecisions(cfg)
+
 	// iterate over all files in archive
-	err := func() error {
+	err = func() error {
 		for {
 			// check if context is canceled
 			if ctx.Err() != nil {
@@ -316,15 +434,58 @@ func extract(ctx context.Context, t Target, dst string, src archiveWalker, cfg *
 				return handleError(cfg, td, "max objects check failed", err)
 			}
 
+			// strip leading path components and rewrite the path of the entry
+			rewritten, keep, err := rewriteEntry(cfg, ae)
+			if err != nil {
+				if err := handleError(cfg, td, "cannot rewrite path", err); err != nil {
+					return err
+				}
+				continue
+			}
+			if !keep {
+				cfg.Logger().Info("skipping file (path rewrite)", "name", ae.Name())
+				continue
+			}
+			ae = rewritten
+
 			// check if file needs to match patterns
-			match, err := checkPatterns(cfg.Patterns(), ae.Name())
+			match, err := matchPatterns(cfg, ae.Name())
 			if err != nil {
 				return handleError(cfg, td, "cannot check pattern", err)
 			}
-			if !match {
+			switch match {
+			case patternMismatch:
 				cfg.Logger().Info("skipping file (pattern mismatch)", "name", ae.Name())
 				td.PatternMismatches++
 				continue
+			case patternExcluded:
+				cfg.Logger().Info("skipping file (excluded by pattern)", "name", ae.Name())
+				td.PatternExcludes++
+				continue
+			case patternIncluded:
+				td.PatternIncludes++
+			}
+
+			// let the entry hook decide how the entry is handled
+			if decisions != nil {
+				decided, action, err := decisions.decide(ctx, ae)
+				switch {
+				case action == ActionAbort:
+					return err
+				case err != nil:
+					if err := handleError(cfg, td, "entry hook failed", err); err != nil {
+						return err
+					}
+					continue
+				case action == ActionSkip:
+					cfg.Logger().Info("skipping file (entry hook)", "name", ae.Name())
+					td.EntryHookSkips++
+					continue
+				case action == ActionRename:
+					cfg.Logger().Info("renaming file (entry hook)", "name", ae.Name(), "new_name", decided.Name())
+					td.EntryHookRenames++
+				}
+				ae = decided
 			}
 
 			cfg.Logger().Debug("extract", "name", ae.Name())
@@ -357,7 +518,16 @@ func extract(ctx context.Context, t Target, dst string, src archiveWalker, cfg *
 					return handleError(cfg, td, "max extraction size exceeded", err)
 				}
 
+				// check compression ratio forecast
+				compressed := compressedSize(ae)
+				if compressed >= 0 {
+					if err := cfg.CheckCompressionRatio(compressed, ae.Size()); err != nil {
+						return handleError(cfg, td, "max compression ratio exceeded", err)
+					}
+				}
+
 				// open file in archive
+				var nested *nestedArchive
 				err, fileCreated := func() (error, bool) {
 					fin, err := ae.Open()
 					if err != nil {
@@ -365,10 +535,20 @@ func extract(ctx context.Context, t Target, dst string, src archiveWalker, cfg *
 					}
 					defer fin.Close()
 
+					// check compression ratio while decompressing
+					src := newCompressionRatioReader(fin, cfg, compressed, input, extractionSize)
+
+					// cache content of nested archives
+					if recursive != nil {
+						if src, nested, err = recursive.detect(src); err != nil {
+							return handleError(cfg, td, "failed to detect nested archive", err), false
+						}
+					}
+
 					// create file
-					n, err := createFile(t, dst, ae.Name(), fin, ae.Mode(), cfg.MaxExtractionSize()-extractionSize, cfg)
+					n, err := createFile(ctx, t, dst, newEntryInfo(ae), src, cfg.MaxExtractionSize()-extractionSize, cfg, td)
 					extractionSize = extractionSize + n
-					td.ExtractionSize = extractionSize
+					td.ExtractionSize = td.ExtractionSize + n
 					if err != nil {
 
 						// increase error counter, set error and end if necessary
@@ -379,6 +559,9 @@ func extract(ctx context.Context, t Target, dst string, src archiveWalker, cfg *
 					return nil, true
 				}()
 				if err != nil {
+					if nested != nil {
+						nested.Close()
+					}
 					return err
 				}
 
@@ -390,6 +573,25 @@ func extract(ctx context.Context, t Target, dst string, src archiveWalker, cfg *
 					}
 				}
 
+				// extract nested archive, which shares the limits with this archive
+				if nested != nil {
+					if !fileCreated {
+						nested.Close()
+						continue
+					}
+					files, size, err := recursive.unpack(ctx, t, dst, ae.Name(), nested, fileCounter, extractionSize)
+					fileCounter += files
+					extractionSize += size
+					if errors.Is(err, ErrAbortedByEntryHook) {
+						return err
+					}
+					if err != nil {
+						if err := handleError(cfg, td, "failed to extract nested archive", err); err != nil {
+							return err
+						}
+					}
+				}
+
 			// its a symlink !!
 			case ae.IsSymlink():
 
@@ -423,10 +625,28 @@ func extract(ctx context.Context, t Target, dst string, src archiveWalker, cfg *
 				// store telemetry and continue
 				td.ExtractedSymlinks++
 
+			// its a hard link
+			case ae.IsHardlink():
+
+				// create link
+				if err := createHardlink(t, dst, ae.Name(), ae.Linkname(), cfg); err != nil {
+
+					// increase error counter, set error and end if necessary
+					if err := handleError(cfg, td, "failed to create safe hard link", err); err != nil {
+						return err
+					}
+
+					// do not end on error
+					continue
+				}
+
+				// store telemetry and continue
+				td.ExtractedHardlinks++
+
 			default:
 
 				// tar specific: check for git comment file `pax_global_header` from type `67` and skip
-				if ae.Type()&tar.TypeXGlobalHeader == tar.TypeXGlobalHeader && ae.Name() == "pax_global_header" {
+				if isPaxGlobalHeader(ae) {
 					continue
 				}
 
@@ -456,12 +676,17 @@ func extract(ctx context.Context, t Target, dst string, src archiveWalker, cfg *
 		}
 	}
 
+	// check that all files with an expected digest are extracted
+	if err := verifier.checkMissing(cfg); err != nil {
+		return handleError(cfg, td, "expected files missing", err)
+	}
+
 	// extraction finished
 	return nil
 }
 
 // setFileAttributesAndOwner sets the file attributes for the given path and archive entry.
-func setFileAttributesAndOwner(t Target, path string, ae archiveEntry, dropFileAttributes bool, owner bool) error {
+func setFileAttributesAndOwner(t Target, path string, ae Entry, dropFileAttributes bool, owner bool) error {
 	if !dropFileAttributes { // preserve file attributes
 		if ae.IsSymlink() { // only time attributes are supported for symlinks
 			if err := t.Lchtimes(path, ae.AccessTime(), ae.ModTime()); err != nil {
@@ -534,6 +759,54 @@ func readerToReaderAtSeeker(c *Config, r io.Reader) (seekerReaderAt, error) {
 	return tmpFile, nil
 }
 
+// cacheInput converts src with readerToReaderAtSeeker and returns a function that removes
+// the temporary file, which is created if src needs to be cached on disk.
+func cacheInput(c *Config, src io.Reader) (seekerReaderAt, func(), error) {
+	sra, err := readerToReaderAtSeeker(c, src)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// only remove temporary files, but not the original input
+	cleanup := func() {}
+	if f, ok := sra.(*os.File); ok && any(sra) != any(src) {
+		cleanup = func() {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}
+	return sra, cleanup, nil
+}
+
+// checkInputSize determines the size of s and checks if it exceeds the maximum input size.
+// Afterwards, s is reset to the start.
+func checkInputSize(c *Config, s io.Seeker) (int64, error) {
+	size, err := s.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, fmt.Errorf("cannot seek to end of reader: %w", err)
+	}
+	if c.MaxInputSize() != -1 && size > c.MaxInputSize() {
+		return size, fmt.Errorf("input size exceeds maximum input size")
+	}
+	if _, err := s.Seek(0, io.SeekStart); err != nil {
+		return size, fmt.Errorf("cannot seek to start of reader: %w", err)
+	}
+	return size, nil
+}
+
+// closeWalker closes the walker, if it implements io.Closer.
+func closeWalker(w Walker) error {
+	if c, ok := w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// isPaxGlobalHeader checks if the entry is a git comment file `pax_global_header` from type `67`.
+func isPaxGlobalHeader(ae Entry) bool {
+	return ae.Type()&tar.TypeXGlobalHeader == tar.TypeXGlobalHeader && ae.Name() == "pax_global_header"
+}
+
 // unsupportedFile returns an error that indicates that the file is not supported.
 func unsupportedFile(filename string) error {
 	return &UnsupportedFileError{error: ErrUnsupportedFile, filename: filename}
@@ -560,11 +833,20 @@ func (e UnsupportedFileError) Error() string {
 	return fmt.Sprintf("%v: %s", e.error, e.filename)
 }
 
-// getHeader reads the header from src and returns it. If src is a io.Seeker, the header is read
-// directly from the reader and the reader gets reset. If src is not a io.Seeker, the header is read
-// and transformed into a HeaderReader, which is returned as the second return value. If an error
+// headerPrefixLength is the maximum offset of magic bytes, which are read from streams.
+// Magic bytes at larger offsets, like the ISO 9660 volume descriptor, are only read
+// from inputs with random access, so that streams are not buffered for them.
+const headerPrefixLength = 4096
+
+// getHeader reads the header from src, which is required to identify all extractors in e, and
+// returns it. If src is a io.Seeker, the header is read directly from the reader and the reader
+// gets reset. If src is a seekerReaderAt, magic bytes at large offsets are read separately, so
+// that the gaps in between are not read. If src is not a io.Seeker, the header is read and
+// transformed into a HeaderReader, which is returned as the second return value. If an error
 // occurs, the header is nil and the error is returned as the third return value
-func getHeader(src io.Reader) ([]byte, io.Reader, error) {
+func getHeader(src io.Reader, e extractors) ([]byte, io.Reader, error) {
+	maxHeaderLength := e.maxHeaderLength()
+
 	// check if source offers seek and preserve type of source
 	if s, ok := src.(io.Seeker); ok {
 
@@ -572,10 +854,28 @@ func getHeader(src io.Reader) ([]byte, io.Reader, error) {
 		header := make([]byte, maxHeaderLength)
 
 		// read header from source
-		_, err := src.Read(header)
-		if err != nil {
+		_, err := io.ReadFull(src, header)
+		if err != nil && err != io.ErrUnexpectedEOF {
 			return nil, nil, fmt.Errorf("failed to read header: %w", err)
 		}
+
+		// read magic bytes at large offsets
+		if ra, ok := src.(seekerReaderAt); ok {
+			for _, ex := range e {
+				if ex.Offset < headerPrefixLength {
+					continue
+				}
+				end := ex.headerLength()
+				if len(header) < end {
+					header = append(header, make([]byte, end-len(header))...)
+				}
+				_, err := ra.ReadAt(header[ex.Offset:end], int64(ex.Offset))
+				if err != nil && err != io.EOF {
+					return nil, nil, fmt.Errorf("failed to read header: %w", err)
+				}
+			}
+		}
+
 		// reset reader
 		_, err = s.Seek(0, io.SeekStart)
 		if err != nil {
@@ -0,0 +1,227 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Format describes an archive or compression format, which can be registered with
+// [RegisterFormat] or [WithFormat] to participate in the format detection of [UnpackTo],
+// [List] and [Walk].
+//
+// Exactly one of Decompress or NewWalker must be set. Formats with a Decompress function are
+// handled like the built-in compression formats, i.e. the decompressed content is written to a
+// single file or, if it is a tar archive, extracted. Formats with a NewWalker function are
+// handled like the built-in archive formats.
+type Format struct {
+	// MagicBytes are the magic bytes that identify the format, located at Offset.
+	MagicBytes [][]byte
+
+	// Offset is the offset of the magic bytes in the input. Magic bytes at an offset of
+	// 4096 bytes or more are only detected in inputs with random access, like files.
+	Offset int
+
+	// HeaderCheck is an optional function that checks if the header of the input matches
+	// the format. The header contains at least Offset plus the length of the longest
+	// magic bytes, if the input is long enough. If HeaderCheck is nil, the header is
+	// compared with MagicBytes at Offset.
+	HeaderCheck func(header []byte) bool
+
+	// Decompress returns a reader that decompresses src.
+	Decompress func(src io.Reader) (io.Reader, error)
+
+	// NewWalker opens the archive in src and returns a [Walker] to iterate over its entries.
+	// If the returned [Walker] implements io.Closer, it is closed after usage. The input size
+	// is limited to the configured maximum input size.
+	NewWalker func(ctx context.Context, src io.Reader, cfg *Config) (Walker, error)
+}
+
+// namedFormat is a format, which is registered with [WithFormat] for a configuration.
+type namedFormat struct {
+	name   string
+	format Format
+}
+
+var (
+	// ErrInvalidFormat indicates that a [Format] cannot be registered.
+	ErrInvalidFormat = fmt.Errorf("extract: invalid format")
+
+	// extractorsMutex protects availableExtractors and registeredFormats against concurrent
+	// registrations.
+	extractorsMutex sync.RWMutex
+
+	// registeredFormats is the number of formats registered with [RegisterFormat].
+	registeredFormats int
+)
+
+// RegisterFormat registers the format f under name globally, so that it participates in
+// the format detection and can be selected with [WithExtractType]. The name is used as
+// file extension. Registered formats are detected before the built-in formats, in the
+// order of their registration. An error is returned, if the format is invalid, the name
+// is already registered or the magic bytes at the offset are already used by another
+// format.
+func RegisterFormat(name string, f Format) error {
+	name = strings.ToLower(name)
+	ex, err := newExtractor(name, f)
+	if err != nil {
+		return err
+	}
+
+	extractorsMutex.Lock()
+	defer extractorsMutex.Unlock()
+	if _, found := availableExtractors[name]; found {
+		return fmt.Errorf("%w: %q is already registered", ErrInvalidFormat, name)
+	}
+	for other, o := range availableExtractors {
+		if sharesMagicBytes(ex, o) {
+			return fmt.Errorf("%w: magic bytes of %q are already used by %q", ErrInvalidFormat, name, other)
+		}
+	}
+	registeredFormats++
+	ex.precedence = precedenceRegistered
+	ex.sequence = registeredFormats
+	availableExtractors[name] = ex
+	return nil
+}
+
+// sharesMagicBytes returns true if a and b have equal magic bytes at the same offset.
+func sharesMagicBytes(a, b extractor) bool {
+	if a.Offset != b.Offset {
+		return false
+	}
+	for _, mb := range a.MagicBytes {
+		if slices.ContainsFunc(b.MagicBytes, func(other []byte) bool { return bytes.Equal(mb, other) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// Formats returns the sorted names of all globally registered formats.
+func Formats() []string {
+	extractorsMutex.RLock()
+	defer extractorsMutex.RUnlock()
+	return slices.Sorted(maps.Keys(availableExtractors))
+}
+
+// newExtractor converts the format f into an extractor.
+func newExtractor(name string, f Format) (extractor, error) {
+	if len(name) == 0 {
+		return extractor{}, fmt.Errorf("%w: empty name", ErrInvalidFormat)
+	}
+	if (f.Decompress == nil) == (f.NewWalker == nil) {
+		return extractor{}, fmt.Errorf("%w: %q requires either a decompress function or a walker constructor", ErrInvalidFormat, name)
+	}
+	if f.HeaderCheck == nil && len(f.MagicBytes) == 0 {
+		return extractor{}, fmt.Errorf("%w: %q requires magic bytes or a header check", ErrInvalidFormat, name)
+	}
+	if f.Offset < 0 {
+		return extractor{}, fmt.Errorf("%w: %q has a negative offset", ErrInvalidFormat, name)
+	}
+
+	// prepare header check
+	headerCheck := f.HeaderCheck
+	if headerCheck == nil {
+		headerCheck = func(header []byte) bool {
+			return matchesMagicBytes(header, f.Offset, f.MagicBytes)
+		}
+	}
+
+	// prepare decompression format
+	if f.Decompress != nil {
+		decFunc := decompressionFunc(f.Decompress)
+		return extractor{
+			Unpacker: func(ctx context.Context, t Target, dst string, src io.Reader, cfg *Config) error {
+				return decompress(ctx, t, dst, src, cfg, decFunc, name)
+			},
+			Walker: func(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+				return walkDecompressed(ctx, src, cfg, decFunc, name)
+			},
+			HeaderCheck: headerCheck,
+			MagicBytes:  f.MagicBytes,
+			Offset:      f.Offset,
+		}, nil
+	}
+
+	// prepare archive format
+	newWalker := f.NewWalker
+	walker := func(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+		if sra, ok := src.(seekerReaderAt); ok {
+			if _, err := checkInputSize(cfg, sra); err != nil {
+				return nil, err
+			}
+			return newWalker(ctx, src, cfg)
+		}
+		return newWalker(ctx, newLimitErrorReader(src, cfg.MaxInputSize()), cfg)
+	}
+	return extractor{
+		Unpacker:    unpackWalker(walker, name),
+		Walker:      walker,
+		HeaderCheck: headerCheck,
+		MagicBytes:  f.MagicBytes,
+		Offset:      f.Offset,
+	}, nil
+}
+
+// unpackWalker returns an unpackFunc that extracts the entries returned by the walker
+// created with newWalker.
+func unpackWalker(newWalker walkerFunc, name string) unpackFunc {
+	return func(ctx context.Context, t Target, dst string, src io.Reader, cfg *Config) error {
+		// prepare telemetry data collection and emit
+		td := &TelemetryData{ExtractedType: name}
+		defer cfg.TelemetryHook()(ctx, td)
+		defer captureExtractionDuration(td, now())
+
+		// capture input size, the limit is enforced by the walker
+		if sra, ok := src.(seekerReaderAt); ok {
+			size, err := checkInputSize(cfg, sra)
+			td.InputSize = size
+			if err != nil {
+				return handleError(cfg, td, fmt.Sprintf("cannot unpack %s archive", name), err)
+			}
+		} else {
+			countingReader := newLimitErrorReader(src, -1)
+			defer captureInputSize(td, countingReader)
+			src = countingReader
+			cfg = cfg.withCompressedInput(consumedInput(countingReader))
+		}
+
+		// open archive
+		w, err := newWalker(ctx, src, cfg)
+		if err != nil {
+			return handleError(cfg, td, fmt.Sprintf("cannot open %s archive", name), err)
+		}
+		defer closeWalker(w)
+
+		return extract(ctx, t, dst, w, cfg, td)
+	}
+}
+
+// extractors returns the extractors that are available for the configuration. Formats
+// registered with [WithFormat] take precedence over globally registered formats, and are
+// detected first, in the order of their registration.
+func (c *Config) extractors() (extractors, error) {
+	extractorsMutex.RLock()
+	e := maps.Clone(availableExtractors)
+	extractorsMutex.RUnlock()
+
+	for i, nf := range c.formats {
+		ex, err := newExtractor(nf.name, nf.format)
+		if err != nil {
+			return nil, err
+		}
+		ex.precedence = precedenceConfig
+		ex.sequence = i
+		e[nf.name] = ex
+	}
+	return e, nil
+}
@@ -0,0 +1,346 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-extract"
+)
+
+// upperMagic is the magic prefix of the test compression format "upper"
+var upperMagic = []byte("UPPER\n")
+
+// decompressUpper removes the magic prefix and converts the content to upper case
+func decompressUpper(src io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, upperMagic) {
+		return nil, fmt.Errorf("missing magic bytes")
+	}
+	return bytes.NewReader(bytes.ToUpper(data[len(upperMagic):])), nil
+}
+
+// linesMagic is the magic prefix of the test archive format "lines", which contains
+// one file per line in the form "name:content" after the magic line
+var linesMagic = []byte("LINES\n")
+
+// scopedLinesMagic is an alternative magic prefix of the test archive format "lines"
+var scopedLinesMagic = []byte("SCOPED\n")
+
+type linesWalker struct {
+	scanner *bufio.Scanner
+}
+
+func newLinesWalker(ctx context.Context, src io.Reader, cfg *extract.Config) (extract.Walker, error) {
+	scanner := bufio.NewScanner(src)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("missing magic line")
+	}
+	return &linesWalker{scanner: scanner}, nil
+}
+
+func (w *linesWalker) Type() string {
+	return "lines"
+}
+
+func (w *linesWalker) Next() (extract.Entry, error) {
+	if !w.scanner.Scan() {
+		if err := w.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	name, content, found := strings.Cut(w.scanner.Text(), ":")
+	if !found {
+		return nil, fmt.Errorf("invalid line %q", w.scanner.Text())
+	}
+	return &linesEntry{name: name, content: content}, nil
+}
+
+type linesEntry struct {
+	name    string
+	content string
+}
+
+func (e *linesEntry) AccessTime() time.Time { return time.Time{} }
+func (e *linesEntry) Gid() int              { return 0 }
+func (e *linesEntry) IsRegular() bool       { return true }
+func (e *linesEntry) IsDir() bool           { return false }
+func (e *linesEntry) IsHardlink() bool      { return false }
+func (e *linesEntry) IsSymlink() bool       { return false }
+func (e *linesEntry) Linkname() string      { return "" }
+func (e *linesEntry) Mode() fs.FileMode     { return 0644 }
+func (e *linesEntry) ModTime() time.Time    { return time.Time{} }
+func (e *linesEntry) Name() string          { return e.name }
+func (e *linesEntry) Open() (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(e.content)), nil
+}
+func (e *linesEntry) Size() int64       { return int64(len(e.content)) }
+func (e *linesEntry) Sys() interface{}  { return nil }
+func (e *linesEntry) Type() fs.FileMode { return 0 }
+func (e *linesEntry) Uid() int          { return 0 }
+
+func ExampleRegisterFormat() {
+	// register a compression format, which is identified by its magic bytes
+	magic := []byte("UPPERCASE\n")
+	err := extract.RegisterFormat("upper", extract.Format{
+		MagicBytes: [][]byte{magic},
+		Decompress: func(src io.Reader) (io.Reader, error) {
+			data, err := io.ReadAll(src)
+			if err != nil {
+				return nil, err
+			}
+			return bytes.NewReader(bytes.ToUpper(bytes.TrimPrefix(data, magic))), nil
+		},
+	})
+	if err != nil {
+		// handle error
+	}
+
+	fmt.Println(extract.HasKnownArchiveExtension("example.upper"))
+	// Output:
+	// true
+}
+
+func TestRegisterFormat(t *testing.T) {
+	if err := extract.RegisterFormat("test-upper", extract.Format{
+		MagicBytes: [][]byte{upperMagic},
+		Decompress: decompressUpper,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := extract.RegisterFormat("Test-Lines", extract.Format{
+		MagicBytes: [][]byte{linesMagic},
+		NewWalker:  newLinesWalker,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// registered formats are listed and known by their extension
+	for _, name := range []string{"test-upper", "test-lines"} {
+		if !slices.Contains(extract.Formats(), name) {
+			t.Errorf("expected format %q in %v", name, extract.Formats())
+		}
+		if !extract.HasKnownArchiveExtension("archive." + name) {
+			t.Errorf("expected known archive extension for %q", name)
+		}
+	}
+
+	// duplicate registration fails
+	err := extract.RegisterFormat("tar", extract.Format{MagicBytes: [][]byte{linesMagic}, NewWalker: newLinesWalker})
+	if !errors.Is(err, extract.ErrInvalidFormat) {
+		t.Errorf("expected error %v, got %v", extract.ErrInvalidFormat, err)
+	}
+
+	// registration with the magic bytes of another format fails
+	for _, f := range []extract.Format{
+		{MagicBytes: [][]byte{linesMagic}, NewWalker: newLinesWalker},
+		{MagicBytes: [][]byte{[]byte("other"), {0x1f, 0x8b}}, Decompress: decompressUpper},
+	} {
+		err := extract.RegisterFormat("test-duplicate", f)
+		if !errors.Is(err, extract.ErrInvalidFormat) {
+			t.Errorf("expected error %v, got %v", extract.ErrInvalidFormat, err)
+		}
+	}
+	if slices.Contains(extract.Formats(), "test-duplicate") {
+		t.Errorf("expected format with duplicate magic bytes not to be registered")
+	}
+
+	tests := []struct {
+		name            string
+		src             []byte
+		cfg             *extract.Config
+		expectedContent map[string]string
+	}{
+		{
+			name:            "decompress format",
+			src:             append(bytes.Clone(upperMagic), []byte("hello world")...),
+			expectedContent: map[string]string{"goextract-decompressed-content": "HELLO WORLD"},
+		},
+		{
+			name:            "walker format",
+			src:             append(bytes.Clone(linesMagic), []byte("a:hello\nb:world\n")...),
+			expectedContent: map[string]string{"a": "hello", "b": "world"},
+		},
+		{
+			name:            "walker format with type",
+			src:             append(bytes.Clone(linesMagic), []byte("a:hello\n")...),
+			cfg:             extract.NewConfig(extract.WithExtractType("test-lines")),
+			expectedContent: map[string]string{"a": "hello"},
+		},
+		{
+			name:            "walker format with patterns",
+			src:             append(bytes.Clone(linesMagic), []byte("a:hello\nb:world\n")...),
+			cfg:             extract.NewConfig(extract.WithPatterns("b")),
+			expectedContent: map[string]string{"b": "world"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			if cfg == nil {
+				cfg = extract.NewConfig()
+			}
+			tm := extract.NewTargetMemory()
+			if err := extract.UnpackTo(context.Background(), tm, "", asIoReader(t, tc.src), cfg); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for name, content := range tc.expectedContent {
+				data, err := fs.ReadFile(tm, name)
+				if err != nil {
+					t.Fatalf("error reading %q: %v", name, err)
+				}
+				if string(data) != content {
+					t.Errorf("expected content %q for %q, got %q", content, name, data)
+				}
+			}
+		})
+	}
+}
+
+func TestWithFormat(t *testing.T) {
+	src := append(bytes.Clone(scopedLinesMagic), []byte("a:hello\nb:world\n")...)
+
+	// unknown without the format
+	_, err := extract.List(context.Background(), asIoReader(t, src), extract.NewConfig())
+	if !errors.Is(err, extract.ErrNoExtractorFound) {
+		t.Fatalf("expected error %v, got %v", extract.ErrNoExtractorFound, err)
+	}
+
+	// known with the format
+	cfg := extract.NewConfig(extract.WithFormat("scoped-lines", extract.Format{
+		MagicBytes: [][]byte{scopedLinesMagic},
+		NewWalker:  newLinesWalker,
+	}))
+	entries, err := extract.List(context.Background(), asIoReader(t, src), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	// the scoped format is not registered globally
+	if extract.HasKnownArchiveExtension("archive.scoped-lines") {
+		t.Errorf("expected unknown archive extension for scoped format")
+	}
+	if !cfg.HasKnownArchiveExtension("archive.scoped-lines") || !cfg.HasKnownArchiveExtension("archive.zip") {
+		t.Errorf("expected known archive extensions for configuration")
+	}
+	if extract.NewConfig().HasKnownArchiveExtension("archive.scoped-lines") {
+		t.Errorf("expected unknown archive extension for default configuration")
+	}
+
+	// the input size is limited
+	cfg = extract.NewConfig(
+		extract.WithFormat("scoped-lines", extract.Format{MagicBytes: [][]byte{scopedLinesMagic}, NewWalker: newLinesWalker}),
+		extract.WithMaxInputSize(8),
+	)
+	if err := extract.UnpackTo(context.Background(), extract.NewTargetMemory(), "", asIoReader(t, src), cfg); err == nil {
+		t.Errorf("expected error for exceeded input size")
+	}
+}
+
+func TestFormatDetectionOrder(t *testing.T) {
+	gzipMagic := []byte{0x1f, 0x8b}
+	src := compressGzip(t, []byte("hello world"))
+
+	// formats of the configuration are detected before the built-in formats
+	var detected bool
+	cfg := extract.NewConfig(extract.WithFormat("custom-gzip", extract.Format{
+		MagicBytes: [][]byte{gzipMagic},
+		NewWalker: func(ctx context.Context, src io.Reader, cfg *extract.Config) (extract.Walker, error) {
+			detected = true
+			return newLinesWalker(ctx, bytes.NewReader(linesMagic), cfg)
+		},
+	}))
+	for i := 0; i < 10; i++ {
+		detected = false
+		if _, err := extract.List(context.Background(), asIoReader(t, src), cfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !detected {
+			t.Fatalf("expected custom format to be detected before gzip")
+		}
+	}
+
+	// formats of the configuration are detected in the order of their registration
+	for i := 0; i < 10; i++ {
+		var detected string
+		cfg := extract.NewConfig(
+			extract.WithFormat("first", extract.Format{
+				MagicBytes: [][]byte{linesMagic},
+				NewWalker: func(ctx context.Context, src io.Reader, cfg *extract.Config) (extract.Walker, error) {
+					detected = "first"
+					return newLinesWalker(ctx, src, cfg)
+				},
+			}),
+			extract.WithFormat("second", extract.Format{
+				MagicBytes: [][]byte{linesMagic},
+				NewWalker: func(ctx context.Context, src io.Reader, cfg *extract.Config) (extract.Walker, error) {
+					detected = "second"
+					return newLinesWalker(ctx, src, cfg)
+				},
+			}),
+		)
+		src := append(bytes.Clone(linesMagic), []byte("a:hello\n")...)
+		if _, err := extract.List(context.Background(), asIoReader(t, src), cfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if detected != "first" {
+			t.Fatalf("expected first format to be detected, got %q", detected)
+		}
+	}
+}
+
+func TestInvalidFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format extract.Format
+	}{
+		{
+			name:   "no decompress and no walker",
+			format: extract.Format{MagicBytes: [][]byte{linesMagic}},
+		},
+		{
+			name:   "decompress and walker",
+			format: extract.Format{MagicBytes: [][]byte{linesMagic}, Decompress: decompressUpper, NewWalker: newLinesWalker},
+		},
+		{
+			name:   "no magic bytes",
+			format: extract.Format{NewWalker: newLinesWalker},
+		},
+		{
+			name:   "negative offset",
+			format: extract.Format{MagicBytes: [][]byte{linesMagic}, Offset: -1, NewWalker: newLinesWalker},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := extract.RegisterFormat("invalid", tc.format); !errors.Is(err, extract.ErrInvalidFormat) {
+				t.Errorf("expected error %v, got %v", extract.ErrInvalidFormat, err)
+			}
+			cfg := extract.NewConfig(extract.WithFormat("invalid", tc.format))
+			err := extract.UnpackTo(context.Background(), extract.NewTargetMemory(), "", asIoReader(t, []byte("rubbish")), cfg)
+			if !errors.Is(err, extract.ErrInvalidFormat) {
+				t.Errorf("expected error %v, got %v", extract.ErrInvalidFormat, err)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ module github.com/hashicorp/go-extract
 go 1.25.1
 
 require (
+	github.com/ProtonMail/go-crypto v1.3.0
 	github.com/alecthomas/kong v1.14.0
 	github.com/andybalholm/brotli v1.2.0
 	github.com/bodgit/sevenzip v1.6.1
@@ -11,14 +12,16 @@ require (
 	github.com/nwaples/rardecode/v2 v2.2.2
 	github.com/pierrec/lz4/v4 v4.1.26
 	github.com/ulikunitz/xz v0.5.15
+	golang.org/x/crypto v0.48.0
 	golang.org/x/sys v0.42.0
 )
 
 require (
 	github.com/bodgit/plumbing v1.3.0 // indirect
 	github.com/bodgit/windows v1.0.1 // indirect
+	github.com/cloudflare/circl v1.6.1 // indirect
 	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
 	github.com/spf13/afero v1.11.0 // indirect
 	go4.org v0.0.0-20200411211856-f5505b9728dd // indirect
-	golang.org/x/text v0.21.0 // indirect
+	golang.org/x/text v0.34.0 // indirect
 )
@@ -34,6 +34,11 @@ func unpackGZip(ctx context.Context, t Target, dst string, src io.Reader, cfg *C
 	return decompress(ctx, t, dst, src, cfg, decompressGZipStream, fileExtensionGZip)
 }
 
+// walkGZip opens the gzip compressed src and returns a Walker for the decompressed content.
+func walkGZip(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return walkDecompressed(ctx, src, cfg, decompressGZipStream, fileExtensionGZip)
+}
+
 // decompressGZipStream returns an io.Reader that decompresses src with gzip algorithm.
 func decompressGZipStream(src io.Reader) (io.Reader, error) {
 	return gzip.NewReader(src)
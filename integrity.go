@@ -0,0 +1,157 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// TestResult is the result of the integrity test of an entry with [Test].
+type TestResult struct {
+	EntryInfo
+
+	// Err is the error, which occurred while the content of the entry was decoded, or nil
+	// if the entry is intact
+	Err error `json:"-"`
+}
+
+// streamWalker is implemented by walkers of archives in a compressed stream, whose trailer,
+// e.g. the checksum of gzip or xz, is only verified, when the stream is read to its end.
+type streamWalker interface {
+	remainingStream() io.Reader
+}
+
+// Test tests the integrity of the archive in src, like `unzip -t`, without writing anything,
+// according to the given configuration. If cfg is nil, the default configuration is used.
+//
+// The archive type is determined in the same way as for [UnpackTo]. The content of every
+// entry is fully decoded, so that the checksums of the format are verified, e.g. the CRC-32 of
+// zip entries, the CRCs of 7-Zip and Rar archives and the trailers of gzip or xz streams. Only
+// entries that match the configured patterns are tested, and the configured maximum number of
+// files and maximum extraction size are enforced, so that testing an archive bomb is safe.
+//
+// A result is returned for every tested entry. An entry with corrupt content does not end the
+// test, its error wraps [ErrCorruptData] or a password error, like [ErrWrongPassword], and
+// the returned error joins the errors of all corrupt entries. Errors, which prevent further
+// entries from being read, and exceeded limits end the test.
+func Test(ctx context.Context, src io.Reader, cfg *Config) ([]TestResult, error) {
+	if cfg == nil {
+		cfg = NewConfig()
+	}
+
+	// open archive
+	w, err := newWalker(ctx, src, cfg)
+	if errors.Is(err, ErrFailedToList) {
+		return nil, testError("archive", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closeWalker(w)
+
+	// test all entries in archive
+	var results []TestResult
+	var errs []error
+	var fileCounter int64
+	var extractionSize int64
+	for {
+		// check if context is canceled
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		// get next entry
+		ae, err := w.Next()
+		switch {
+		case err == io.EOF:
+			// verify the trailer of the compressed stream
+			if sw, ok := w.(streamWalker); ok {
+				n, err := testContent(sw.remainingStream(), cfg.MaxExtractionSize()-extractionSize)
+				extractionSize += n
+				if err != nil {
+					return results, errors.Join(append(errs, testError("compressed stream", err))...)
+				}
+			}
+			return results, errors.Join(errs...)
+		case err != nil:
+			return results, errors.Join(append(errs, testError("archive", err))...)
+		case ae == nil:
+			continue
+		}
+
+		// skip tar specific git comment file
+		if isPaxGlobalHeader(ae) {
+			continue
+		}
+
+		// check if maximum of files (including folder and symlinks) is exceeded
+		fileCounter++
+		if err := cfg.CheckMaxFiles(fileCounter); err != nil {
+			return results, err
+		}
+
+		// check if entry needs to match patterns
+		match, err := matchPatterns(cfg, ae.Name())
+		if err != nil {
+			return results, err
+		}
+		if match.skip() {
+			continue
+		}
+
+		// decode the content of regular files
+		result := TestResult{EntryInfo: newEntryInfo(ae)}
+		if ae.IsRegular() {
+			if err := cfg.CheckExtractionSize(extractionSize + ae.Size()); err != nil {
+				return results, err
+			}
+			n, err := testEntry(ae, cfg.MaxExtractionSize()-extractionSize)
+			extractionSize += n
+			switch {
+			case errors.Is(err, ErrMaxExtractionSizeExceeded):
+				return results, err
+			case err != nil:
+				result.Err = testError(ae.Name(), err)
+				errs = append(errs, result.Err)
+			}
+		}
+		cfg.Logger().Debug("test", "name", ae.Name(), "err", result.Err)
+		results = append(results, result)
+	}
+}
+
+// testEntry decodes the content of the entry and returns the number of decoded bytes.
+func testEntry(ae Entry, maxSize int64) (int64, error) {
+	r, err := ae.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	return testContent(r, maxSize)
+}
+
+// testContent reads r to its end without writing the content anywhere and returns the number
+// of read bytes. If more than maxSize bytes are read, [ErrMaxExtractionSizeExceeded] is returned.
+func testContent(r io.Reader, maxSize int64) (int64, error) {
+	n, err := io.Copy(limitWriter(io.Discard, maxSize), r)
+	if errors.Is(err, io.ErrShortWrite) {
+		return n, ErrMaxExtractionSizeExceeded
+	}
+	return n, err
+}
+
+// testError returns the error of the tested name, which wraps [ErrCorruptData], unless
+// err already indicates corrupt data or a wrong or missing password.
+func testError(name string, err error) error {
+	if errors.Is(err, ErrMaxExtractionSizeExceeded) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if errors.Is(err, ErrCorruptData) || errors.Is(err, ErrWrongPassword) || errors.Is(err, ErrPasswordRequired) {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return fmt.Errorf("%w: %s: %w", ErrCorruptData, name, err)
+}
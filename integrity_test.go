@@ -0,0 +1,184 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func ExampleTest() {
+	var (
+		ctx = context.Background()    // context for cancellation
+		src = openFile("example.zip") // source reader
+		cfg = extract.NewConfig()     // custom config for testing
+	)
+
+	// test entries
+	results, err := extract.Test(ctx, src, cfg)
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Println("FAILED", r.Name, r.Err)
+			continue
+		}
+		fmt.Println("OK", r.Name)
+	}
+	if err != nil {
+		// handle error
+	}
+	// Output:
+	// OK example.txt
+}
+
+func TestTest(t *testing.T) {
+	contents := []archiveContent{
+		{Name: "dir", Mode: fs.ModeDir | 0755},
+		{Name: "dir/a", Content: []byte("hello world"), Mode: 0644},
+		{Name: "dir/b", Content: []byte("lorem ipsum"), Mode: 0644},
+		{Name: "link", Linktarget: "dir/a", Mode: fs.ModeSymlink | 0755},
+	}
+	zipArchive := packZip(t, contents)
+	tarGz := compressGzip(t, packTar(t, contents))
+
+	// the trailer of a larger stream is read after the header of the archive is detected
+	largeContents := append(contents, archiveContent{Name: "large", Content: bytes.Repeat([]byte("x"), 1<<17), Mode: 0644})
+	largeTarGz := compressGzip(t, packTar(t, largeContents))
+
+	// flip a byte of the content of a file, or of the checksum in the gzip trailer
+	corrupt := func(b []byte, old string) []byte {
+		b = bytes.Clone(b)
+		i := bytes.Index(b, []byte(old))
+		if i < 0 {
+			t.Fatalf("content %q not found", old)
+		}
+		b[i] ^= 0xff
+		return b
+	}
+	corruptTrailer := func(b []byte) []byte {
+		b = bytes.Clone(b)
+		b[len(b)-8] ^= 0xff
+		return b
+	}
+
+	tests := []struct {
+		name           string
+		src            []byte
+		cfg            *extract.Config
+		expectedNames  []string
+		expectedFailed []string
+		expectError    error
+	}{
+		{
+			name:          "intact zip",
+			src:           zipArchive,
+			expectedNames: []string{"dir", "dir/a", "dir/b", "link"},
+		},
+		{
+			name:           "zip with corrupt entry",
+			src:            corrupt(zipArchive, "lorem ipsum"),
+			expectedNames:  []string{"dir", "dir/a", "dir/b", "link"},
+			expectedFailed: []string{"dir/b"},
+			expectError:    extract.ErrCorruptData,
+		},
+		{
+			name:          "intact tar.gz",
+			src:           tarGz,
+			expectedNames: []string{"dir", "dir/a", "dir/b", "link"},
+		},
+		{
+			name:          "tar.gz with corrupt trailer",
+			src:           corruptTrailer(tarGz),
+			expectedNames: []string{"dir", "dir/a", "dir/b", "link"},
+			expectError:   extract.ErrCorruptData,
+		},
+		{
+			name:          "large tar.gz with corrupt trailer",
+			src:           corruptTrailer(largeTarGz),
+			expectedNames: []string{"dir", "dir/a", "dir/b", "link", "large"},
+			expectError:   extract.ErrCorruptData,
+		},
+		{
+			name:        "gzip with corrupt trailer",
+			src:         corruptTrailer(compressGzip(t, []byte("hello world"))),
+			expectError: extract.ErrCorruptData,
+		},
+		{
+			name:           "large gzip with corrupt trailer",
+			src:            corruptTrailer(compressGzip(t, bytes.Repeat([]byte("x"), 1<<17))),
+			expectedNames:  []string{"goextract-decompressed-content"},
+			expectedFailed: []string{"goextract-decompressed-content"},
+			expectError:    extract.ErrCorruptData,
+		},
+		{
+			name:           "rar with corrupt entry",
+			src:            packEncryptedRar(t, "secret", true, contents[1:2]),
+			cfg:            extract.NewConfig(extract.WithPassword("secret")),
+			expectedNames:  []string{"dir/a"},
+			expectedFailed: []string{"dir/a"},
+			expectError:    extract.ErrCorruptData,
+		},
+		{
+			name:          "patterns",
+			src:           corrupt(zipArchive, "lorem ipsum"),
+			cfg:           extract.NewConfig(extract.WithPatterns("dir/a")),
+			expectedNames: []string{"dir/a"},
+		},
+		{
+			name:          "max extraction size exceeded",
+			src:           zipArchive,
+			cfg:           extract.NewConfig(extract.WithMaxExtractionSize(15)),
+			expectedNames: []string{"dir", "dir/a"},
+			expectError:   extract.ErrMaxExtractionSizeExceeded,
+		},
+		{
+			name:        "max extraction size of stream exceeded",
+			src:         compressGzip(t, bytes.Repeat([]byte{0}, 1<<16)),
+			cfg:         extract.NewConfig(extract.WithMaxExtractionSize(1 << 10)),
+			expectError: extract.ErrMaxExtractionSizeExceeded,
+		},
+		{
+			name:          "max files exceeded",
+			src:           zipArchive,
+			cfg:           extract.NewConfig(extract.WithMaxFiles(2)),
+			expectedNames: []string{"dir", "dir/a"},
+			expectError:   extract.ErrMaxFilesExceeded,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := extract.Test(context.Background(), asIoReader(t, tc.src), tc.cfg)
+			if tc.expectError != nil {
+				if !errors.Is(err, tc.expectError) {
+					t.Fatalf("expected error %v, got %v", tc.expectError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var names, failed []string
+			for _, r := range results {
+				names = append(names, r.Name)
+				if r.Err != nil {
+					if !errors.Is(r.Err, extract.ErrCorruptData) {
+						t.Errorf("expected %s to fail with %v, got %v", r.Name, extract.ErrCorruptData, r.Err)
+					}
+					failed = append(failed, r.Name)
+				}
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tc.expectedNames) {
+				t.Errorf("expected entries %v, got %v", tc.expectedNames, names)
+			}
+			if fmt.Sprint(failed) != fmt.Sprint(tc.expectedFailed) {
+				t.Errorf("expected failed entries %v, got %v", tc.expectedFailed, failed)
+			}
+		})
+	}
+}
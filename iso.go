@@ -0,0 +1,732 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// fileExtensionIso is the file extension for ISO 9660 images
+const fileExtensionIso = "iso"
+
+// offsetIso is the offset of the standard identifier in the first volume descriptor
+const offsetIso = 0x8001
+
+// magicBytesIso is the standard identifier of ISO 9660 volume descriptors
+//
+// https://wiki.osdev.org/ISO_9660
+var magicBytesIso = [][]byte{
+	[]byte("CD001"),
+}
+
+const (
+	// isoSectorSize is the size of a logical sector
+	isoSectorSize = 2048
+
+	// isoSystemAreaSectors is the number of sectors in front of the volume descriptors
+	isoSystemAreaSectors = 16
+
+	// isoMaxVolumeDescriptors is the maximum number of volume descriptors that are read
+	isoMaxVolumeDescriptors = 64
+
+	// isoMaxDirectorySize is the maximum size of a directory
+	isoMaxDirectorySize = 64 << 20 // 64 MiB
+
+	// isoMaxContinuationAreas is the maximum number of Rock Ridge continuation areas per record
+	isoMaxContinuationAreas = 16
+
+	// isoMinRecordLength is the minimum length of a directory record
+	isoMinRecordLength = 34
+)
+
+// volume descriptor types
+const (
+	isoVolumeDescriptorPrimary       = 1
+	isoVolumeDescriptorSupplementary = 2
+	isoVolumeDescriptorTerminator    = 255
+)
+
+// flags of directory records
+const (
+	isoFlagDirectory   = 0x02
+	isoFlagMultiExtent = 0x80
+)
+
+// isIso checks if the header matches the magic bytes for ISO 9660 images
+func isIso(header []byte) bool {
+	return matchesMagicBytes(header, offsetIso, magicBytesIso)
+}
+
+// walkIso caches the input, if necessary, and returns a Walker for the ISO 9660 image in src.
+func walkIso(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	sra, cleanup, err := cacheInput(cfg, src)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert reader to readerAt and seeker: %w", err)
+	}
+
+	// check input size
+	size, err := checkInputSize(cfg, sra)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	// read volume descriptors
+	w, err := newIsoWalker(sra, size)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	w.cleanup = cleanup
+	return w, nil
+}
+
+// isoExtent is a contiguous area of an ISO 9660 image
+type isoExtent struct {
+	block  uint32
+	length uint32
+}
+
+// isoDirectory is a directory, which has not been read yet
+type isoDirectory struct {
+	path   string
+	extent isoExtent
+}
+
+// isoWalker is a walker for ISO 9660 images, which supports the Joliet and
+// Rock Ridge extensions.
+type isoWalker struct {
+	ra        io.ReaderAt
+	size      int64
+	blockSize int64
+
+	// joliet is true, if names are read from the Joliet supplementary volume descriptor
+	joliet bool
+
+	// rockRidge is true, if the primary volume uses Rock Ridge extensions
+	rockRidge bool
+
+	// suspSkip is the number of bytes to skip in the system use area of each record
+	suspSkip int
+
+	// dirs are the directories, which have not been read yet
+	dirs []isoDirectory
+
+	// visited contains the extents of all read directories to prevent loops
+	visited map[uint32]bool
+
+	// entries are the entries of the current directory
+	entries []*isoEntry
+
+	cleanup func()
+}
+
+// newIsoWalker reads the volume descriptors of the image in ra and prepares the
+// walk, starting at the root directory.
+func newIsoWalker(ra io.ReaderAt, size int64) (*isoWalker, error) {
+	var primary, joliet []byte
+	for i := 0; i < isoMaxVolumeDescriptors; i++ {
+		vd := make([]byte, isoSectorSize)
+		if _, err := ra.ReadAt(vd, int64(isoSystemAreaSectors+i)*isoSectorSize); err != nil {
+			return nil, fmt.Errorf("iso: cannot read volume descriptor: %w", err)
+		}
+		if !matchesMagicBytes(vd, 1, magicBytesIso) {
+			return nil, fmt.Errorf("iso: invalid volume descriptor")
+		}
+		if vd[0] == isoVolumeDescriptorTerminator {
+			break
+		}
+		switch {
+		case vd[0] == isoVolumeDescriptorPrimary && primary == nil:
+			primary = vd
+		case vd[0] == isoVolumeDescriptorSupplementary && isJolietEscape(vd[88:91]):
+			joliet = vd
+		}
+	}
+	if primary == nil {
+		return nil, fmt.Errorf("iso: primary volume descriptor not found")
+	}
+
+	// check logical block size
+	blockSize := int64(binary.LittleEndian.Uint16(primary[128:130]))
+	if blockSize != 512 && blockSize != 1024 && blockSize != 2048 {
+		return nil, fmt.Errorf("iso: unsupported logical block size %d", blockSize)
+	}
+
+	w := &isoWalker{
+		ra:        ra,
+		size:      size,
+		blockSize: blockSize,
+		visited:   make(map[uint32]bool),
+	}
+
+	// prefer Rock Ridge, then Joliet, then plain ISO 9660 names
+	root := parseIsoExtent(primary[156:190])
+	rockRidge, skip, err := w.detectRockRidge(root)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case rockRidge:
+		w.rockRidge = true
+		w.suspSkip = skip
+	case joliet != nil:
+		w.joliet = true
+		root = parseIsoExtent(joliet[156:190])
+	}
+
+	w.dirs = append(w.dirs, isoDirectory{extent: root})
+	w.visited[root.block] = true
+	return w, nil
+}
+
+// isJolietEscape checks if the escape sequence of a supplementary volume descriptor
+// identifies a Joliet volume
+func isJolietEscape(esc []byte) bool {
+	return esc[0] == '%' && esc[1] == '/' && (esc[2] == '@' || esc[2] == 'C' || esc[2] == 'E')
+}
+
+// parseIsoExtent returns the extent of a directory record
+func parseIsoExtent(rec []byte) isoExtent {
+	return isoExtent{
+		block:  binary.LittleEndian.Uint32(rec[2:6]) + uint32(rec[1]),
+		length: binary.LittleEndian.Uint32(rec[10:14]),
+	}
+}
+
+// detectRockRidge checks if the first record of the root directory contains the
+// SUSP indicator and returns the number of bytes to skip in each system use area.
+func (w *isoWalker) detectRockRidge(root isoExtent) (bool, int, error) {
+	sector := make([]byte, min(root.length, isoSectorSize))
+	if _, err := w.readAt(sector, root.block, 0); err != nil {
+		return false, 0, fmt.Errorf("iso: cannot read root directory: %w", err)
+	}
+	if len(sector) < isoMinRecordLength {
+		return false, 0, fmt.Errorf("iso: invalid root directory")
+	}
+	recLen := int(sector[0])
+	if recLen < isoMinRecordLength || recLen > len(sector) {
+		return false, 0, fmt.Errorf("iso: invalid root directory")
+	}
+	su := sector[isoMinRecordLength:recLen]
+	if len(su) >= 7 && string(su[0:2]) == "SP" && su[4] == 0xbe && su[5] == 0xef {
+		return true, int(su[6]), nil
+	}
+	return false, 0, nil
+}
+
+// readAt reads len(p) bytes at the given block and offset, and checks that the
+// area is within the image.
+func (w *isoWalker) readAt(p []byte, block uint32, offset int64) (int, error) {
+	start := int64(block)*w.blockSize + offset
+	if start+int64(len(p)) > w.size {
+		return 0, fmt.Errorf("area exceeds image size")
+	}
+	return w.ra.ReadAt(p, start)
+}
+
+// Type returns the file extension for ISO 9660 images
+func (w *isoWalker) Type() string {
+	return fileExtensionIso
+}
+
+// Next returns the next entry of the image. Directories are walked in breadth-first order.
+func (w *isoWalker) Next() (Entry, error) {
+	for {
+		if len(w.entries) > 0 {
+			e := w.entries[0]
+			w.entries = w.entries[1:]
+			return e, nil
+		}
+		if len(w.dirs) == 0 {
+			return nil, io.EOF
+		}
+		d := w.dirs[0]
+		w.dirs = w.dirs[1:]
+		if err := w.readDirectory(d); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Close removes the cached input, if any.
+func (w *isoWalker) Close() error {
+	if w.cleanup != nil {
+		w.cleanup()
+	}
+	return nil
+}
+
+// readDirectory reads all records of the directory d, queues the entries and the
+// subdirectories.
+func (w *isoWalker) readDirectory(d isoDirectory) error {
+	if d.extent.length > isoMaxDirectorySize {
+		return fmt.Errorf("iso: directory %q exceeds maximum size", d.path)
+	}
+	data := make([]byte, d.extent.length)
+	if _, err := w.readAt(data, d.extent.block, 0); err != nil {
+		return fmt.Errorf("iso: cannot read directory %q: %w", d.path, err)
+	}
+
+	var pending *isoEntry
+	for offset := 0; offset < len(data); {
+		// records do not cross sector boundaries, a zero length pads the sector
+		recLen := int(data[offset])
+		if recLen == 0 {
+			offset = (offset/isoSectorSize + 1) * isoSectorSize
+			continue
+		}
+		if recLen < isoMinRecordLength || offset+recLen > len(data) {
+			return fmt.Errorf("iso: invalid record in directory %q", d.path)
+		}
+		rec := data[offset : offset+recLen]
+		offset += recLen
+
+		nameLen := int(rec[32])
+		if 33+nameLen > recLen {
+			return fmt.Errorf("iso: invalid name length in directory %q", d.path)
+		}
+		rawName := rec[33 : 33+nameLen]
+
+		// skip current and parent directory
+		if nameLen == 1 && (rawName[0] == 0 || rawName[0] == 1) {
+			continue
+		}
+
+		e := &isoEntry{
+			w:       w,
+			record:  rec,
+			name:    w.decodeName(rawName, rec[25]&isoFlagDirectory != 0),
+			dir:     rec[25]&isoFlagDirectory != 0,
+			extents: []isoExtent{parseIsoExtent(rec)},
+			modTime: parseIsoRecordingTime(rec[18:25]),
+		}
+
+		// apply Rock Ridge extensions
+		if w.rockRidge {
+			suStart := 33 + nameLen + (1 - nameLen%2) + w.suspSkip
+			if suStart < recLen {
+				if err := w.parseSystemUse(e, rec[suStart:], 0); err != nil {
+					return fmt.Errorf("iso: invalid rock ridge extension of %q: %w", e.name, err)
+				}
+			}
+			if e.relocated {
+				continue
+			}
+			if e.childLink != nil {
+				if err := w.resolveChildLink(e); err != nil {
+					return err
+				}
+			}
+		}
+
+		// check name
+		if len(e.name) == 0 || strings.Contains(e.name, "/") {
+			return fmt.Errorf("iso: invalid name %q in directory %q", e.name, d.path)
+		}
+		if len(d.path) > 0 {
+			e.name = d.path + "/" + e.name
+		}
+
+		// collect further extents of large files
+		switch {
+		case pending != nil:
+			pending.extents = append(pending.extents, e.extents[0])
+			if rec[25]&isoFlagMultiExtent != 0 {
+				continue
+			}
+			e, pending = pending, nil
+		case rec[25]&isoFlagMultiExtent != 0 && !e.dir:
+			pending = e
+			continue
+		}
+
+		// check extents of files
+		if !e.dir && !e.IsSymlink() {
+			for _, ext := range e.extents {
+				if int64(ext.block)*w.blockSize+int64(ext.length) > w.size {
+					return fmt.Errorf("iso: content of %q exceeds image size", e.name)
+				}
+			}
+		}
+
+		// queue subdirectories, which have not been visited
+		if e.dir && !w.visited[e.extents[0].block] {
+			w.visited[e.extents[0].block] = true
+			w.dirs = append(w.dirs, isoDirectory{path: e.name, extent: e.extents[0]})
+		}
+		w.entries = append(w.entries, e)
+	}
+	if pending != nil {
+		return fmt.Errorf("iso: incomplete multi-extent file %q", pending.name)
+	}
+	return nil
+}
+
+// decodeName converts the identifier of a record into a file name
+func (w *isoWalker) decodeName(raw []byte, dir bool) string {
+	var name string
+	if w.joliet {
+		u := make([]uint16, len(raw)/2)
+		for i := range u {
+			u[i] = binary.BigEndian.Uint16(raw[i*2:])
+		}
+		name = string(utf16.Decode(u))
+	} else {
+		name = string(raw)
+	}
+	if dir {
+		return name
+	}
+
+	// remove version and trailing dot of file names
+	if i := strings.LastIndexByte(name, ';'); i >= 0 {
+		name = name[:i]
+	}
+	return strings.TrimSuffix(name, ".")
+}
+
+// parseSystemUse applies the Rock Ridge entries in the system use area su to e.
+func (w *isoWalker) parseSystemUse(e *isoEntry, su []byte, continuations int) error {
+	var (
+		name        []byte
+		hasName     bool
+		continueAt  *isoExtent
+		continueOff int64
+	)
+	for len(su) >= 4 {
+		sig, l := string(su[0:2]), int(su[2])
+		if l < 4 || l > len(su) {
+			break
+		}
+		data := su[4:l]
+		su = su[l:]
+
+		switch sig {
+
+		// POSIX file attributes
+		case "PX":
+			if len(data) < 32 {
+				return fmt.Errorf("invalid PX entry")
+			}
+			mode := binary.LittleEndian.Uint32(data[0:4])
+			e.mode = &mode
+			e.uid = int(binary.LittleEndian.Uint32(data[16:20]))
+			e.gid = int(binary.LittleEndian.Uint32(data[24:28]))
+
+		// alternate name
+		case "NM":
+			if len(data) < 1 {
+				return fmt.Errorf("invalid NM entry")
+			}
+			if data[0]&0x06 == 0 { // ignore current and parent directory
+				name = append(name, data[1:]...)
+				hasName = true
+			}
+
+		// symbolic link
+		case "SL":
+			if len(data) < 1 {
+				return fmt.Errorf("invalid SL entry")
+			}
+			if err := e.appendLinkComponents(data[1:]); err != nil {
+				return err
+			}
+
+		// time stamps
+		case "TF":
+			if len(data) < 1 {
+				return fmt.Errorf("invalid TF entry")
+			}
+			e.parseTimestamps(data[0], data[1:])
+
+		// relocated directory, which is referenced by a child link
+		case "RE":
+			e.relocated = true
+
+		// child link to a relocated directory
+		case "CL":
+			if len(data) < 8 {
+				return fmt.Errorf("invalid CL entry")
+			}
+			block := binary.LittleEndian.Uint32(data[0:4])
+			e.childLink = &block
+
+		// continuation area
+		case "CE":
+			if len(data) < 24 {
+				return fmt.Errorf("invalid CE entry")
+			}
+			continueAt = &isoExtent{
+				block:  binary.LittleEndian.Uint32(data[0:4]),
+				length: binary.LittleEndian.Uint32(data[16:20]),
+			}
+			continueOff = int64(binary.LittleEndian.Uint32(data[8:12]))
+
+		// end of system use entries
+		case "ST":
+			su = nil
+		}
+	}
+	if hasName {
+		e.name = string(name)
+	}
+
+	// read continuation area
+	if continueAt != nil {
+		if continuations >= isoMaxContinuationAreas || continueAt.length > isoSectorSize {
+			return fmt.Errorf("invalid continuation area")
+		}
+		area := make([]byte, continueAt.length)
+		if _, err := w.readAt(area, continueAt.block, continueOff); err != nil {
+			return fmt.Errorf("cannot read continuation area: %w", err)
+		}
+		return w.parseSystemUse(e, area, continuations+1)
+	}
+	return nil
+}
+
+// resolveChildLink converts the entry e into the relocated directory, which is
+// referenced by its child link.
+func (w *isoWalker) resolveChildLink(e *isoEntry) error {
+	sector := make([]byte, isoSectorSize)
+	if _, err := w.readAt(sector, *e.childLink, 0); err != nil {
+		return fmt.Errorf("iso: cannot read relocated directory %q: %w", e.name, err)
+	}
+	if int(sector[0]) < isoMinRecordLength {
+		return fmt.Errorf("iso: invalid relocated directory %q", e.name)
+	}
+	e.dir = true
+	e.extents = []isoExtent{parseIsoExtent(sector)}
+	return nil
+}
+
+// parseIsoRecordingTime parses the recording time of a directory record
+func parseIsoRecordingTime(b []byte) time.Time {
+	if b[0] == 0 && b[1] == 0 && b[2] == 0 {
+		return time.Time{}
+	}
+	loc := time.FixedZone("", int(int8(b[6]))*15*60)
+	return time.Date(1900+int(b[0]), time.Month(b[1]), int(b[2]), int(b[3]), int(b[4]), int(b[5]), 0, loc)
+}
+
+// parseIsoLongTime parses a time stamp in the 17 byte format of volume descriptors
+func parseIsoLongTime(b []byte) time.Time {
+	digits := func(s []byte) int {
+		v, _ := strconv.Atoi(string(s))
+		return v
+	}
+	year := digits(b[0:4])
+	if year == 0 {
+		return time.Time{}
+	}
+	loc := time.FixedZone("", int(int8(b[16]))*15*60)
+	return time.Date(year, time.Month(digits(b[4:6])), digits(b[6:8]), digits(b[8:10]), digits(b[10:12]), digits(b[12:14]), digits(b[14:16])*10*int(time.Millisecond), loc)
+}
+
+// isoEntry is an entry in an ISO 9660 image
+type isoEntry struct {
+	w       *isoWalker
+	record  []byte
+	name    string
+	dir     bool
+	extents []isoExtent
+
+	modTime    time.Time
+	accessTime time.Time
+
+	// Rock Ridge attributes
+	mode        *uint32
+	uid         int
+	gid         int
+	linkParts   []string
+	linkPartEnd bool
+	relocated   bool
+	childLink   *uint32
+}
+
+// appendLinkComponents appends the components of a Rock Ridge SL entry to the link target
+func (e *isoEntry) appendLinkComponents(data []byte) error {
+	for len(data) > 0 {
+		if len(data) < 2 || 2+int(data[1]) > len(data) {
+			return fmt.Errorf("invalid SL component")
+		}
+		flags, content := data[0], data[2:2+int(data[1])]
+		data = data[2+int(data[1]):]
+
+		var part string
+		switch {
+		case flags&0x02 != 0:
+			part = "."
+		case flags&0x04 != 0:
+			part = ".."
+		case flags&0x08 != 0:
+			part = "/"
+		default:
+			part = string(content)
+		}
+
+		// continue the previous component, or start a new one
+		if len(e.linkParts) > 0 && !e.linkPartEnd {
+			e.linkParts[len(e.linkParts)-1] += part
+		} else {
+			e.linkParts = append(e.linkParts, part)
+		}
+		e.linkPartEnd = flags&0x01 == 0
+	}
+	return nil
+}
+
+// parseTimestamps applies the time stamps of a Rock Ridge TF entry
+func (e *isoEntry) parseTimestamps(flags byte, data []byte) {
+	size := 7
+	if flags&0x80 != 0 {
+		size = 17
+	}
+	for bit := 0; bit < 7; bit++ {
+		if flags&(1<<bit) == 0 {
+			continue
+		}
+		if len(data) < size {
+			return
+		}
+		var ts time.Time
+		if size == 7 {
+			ts = parseIsoRecordingTime(data[:size])
+		} else {
+			ts = parseIsoLongTime(data[:size])
+		}
+		data = data[size:]
+		switch bit {
+		case 1: // modify
+			e.modTime = ts
+		case 2: // access
+			e.accessTime = ts
+		}
+	}
+}
+
+// Name returns the name of the entry
+func (e *isoEntry) Name() string {
+	return e.name
+}
+
+// Size returns the size of the entry
+func (e *isoEntry) Size() int64 {
+	if e.dir || e.IsSymlink() {
+		return 0
+	}
+	var size int64
+	for _, ext := range e.extents {
+		size += int64(ext.length)
+	}
+	return size
+}
+
+// Mode returns the mode of the entry. Without Rock Ridge extensions, directories
+// have mode 0755 and files mode 0644.
+func (e *isoEntry) Mode() fs.FileMode {
+	if e.mode != nil {
+		mode := unixFileMode(*e.mode)
+		if e.dir {
+			mode = mode.Perm() | fs.ModeDir
+		}
+		return mode
+	}
+	if e.dir {
+		return fs.ModeDir | 0755
+	}
+	if len(e.linkParts) > 0 {
+		return fs.ModeSymlink | 0777
+	}
+	return 0644
+}
+
+// Linkname returns the target of a Rock Ridge symlink
+func (e *isoEntry) Linkname() string {
+	if len(e.linkParts) > 0 && e.linkParts[0] == "/" {
+		return "/" + strings.Join(e.linkParts[1:], "/")
+	}
+	return strings.Join(e.linkParts, "/")
+}
+
+// IsRegular returns true if the entry is a regular file
+func (e *isoEntry) IsRegular() bool {
+	return e.Mode().Type() == 0
+}
+
+// IsDir returns true if the entry is a directory
+func (e *isoEntry) IsDir() bool {
+	return e.dir
+}
+
+// IsHardlink returns false, because hard links are stored as regular files
+func (e *isoEntry) IsHardlink() bool {
+	return false
+}
+
+// IsSymlink returns true if the entry is a Rock Ridge symlink
+func (e *isoEntry) IsSymlink() bool {
+	return !e.dir && (len(e.linkParts) > 0 || (e.mode != nil && *e.mode&unixModeTypeMask == unixModeSymlink))
+}
+
+// Open returns a reader for the content of the entry
+func (e *isoEntry) Open() (io.ReadCloser, error) {
+	readers := make([]io.Reader, 0, len(e.extents))
+	for _, ext := range e.extents {
+		readers = append(readers, io.NewSectionReader(e.w.ra, int64(ext.block)*e.w.blockSize, int64(ext.length)))
+	}
+	return &noopReaderCloser{io.MultiReader(readers...)}, nil
+}
+
+// Type returns the type of the entry
+func (e *isoEntry) Type() fs.FileMode {
+	return e.Mode().Type()
+}
+
+// AccessTime returns the access time of the entry, or the modification time if
+// the access time is unknown
+func (e *isoEntry) AccessTime() time.Time {
+	if e.accessTime.IsZero() {
+		return e.modTime
+	}
+	return e.accessTime
+}
+
+// ModTime returns the modification time of the entry
+func (e *isoEntry) ModTime() time.Time {
+	return e.modTime
+}
+
+// Sys returns the directory record of the entry
+func (e *isoEntry) Sys() interface{} {
+	return e.record
+}
+
+// Gid returns the group id of the entry, or the group id of the current process
+// without Rock Ridge extensions
+func (e *isoEntry) Gid() int {
+	if e.mode == nil {
+		return os.Getegid()
+	}
+	return e.gid
+}
+
+// Uid returns the user id of the entry, or the user id of the current process
+// without Rock Ridge extensions
+func (e *isoEntry) Uid() int {
+	if e.mode == nil {
+		return os.Getuid()
+	}
+	return e.uid
+}
@@ -0,0 +1,429 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestUnpackIso(t *testing.T) {
+	contents := []archiveContent{
+		{Name: "dir", Mode: fs.ModeDir | 0755},
+		{Name: "dir/file.txt", Content: []byte("hello world"), Mode: 0640},
+		{Name: "dir/sub", Mode: fs.ModeDir | 0755},
+		{Name: "dir/sub/a long file name.txt", Content: []byte("hello iso"), Mode: 0644},
+		{Name: "dir/link", Linktarget: "file.txt", Mode: fs.ModeSymlink | 0777},
+	}
+
+	tests := []struct {
+		name        string
+		src         []byte
+		opts        []extract.ConfigOption
+		expected    map[string]string
+		symlinks    map[string]string
+		modes       map[string]fs.FileMode
+		expectError bool
+	}{
+		{
+			name:     "plain",
+			src:      packIso(t, contents, isoOptions{}),
+			expected: map[string]string{"DIR/FILE.TXT": "hello world", "DIR/SUB/A LONG FILE NAME.TXT": "hello iso"},
+		},
+		{
+			name:     "joliet",
+			src:      packIso(t, contents, isoOptions{joliet: true}),
+			expected: map[string]string{"dir/file.txt": "hello world", "dir/sub/a long file name.txt": "hello iso"},
+		},
+		{
+			name:     "rock ridge",
+			src:      packIso(t, contents, isoOptions{rockRidge: true}),
+			expected: map[string]string{"dir/file.txt": "hello world", "dir/sub/a long file name.txt": "hello iso"},
+			symlinks: map[string]string{"dir/link": "file.txt"},
+			modes:    map[string]fs.FileMode{"dir/file.txt": 0640},
+		},
+		{
+			name:     "rock ridge preferred over joliet",
+			src:      packIso(t, contents, isoOptions{rockRidge: true, joliet: true}),
+			expected: map[string]string{"dir/file.txt": "hello world"},
+			symlinks: map[string]string{"dir/link": "file.txt"},
+		},
+		{
+			name:     "multi extent",
+			src:      packIso(t, contents, isoOptions{joliet: true, multiExtent: true}),
+			expected: map[string]string{"dir/file.txt": "hello world", "dir/sub/a long file name.txt": "hello iso"},
+		},
+		{
+			name:     "patterns",
+			src:      packIso(t, contents, isoOptions{joliet: true}),
+			opts:     []extract.ConfigOption{extract.WithPatterns("dir", "dir/sub", "dir/sub/*")},
+			expected: map[string]string{"dir/sub/a long file name.txt": "hello iso"},
+		},
+		{
+			name: "directory loop",
+			src: packIso(t, []archiveContent{
+				{Name: "loop", Mode: fs.ModeDir | 0755},
+				{Name: "file.txt", Content: []byte("hello world"), Mode: 0644},
+			}, isoOptions{joliet: true, loop: "loop"}),
+			expected: map[string]string{"file.txt": "hello world"},
+		},
+		{
+			name: "path traversal",
+			src: packIso(t, []archiveContent{
+				{Name: "..", Content: []byte("hello world"), Mode: 0644},
+			}, isoOptions{rockRidge: true}),
+			expectError: true,
+		},
+		{
+			name: "symlink traversal",
+			src: packIso(t, []archiveContent{
+				{Name: "link", Linktarget: "../../etc/passwd", Mode: fs.ModeSymlink | 0777},
+			}, isoOptions{rockRidge: true}),
+			expectError: true,
+		},
+		{
+			name:        "deny symlinks",
+			src:         packIso(t, contents, isoOptions{rockRidge: true}),
+			opts:        []extract.ConfigOption{extract.WithDenySymlinkExtraction(true)},
+			expectError: true,
+		},
+		{
+			name:        "max input size",
+			src:         packIso(t, contents, isoOptions{}),
+			opts:        []extract.ConfigOption{extract.WithMaxInputSize(1 << 15)},
+			expectError: true,
+		},
+		{
+			name:        "truncated",
+			src:         packIso(t, contents, isoOptions{})[:0x8800],
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		for _, cacheFunction := range []func(*testing.T, []byte) io.Reader{asIoReader, asFileReader} {
+			t.Run(tc.name, func(t *testing.T) {
+				src := cacheFunction(t, tc.src)
+				opts := tc.opts
+
+				// the volume descriptor is only detected in inputs with random access
+				if _, ok := src.(io.Seeker); !ok {
+					opts = append(slices.Clone(opts), extract.WithExtractType("iso"))
+				}
+				cfg := extract.NewConfig(opts...)
+				tm := extract.NewTargetMemory()
+				err := extract.UnpackTo(context.Background(), tm, "", src, cfg)
+				if tc.expectError {
+					if err == nil {
+						t.Fatalf("expected error, got nil")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				checkMemoryContent(t, tm, tc.expected)
+				for name, target := range tc.symlinks {
+					link, err := tm.Readlink(name)
+					if err != nil || link != target {
+						t.Errorf("expected link target %q for %q, got %q (%v)", target, name, link, err)
+					}
+				}
+				for name, mode := range tc.modes {
+					fi, err := tm.Lstat(name)
+					if err != nil || fi.Mode().Perm() != mode {
+						t.Errorf("expected mode %v for %q, got %v (%v)", mode, name, fi, err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestIsoDetectionInStream(t *testing.T) {
+	src := packIso(t, []archiveContent{{Name: "file.txt", Content: []byte("hello world"), Mode: 0644}}, isoOptions{})
+
+	// the stream is not buffered up to the volume descriptor to detect the image
+	r := &countingReader{r: asIoReader(t, src)}
+	_, err := extract.List(context.Background(), r, nil)
+	if !errors.Is(err, extract.ErrNoExtractorFound) {
+		t.Fatalf("expected error %v, got %v", extract.ErrNoExtractorFound, err)
+	}
+	if r.n >= 0x8000 {
+		t.Errorf("expected header to be read without the volume descriptor, got %d bytes", r.n)
+	}
+
+	// the image is detected in inputs with random access
+	entries, err := extract.List(context.Background(), bytes.NewReader(src), nil)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %v (%v)", entries, err)
+	}
+}
+
+// countingReader counts the bytes, which are read from r
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read reads from r and counts the bytes read
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func TestListIso(t *testing.T) {
+	src := packIso(t, []archiveContent{
+		{Name: "dir", Mode: fs.ModeDir | 0755},
+		{Name: "dir/file.txt", Content: []byte("hello world"), Mode: 0600, Uid: 1000, Gid: 100},
+		{Name: "dir/link", Linktarget: "file.txt", Mode: fs.ModeSymlink | 0777},
+	}, isoOptions{rockRidge: true})
+	entries, err := extract.List(context.Background(), asFileReader(t, src), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %v", len(entries), entries)
+	}
+	if entries[0].Name != "dir" || entries[0].Type != extract.EntryTypeDir {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+	if entries[1].Name != "dir/file.txt" || entries[1].Type != extract.EntryTypeRegular || entries[1].Size != 11 || entries[1].Mode.Perm() != 0600 || entries[1].Uid != 1000 || entries[1].Gid != 100 {
+		t.Errorf("unexpected entry: %+v", entries[1])
+	}
+	if entries[2].Name != "dir/link" || entries[2].Type != extract.EntryTypeSymlink || entries[2].Linkname != "file.txt" {
+		t.Errorf("unexpected entry: %+v", entries[2])
+	}
+}
+
+// isoOptions are the options to create an ISO 9660 image
+type isoOptions struct {
+	// joliet adds a Joliet supplementary volume descriptor
+	joliet bool
+
+	// rockRidge adds Rock Ridge extensions to the primary volume
+	rockRidge bool
+
+	// multiExtent splits the content of regular files into two extents
+	multiExtent bool
+
+	// loop adds a record in the given directory, which points to the root directory
+	loop string
+}
+
+// packIso creates an ISO 9660 image with the given content and options
+func packIso(t *testing.T, content []archiveContent, opts isoOptions) []byte {
+	t.Helper()
+	const sector = 2048
+
+	// collect children of all directories
+	children := map[string][]archiveContent{"": nil}
+	for _, c := range content {
+		dir := path.Dir(c.Name)
+		if dir == "." {
+			dir = ""
+		}
+		children[dir] = append(children[dir], c)
+		if _, found := children[c.Name]; c.Mode.IsDir() && !found {
+			children[c.Name] = nil
+		}
+	}
+	dirs := make([]string, 0, len(children))
+	for dir := range children {
+		dirs = append(dirs, dir)
+	}
+	slices.Sort(dirs)
+
+	// allocate sectors: system area, volume descriptors, directories of both trees, files
+	next := uint32(16 + 2)
+	if opts.joliet {
+		next++
+	}
+	primaryDirs := make(map[string]uint32)
+	jolietDirs := make(map[string]uint32)
+	for _, dir := range dirs {
+		primaryDirs[dir] = next
+		next++
+	}
+	if opts.joliet {
+		for _, dir := range dirs {
+			jolietDirs[dir] = next
+			next++
+		}
+	}
+	files := make(map[string]uint32)
+	for _, c := range content {
+		if c.Mode.IsRegular() {
+			files[c.Name] = next
+			next += uint32(len(c.Content)/sector + 2)
+		}
+	}
+	img := make([]byte, int(next)*sector)
+
+	record := func(name []byte, block, size uint32, flags byte, su []byte) []byte {
+		r := make([]byte, 33+len(name))
+		if len(name)%2 == 0 {
+			r = append(r, 0)
+		}
+		r = append(r, su...)
+		if len(r)%2 != 0 {
+			r = append(r, 0)
+		}
+		r[0] = byte(len(r))
+		binary.LittleEndian.PutUint32(r[2:], block)
+		binary.BigEndian.PutUint32(r[6:], block)
+		binary.LittleEndian.PutUint32(r[10:], size)
+		binary.BigEndian.PutUint32(r[14:], size)
+		copy(r[18:25], []byte{124, 1, 2, 3, 4, 5, 0})
+		r[25] = flags
+		binary.LittleEndian.PutUint16(r[28:], 1)
+		binary.BigEndian.PutUint16(r[30:], 1)
+		r[32] = byte(len(name))
+		copy(r[33:], name)
+		return r
+	}
+	susp := func(sig string, data ...byte) []byte {
+		return append([]byte{sig[0], sig[1], byte(4 + len(data)), 1}, data...)
+	}
+	bothEndian := func(v uint32) []byte {
+		return binary.BigEndian.AppendUint32(binary.LittleEndian.AppendUint32(nil, v), v)
+	}
+	rockRidge := func(c archiveContent) []byte {
+		mode := uint32(c.Mode.Perm())
+		switch {
+		case c.Mode.IsDir():
+			mode |= 0040000
+		case c.Mode&fs.ModeSymlink != 0:
+			mode |= 0120000
+		default:
+			mode |= 0100000
+		}
+		px := slices.Concat(bothEndian(mode), bothEndian(1), bothEndian(uint32(c.Uid)), bothEndian(uint32(c.Gid)))
+		su := slices.Concat(susp("PX", px...), susp("NM", append([]byte{0}, path.Base(c.Name)...)...))
+		if c.Linktarget != "" {
+			components := []byte{0}
+			for _, part := range strings.Split(c.Linktarget, "/") {
+				switch part {
+				case "..":
+					components = append(components, 0x04, 0)
+				case ".":
+					components = append(components, 0x02, 0)
+				default:
+					components = append(components, 0, byte(len(part)))
+					components = append(components, part...)
+				}
+			}
+			su = append(su, susp("SL", components...)...)
+		}
+		return su
+	}
+	isoName := func(c archiveContent, joliet bool) []byte {
+		name := path.Base(c.Name)
+		if !c.Mode.IsDir() {
+			name += ";1"
+		}
+		if !joliet {
+			return []byte(strings.ToUpper(name))
+		}
+		var b []byte
+		for _, u := range utf16.Encode([]rune(name)) {
+			b = binary.BigEndian.AppendUint16(b, u)
+		}
+		return b
+	}
+
+	// write directories
+	writeTree := func(dirBlocks map[string]uint32, joliet bool) {
+		for _, dir := range dirs {
+			var data []byte
+			var self []byte
+			if dir == "" && opts.rockRidge && !joliet {
+				self = susp("SP", 0xbe, 0xef, 0)
+			}
+			data = append(data, record([]byte{0}, dirBlocks[dir], sector, 0x02, self)...)
+			data = append(data, record([]byte{1}, dirBlocks[path.Dir(dir)], sector, 0x02, nil)...)
+			for _, c := range children[dir] {
+				var su []byte
+				if opts.rockRidge && !joliet {
+					su = rockRidge(c)
+				}
+				name := isoName(c, joliet)
+				switch {
+				case c.Mode.IsDir():
+					data = append(data, record(name, dirBlocks[c.Name], sector, 0x02, su)...)
+				case c.Mode.IsRegular() && opts.multiExtent && len(c.Content) > 1:
+					half := uint32(len(c.Content) / 2)
+					data = append(data, record(name, files[c.Name], half, 0x80, su)...)
+					data = append(data, record(name, files[c.Name]+1, uint32(len(c.Content))-half, 0, nil)...)
+				case c.Mode.IsRegular():
+					data = append(data, record(name, files[c.Name], uint32(len(c.Content)), 0, su)...)
+				default:
+					data = append(data, record(name, 0, 0, 0, su)...)
+				}
+			}
+			if dir == opts.loop && opts.loop != "" {
+				data = append(data, record([]byte("LOOP"), dirBlocks[""], sector, 0x02, nil)...)
+			}
+			if len(data) > sector {
+				t.Fatalf("directory %q exceeds one sector", dir)
+			}
+			copy(img[int(dirBlocks[dir])*sector:], data)
+		}
+	}
+	writeTree(primaryDirs, false)
+	if opts.joliet {
+		writeTree(jolietDirs, true)
+	}
+
+	// write file content
+	for _, c := range content {
+		switch {
+		case c.Mode.IsRegular() && opts.multiExtent && len(c.Content) > 1:
+			// the second extent starts in the next sector
+			half := len(c.Content) / 2
+			copy(img[int(files[c.Name])*sector:], c.Content[:half])
+			copy(img[(int(files[c.Name])+1)*sector:], c.Content[half:])
+		case c.Mode.IsRegular():
+			copy(img[int(files[c.Name])*sector:], c.Content)
+		}
+	}
+
+	// write volume descriptors
+	descriptor := func(block int, typ byte, root uint32) {
+		vd := img[block*sector : (block+1)*sector]
+		vd[0] = typ
+		copy(vd[1:], "CD001")
+		vd[6] = 1
+		if typ == 255 {
+			return
+		}
+		copy(vd[80:], bothEndian(next))
+		binary.LittleEndian.PutUint16(vd[128:], sector)
+		binary.BigEndian.PutUint16(vd[130:], sector)
+		copy(vd[156:], record([]byte{0}, root, sector, 0x02, nil))
+		if typ == 2 {
+			copy(vd[88:], "%/E")
+		}
+	}
+	descriptor(16, 1, primaryDirs[""])
+	if opts.joliet {
+		descriptor(17, 2, jolietDirs[""])
+		descriptor(18, 255, 0)
+	} else {
+		descriptor(17, 255, 0)
+	}
+	return img
+}
@@ -0,0 +1,183 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// EntryType is the type of an entry in an archive.
+type EntryType int
+
+const (
+	// EntryTypeUnsupported is an entry that cannot be extracted, e.g., FIFO, block or character devices.
+	EntryTypeUnsupported EntryType = iota
+
+	// EntryTypeRegular is a regular file.
+	EntryTypeRegular
+
+	// EntryTypeDir is a directory.
+	EntryTypeDir
+
+	// EntryTypeSymlink is a symbolic link.
+	EntryTypeSymlink
+
+	// EntryTypeHardlink is a hard link to a regular file in the same archive.
+	EntryTypeHardlink
+)
+
+// String returns a string representation of [EntryType].
+func (et EntryType) String() string {
+	switch et {
+	case EntryTypeRegular:
+		return "file"
+	case EntryTypeDir:
+		return "dir"
+	case EntryTypeSymlink:
+		return "symlink"
+	case EntryTypeHardlink:
+		return "hardlink"
+	default:
+		return "unsupported"
+	}
+}
+
+// MarshalText implements the [encoding.TextMarshaler] interface.
+func (et EntryType) MarshalText() ([]byte, error) {
+	return []byte(et.String()), nil
+}
+
+// EntryInfo describes an entry in an archive.
+type EntryInfo struct {
+	// Name is the name of the entry in the archive
+	Name string `json:"name"`
+
+	// Size is the uncompressed size of the entry. The size is -1, if it is unknown
+	// without decompressing the entry, e.g., for compressed single files.
+	Size int64 `json:"size"`
+
+	// Mode is the file mode of the entry
+	Mode fs.FileMode `json:"mode"`
+
+	// Type is the type of the entry
+	Type EntryType `json:"type"`
+
+	// Linkname is the target of a symlink or hard link
+	Linkname string `json:"linkname,omitempty"`
+
+	// ModTime is the modification time of the entry
+	ModTime time.Time `json:"mod_time"`
+
+	// Uid is the user id of the entry owner
+	Uid int `json:"uid"`
+
+	// Gid is the group id of the entry owner
+	Gid int `json:"gid"`
+
+	// Metadata contains format specific metadata of the entry, see [MetadataEntry]
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// newEntryInfo creates an [EntryInfo] from the given archive entry.
+func newEntryInfo(ae Entry) EntryInfo {
+	ei := EntryInfo{
+		Name:    ae.Name(),
+		Size:    ae.Size(),
+		Mode:    ae.Mode(),
+		Type:    entryType(ae),
+		ModTime: ae.ModTime(),
+		Uid:     ae.Uid(),
+		Gid:     ae.Gid(),
+	}
+	if ei.Type == EntryTypeSymlink || ei.Type == EntryTypeHardlink {
+		ei.Linkname = ae.Linkname()
+	}
+	if me, ok := ae.(MetadataEntry); ok {
+		ei.Metadata = me.Metadata()
+	}
+	return ei
+}
+
+// entryType determines the [EntryType] of the given archive entry.
+func entryType(ae Entry) EntryType {
+	switch {
+	case ae.IsDir():
+		return EntryTypeDir
+	case ae.IsRegular():
+		return EntryTypeRegular
+	case ae.IsSymlink():
+		return EntryTypeSymlink
+	case ae.IsHardlink():
+		return EntryTypeHardlink
+	default:
+		return EntryTypeUnsupported
+	}
+}
+
+// List enumerates all entries of the archive in src without extracting them, according to
+// the given configuration. If cfg is nil, the default configuration is used.
+//
+// The archive type is determined in the same way as for [UnpackTo]. The configured maximum
+// input size and maximum number of files are enforced, and only entries that match the
+// configured patterns are returned. For compressed single files, the decompressed content
+// is returned as a single entry with unknown size.
+func List(ctx context.Context, src io.Reader, cfg *Config) ([]EntryInfo, error) {
+	var entries []EntryInfo
+	for ae, err := range walkEntries(ctx, src, cfg) {
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, newEntryInfo(ae))
+	}
+	return entries, nil
+}
+
+// newWalker identifies the archive type of src, based on the configured extraction type or the
+// magic bytes and file extension, and opens a Walker for it.
+func newWalker(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	available, err := cfg.extractors()
+	if err != nil {
+		return nil, err
+	}
+	if et := cfg.ExtractType(); len(et) > 0 {
+		ae, found := available[et]
+		if !found {
+			return nil, fmt.Errorf("%w: %q not in %q", ErrUnsupportedFileType, et, available.Extensions())
+		}
+		if et == fileExtensionTarGZip {
+			cfg.SetNoUntarAfterDecompression(false)
+		}
+		w, err := ae.Walker(ctx, src, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrFailedToList, err)
+		}
+		return w, nil
+	}
+
+	header, reader, err := getHeader(src, available)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToReadHeader, err)
+	}
+
+	var name string
+	if f, ok := src.(*os.File); ok {
+		name = filepath.Ext(f.Name())
+	}
+
+	ae, found := available.getExtractor(header, name)
+	if !found {
+		return nil, ErrNoExtractorFound
+	}
+	w, err := ae.Walker(ctx, reader, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToList, err)
+	}
+	return w, nil
+}
@@ -0,0 +1,258 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func ExampleList() {
+	var (
+		ctx = context.Background()    // context for cancellation
+		src = openFile("example.zip") // source reader
+		cfg = extract.NewConfig()     // custom config for listing
+	)
+
+	// list entries
+	entries, err := extract.List(ctx, src, cfg)
+	if err != nil {
+		// handle error
+	}
+
+	for _, e := range entries {
+		fmt.Println(e.Type, e.Name, e.Size)
+	}
+	// Output:
+	// file example.txt 15
+}
+
+func TestList(t *testing.T) {
+
+	contents := []archiveContent{
+		{Name: "test", Content: []byte("hello world"), Mode: 0644},
+		{Name: "dir", Mode: fs.ModeDir | 0755},
+		{Name: "dir/entry", Content: []byte("hello world"), Mode: 0644},
+		{Name: "dir/link", Linktarget: "../test", Mode: fs.ModeSymlink | 0755},
+		{Name: "dir/hardlink", Linktarget: "test", Hardlink: true, Mode: 0644},
+	}
+
+	tests := []struct {
+		name          string
+		src           []byte
+		cfg           *extract.Config
+		expectedNames []string
+		expectedTypes []extract.EntryType
+		expectError   error
+	}{
+		{
+			name:          "tar",
+			src:           packTar(t, contents),
+			expectedNames: []string{"test", "dir", "dir/entry", "dir/link", "dir/hardlink"},
+			expectedTypes: []extract.EntryType{extract.EntryTypeRegular, extract.EntryTypeDir, extract.EntryTypeRegular, extract.EntryTypeSymlink, extract.EntryTypeHardlink},
+		},
+		{
+			name:          "tar.gz",
+			src:           compressGzip(t, packTar(t, contents[:2])),
+			expectedNames: []string{"test", "dir"},
+			expectedTypes: []extract.EntryType{extract.EntryTypeRegular, extract.EntryTypeDir},
+		},
+		{
+			name:          "zip",
+			src:           packZip(t, contents[:4]),
+			expectedNames: []string{"test", "dir", "dir/entry", "dir/link"},
+			expectedTypes: []extract.EntryType{extract.EntryTypeRegular, extract.EntryTypeDir, extract.EntryTypeRegular, extract.EntryTypeSymlink},
+		},
+		{
+			name:          "7z",
+			src:           pack7z(t, nil),
+			expectedNames: []string{"dir/", "dir/entry", "dir/link", "test"},
+		},
+		{
+			name:          "rar",
+			src:           packRar(t, nil),
+			expectedNames: []string{"test", "dir/entry", "dir/link", "dir"},
+		},
+		{
+			name:          "gzip",
+			src:           compressGzip(t, []byte("hello world")),
+			expectedNames: []string{""}, // name depends on the input file name
+			expectedTypes: []extract.EntryType{extract.EntryTypeRegular},
+		},
+		{
+			name:          "tar with patterns",
+			src:           packTar(t, contents),
+			cfg:           extract.NewConfig(extract.WithPatterns("dir/*")),
+			expectedNames: []string{"dir/entry", "dir/link", "dir/hardlink"},
+		},
+		{
+			name:        "tar with max files",
+			src:         packTar(t, contents),
+			cfg:         extract.NewConfig(extract.WithMaxFiles(2)),
+			expectError: extract.ErrMaxFilesExceeded,
+		},
+		{
+			name:        "zip with max input size",
+			src:         packZip(t, contents),
+			cfg:         extract.NewConfig(extract.WithMaxInputSize(10)),
+			expectError: extract.ErrFailedToList,
+		},
+		{
+			name:        "unknown type",
+			src:         []byte("rubbish"),
+			expectError: extract.ErrNoExtractorFound,
+		},
+	}
+
+	for _, tc := range tests {
+		for _, cacheFunction := range []func(*testing.T, []byte) io.Reader{asIoReader, asFileReader} {
+			t.Run(tc.name, func(t *testing.T) {
+				entries, err := extract.List(context.Background(), cacheFunction(t, tc.src), tc.cfg)
+				if tc.expectError != nil {
+					if !errors.Is(err, tc.expectError) {
+						t.Fatalf("expected error %v, got %v", tc.expectError, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(entries) != len(tc.expectedNames) {
+					t.Fatalf("expected %d entries, got %d: %v", len(tc.expectedNames), len(entries), entries)
+				}
+				for i, e := range entries {
+					if tc.expectedNames[i] != "" && e.Name != tc.expectedNames[i] {
+						t.Errorf("expected name %q, got %q", tc.expectedNames[i], e.Name)
+					}
+					if tc.expectedTypes != nil && e.Type != tc.expectedTypes[i] {
+						t.Errorf("expected type %s for %q, got %s", tc.expectedTypes[i], e.Name, e.Type)
+					}
+					if e.Type == extract.EntryTypeSymlink && e.Linkname != "../test" {
+						t.Errorf("expected link target %q for %q, got %q", "../test", e.Name, e.Linkname)
+					}
+				}
+			})
+		}
+	}
+}
+
+func ExampleWalk() {
+	var (
+		ctx = context.Background()    // context for cancellation
+		src = openFile("example.zip") // source reader
+		cfg = extract.NewConfig()     // custom config for walking
+	)
+
+	// walk entries and read their content
+	for e, err := range extract.Walk(ctx, src, cfg) {
+		if err != nil {
+			// handle error
+			break
+		}
+		if !e.IsRegular() {
+			continue
+		}
+		r, err := e.Open()
+		if err != nil {
+			// handle error
+			break
+		}
+		content, _ := io.ReadAll(r)
+		r.Close()
+		fmt.Println(e.Name(), string(content))
+	}
+	// Output:
+	// example.txt example content
+}
+
+func TestWalk(t *testing.T) {
+
+	tests := []struct {
+		name            string
+		src             []byte
+		expectedContent map[string]string
+		expectedUnsafe  int
+	}{
+		{
+			name: "tar",
+			src: packTar(t, []archiveContent{
+				{Name: "test", Content: []byte("hello world"), Mode: 0644},
+				{Name: "dir", Mode: fs.ModeDir | 0755},
+				{Name: "dir/entry", Content: []byte("hello dir"), Mode: 0644},
+			}),
+			expectedContent: map[string]string{"test": "hello world", "dir/entry": "hello dir"},
+		},
+		{
+			name: "tar.gz",
+			src: compressGzip(t, packTar(t, []archiveContent{
+				{Name: "test", Content: []byte("hello world"), Mode: 0644},
+			})),
+			expectedContent: map[string]string{"test": "hello world"},
+		},
+		{
+			name: "zip",
+			src: packZip(t, []archiveContent{
+				{Name: "test", Content: []byte("hello world"), Mode: 0644},
+			}),
+			expectedContent: map[string]string{"test": "hello world"},
+		},
+		{
+			name: "unsafe entries",
+			src: packTar(t, []archiveContent{
+				{Name: "../escaped", Content: []byte("hello world"), Mode: 0644},
+				{Name: "link", Linktarget: "../../etc/passwd", Mode: fs.ModeSymlink | 0777},
+				{Name: "hardlink", Linktarget: "../escaped", Hardlink: true, Mode: 0644},
+				{Name: "test", Content: []byte("hello world"), Mode: 0644},
+			}),
+			expectedContent: map[string]string{"test": "hello world"},
+			expectedUnsafe:  3,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			content := make(map[string]string)
+			var unsafe int
+			for e, err := range extract.Walk(context.Background(), asIoReader(t, tc.src), nil) {
+				if errors.Is(err, extract.ErrUnsafeEntryName) {
+					unsafe++
+					continue
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !e.IsRegular() {
+					continue
+				}
+				r, err := e.Open()
+				if err != nil {
+					t.Fatalf("error opening entry: %v", err)
+				}
+				data, err := io.ReadAll(r)
+				r.Close()
+				if err != nil {
+					t.Fatalf("error reading entry: %v", err)
+				}
+				content[e.Name()] = string(data)
+			}
+			if unsafe != tc.expectedUnsafe {
+				t.Errorf("expected %d unsafe entries, got %d", tc.expectedUnsafe, unsafe)
+			}
+			if len(content) != len(tc.expectedContent) {
+				t.Fatalf("expected %d files, got %d: %v", len(tc.expectedContent), len(content), content)
+			}
+			for name, c := range tc.expectedContent {
+				if content[name] != c {
+					t.Errorf("expected content %q for %q, got %q", c, name, content[name])
+				}
+			}
+		})
+	}
+}
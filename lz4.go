@@ -29,6 +29,11 @@ func unpackLZ4(ctx context.Context, t Target, dst string, src io.Reader, cfg *Co
 	return decompress(ctx, t, dst, src, cfg, decompressLZ4Stream, fileExtensionLZ4)
 }
 
+// walkLZ4 opens the lz4 compressed src and returns a Walker for the decompressed content.
+func walkLZ4(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return walkDecompressed(ctx, src, cfg, decompressLZ4Stream, fileExtensionLZ4)
+}
+
 // decompressZlibStream returns an io.Reader that decompresses src with zlib algorithm
 func decompressLZ4Stream(src io.Reader) (io.Reader, error) {
 	return lz4.NewReader(src), nil
@@ -0,0 +1,118 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// patternResult is the result of matching the name of an entry against the include and exclude
+// patterns of a configuration.
+type patternResult int
+
+const (
+	patternsNotConfigured patternResult = iota // no include or exclude patterns are configured
+	patternIncluded                            // entry is extracted
+	patternMismatch                            // entry matches no include pattern
+	patternExcluded                            // entry matches an exclude pattern
+)
+
+// skip returns true if the entry is not extracted.
+func (r patternResult) skip() bool {
+	return r == patternMismatch || r == patternExcluded
+}
+
+// matchPatterns matches name against the include and exclude patterns and regular expressions
+// of cfg. An entry is included if no include patterns and regular expressions are configured or
+// any of them matches, and it is not matched by any exclude pattern or regular expression.
+func matchPatterns(cfg *Config, name string) (patternResult, error) {
+	include := cfg.Patterns()
+	exclude := cfg.ExcludePatterns()
+	includeRegexps := cfg.RegexpPatterns()
+	excludeRegexps := cfg.ExcludeRegexpPatterns()
+	if len(include) == 0 && len(exclude) == 0 && len(includeRegexps) == 0 && len(excludeRegexps) == 0 {
+		return patternsNotConfigured, nil
+	}
+
+	// check include patterns
+	if len(include) > 0 || len(includeRegexps) > 0 {
+		match, err := checkPatterns(include, name)
+		if err != nil {
+			return patternMismatch, err
+		}
+		if !match && !checkRegexps(includeRegexps, name) {
+			return patternMismatch, nil
+		}
+	}
+
+	// check exclude patterns
+	excluded, err := checkPatterns(exclude, name)
+	if err != nil {
+		return patternMismatch, err
+	}
+	if excluded || checkRegexps(excludeRegexps, name) {
+		return patternExcluded, nil
+	}
+	return patternIncluded, nil
+}
+
+// checkPatterns checks if the given path matches any of the given patterns.
+// If no patterns are given, the function returns false.
+func checkPatterns(patterns []string, name string) (bool, error) {
+	name = path.Clean(name)
+	for _, pattern := range patterns {
+		if match, err := matchGlob(pattern, name); err != nil {
+			return false, fmt.Errorf("failed to match pattern: %w", err)
+		} else if match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkRegexps checks if the given path matches any of the given regular expressions.
+func checkRegexps(regexps []*regexp.Regexp, name string) bool {
+	name = path.Clean(name)
+	for _, re := range regexps {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob reports whether name matches the shell pattern. The pattern is matched per path
+// component with [path.Match], and the component "**" matches zero or more path components.
+// A pattern with a trailing slash matches the directory and everything below it.
+func matchGlob(pattern string, name string) (bool, error) {
+	if strings.HasSuffix(pattern, "/") {
+		pattern = strings.TrimRight(pattern, "/") + "/**"
+	}
+	return matchComponents(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchComponents matches the components of a name against the components of a pattern.
+func matchComponents(pattern []string, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if match, err := matchComponents(pattern[1:], name[i:]); err != nil || match {
+					return match, err
+				}
+			}
+			return false, nil
+		}
+		if len(name) == 0 {
+			return false, nil
+		}
+		if match, err := path.Match(pattern[0], name[0]); err != nil || !match {
+			return false, err
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0, nil
+}
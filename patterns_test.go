@@ -0,0 +1,171 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestPatterns(t *testing.T) {
+	archive := packTar(t, []archiveContent{
+		{Name: "README.md", Content: []byte("readme"), Mode: 0644},
+		{Name: "docs/a.md", Content: []byte("a"), Mode: 0644},
+		{Name: "docs/sub/b.txt", Content: []byte("b"), Mode: 0644},
+		{Name: "src/main.go", Content: []byte("main"), Mode: 0644},
+		{Name: "src/node_modules/x/index.js", Content: []byte("x"), Mode: 0644},
+		{Name: "node_modules/y.js", Content: []byte("y"), Mode: 0644},
+		{Name: "bin/tool.exe", Content: []byte("tool"), Mode: 0644},
+	})
+
+	tests := []struct {
+		name       string
+		opts       []extract.ConfigOption
+		expected   []string
+		includes   int64
+		excludes   int64
+		mismatches int64
+		expectErr  bool
+	}{
+		{
+			name:     "no patterns",
+			expected: []string{"README.md", "bin/tool.exe", "docs/a.md", "docs/sub/b.txt", "node_modules/y.js", "src/main.go", "src/node_modules/x/index.js"},
+		},
+		{
+			name:       "shell pattern",
+			opts:       []extract.ConfigOption{extract.WithPatterns("*.md")},
+			expected:   []string{"README.md"},
+			includes:   1,
+			mismatches: 6,
+		},
+		{
+			name:       "recursive glob",
+			opts:       []extract.ConfigOption{extract.WithPatterns("**/*.md", "**/*.go")},
+			expected:   []string{"README.md", "docs/a.md", "src/main.go"},
+			includes:   3,
+			mismatches: 4,
+		},
+		{
+			name:       "directory prefix",
+			opts:       []extract.ConfigOption{extract.WithPatterns("docs/")},
+			expected:   []string{"docs/a.md", "docs/sub/b.txt"},
+			includes:   2,
+			mismatches: 5,
+		},
+		{
+			name:     "exclude patterns",
+			opts:     []extract.ConfigOption{extract.WithExcludePatterns("**/node_modules/", "**/*.exe")},
+			expected: []string{"README.md", "docs/a.md", "docs/sub/b.txt", "src/main.go"},
+			includes: 4,
+			excludes: 3,
+		},
+		{
+			name:       "include and exclude patterns",
+			opts:       []extract.ConfigOption{extract.WithPatterns("docs/", "src/"), extract.WithExcludePatterns("**/*.txt", "**/node_modules/**")},
+			expected:   []string{"docs/a.md", "src/main.go"},
+			includes:   2,
+			excludes:   2,
+			mismatches: 3,
+		},
+		{
+			name:       "regular expressions",
+			opts:       []extract.ConfigOption{extract.WithRegexpPatterns(regexp.MustCompile(`\.(md|txt)$`)), extract.WithExcludeRegexpPatterns(regexp.MustCompile(`^docs/sub/`))},
+			expected:   []string{"README.md", "docs/a.md"},
+			includes:   2,
+			excludes:   1,
+			mismatches: 4,
+		},
+		{
+			name:       "shell patterns or regular expressions",
+			opts:       []extract.ConfigOption{extract.WithPatterns("bin/*"), extract.WithRegexpPatterns(regexp.MustCompile(`\.go$`))},
+			expected:   []string{"bin/tool.exe", "src/main.go"},
+			includes:   2,
+			mismatches: 5,
+		},
+		{
+			name:      "invalid pattern",
+			opts:      []extract.ConfigOption{extract.WithExcludePatterns("[")},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var td *extract.TelemetryData
+			dir := t.TempDir()
+			opts := append([]extract.ConfigOption{extract.WithTelemetryHook(func(_ context.Context, d *extract.TelemetryData) { td = d })}, tc.opts...)
+			err := extract.Unpack(context.Background(), dir, bytes.NewReader(archive), extract.NewConfig(opts...))
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var files []string
+			if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				rel, err := filepath.Rel(dir, path)
+				files = append(files, filepath.ToSlash(rel))
+				return err
+			}); err != nil {
+				t.Fatalf("failed to walk destination: %v", err)
+			}
+			if !slices.Equal(files, tc.expected) {
+				t.Errorf("expected files %v, got %v", tc.expected, files)
+			}
+			if td.PatternIncludes != tc.includes || td.PatternExcludes != tc.excludes || td.PatternMismatches != tc.mismatches {
+				t.Errorf("expected %d includes, %d excludes and %d mismatches, got %d, %d and %d",
+					tc.includes, tc.excludes, tc.mismatches, td.PatternIncludes, td.PatternExcludes, td.PatternMismatches)
+			}
+
+			// listing applies the same patterns
+			entries, err := extract.List(context.Background(), bytes.NewReader(archive), extract.NewConfig(tc.opts...))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var listed []string
+			for _, e := range entries {
+				listed = append(listed, e.Name)
+			}
+			sort.Strings(listed)
+			if !slices.Equal(listed, tc.expected) {
+				t.Errorf("expected listed entries %v, got %v", tc.expected, listed)
+			}
+		})
+	}
+}
+
+func TestPatternsMatchDirectories(t *testing.T) {
+	archive := packTar(t, []archiveContent{
+		{Name: "docs/", Mode: fs.ModeDir | 0755},
+		{Name: "docs/a.md", Content: []byte("a"), Mode: 0644},
+		{Name: "other/", Mode: fs.ModeDir | 0755},
+	})
+	dir := t.TempDir()
+	cfg := extract.NewConfig(extract.WithPatterns("docs/"))
+	if err := extract.Unpack(context.Background(), dir, bytes.NewReader(archive), cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "docs", "a.md")); err != nil {
+		t.Errorf("expected docs/a.md to be extracted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other")); err == nil {
+		t.Errorf("expected other not to be extracted")
+	}
+}
@@ -5,6 +5,8 @@ package extract
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
@@ -48,27 +50,111 @@ func processRar(ctx context.Context, t Target, dst string, src io.Reader, cfg *C
 	// log extraction
 	cfg.Logger().Info("extracting rar")
 
+	// prepare decryption
+	opts, hasPassword, err := rarOptions(cfg)
+	if err != nil {
+		return handleError(cfg, td, "cannot get password", err)
+	}
+
 	// check if src is a file, instantiate reader from file
 	if s, ok := src.(*os.File); ok {
-		a, err := rardecode.OpenReader(s.Name())
+		a, err := rardecode.OpenReader(s.Name(), opts...)
 		if err != nil {
-			return handleError(cfg, td, "cannot create rar decoder", err)
+			return handleError(cfg, td, "cannot create rar decoder", rarError(err))
 		}
 		defer a.Close()
-		return extract(ctx, t, dst, &rarWalker{&a.Reader}, cfg, td)
+		return extract(ctx, t, dst, &rarWalker{r: &a.Reader, hasPassword: hasPassword}, cfg, td)
 	}
 
 	// get bytes from reader
-	a, err := rardecode.NewReader(src)
+	a, err := rardecode.NewReader(src, opts...)
+	if err != nil {
+		return handleError(cfg, td, "cannot create rar decoder", rarError(err))
+	}
+	return extract(ctx, t, dst, &rarWalker{r: a, hasPassword: hasPassword}, cfg, td)
+}
+
+// rarOptions returns the options for the rar decoder, which contain the configured
+// password, and if a password is configured.
+func rarOptions(cfg *Config) ([]rardecode.Option, bool, error) {
+	password, hasPassword, err := cfg.archivePassword()
+	if err != nil || !hasPassword {
+		return nil, false, err
+	}
+	return []rardecode.Option{rardecode.Password(password)}, true, nil
+}
+
+// rarError wraps err with a password error, if err is caused by encrypted content, and
+// with [ErrCorruptData], if the checksum of the content does not match.
+func rarError(err error) error {
+	switch {
+	case errors.Is(err, rardecode.ErrArchiveEncrypted), errors.Is(err, rardecode.ErrArchivedFileEncrypted):
+		return fmt.Errorf("%w: %w", ErrPasswordRequired, err)
+	case errors.Is(err, rardecode.ErrBadPassword):
+		return fmt.Errorf("%w: %w", ErrWrongPassword, err)
+	case errors.Is(err, rardecode.ErrBadFileChecksum):
+		return fmt.Errorf("%w: %w", ErrCorruptData, err)
+	}
+	return err
+}
+
+// walkRar returns a Walker for the Rar archive in src. If src is not a
+// readerAt and a seeker, src is cached according to the configuration.
+func walkRar(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	sra, cleanup, err := cacheInput(cfg, src)
+	if err != nil {
+		return nil, fmt.Errorf("cannot cache reader: %w", err)
+	}
+
+	// check input size
+	if _, err := checkInputSize(cfg, sra); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	// prepare decryption
+	opts, hasPassword, err := rarOptions(cfg)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("cannot get password: %w", err)
+	}
+
+	// check if src is a file, instantiate reader from file
+	if f, ok := sra.(*os.File); ok {
+		a, err := rardecode.OpenReader(f.Name(), opts...)
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("cannot create rar decoder: %w", rarError(err))
+		}
+		return &rarWalker{r: &a.Reader, hasPassword: hasPassword, cleanup: func() {
+			a.Close()
+			cleanup()
+		}}, nil
+	}
+
+	// create reader from stream
+	a, err := rardecode.NewReader(sra.(io.Reader), opts...)
 	if err != nil {
-		return handleError(cfg, td, "cannot create rar decoder", err)
+		cleanup()
+		return nil, fmt.Errorf("cannot create rar decoder: %w", rarError(err))
 	}
-	return extract(ctx, t, dst, &rarWalker{a}, cfg, td)
+	return &rarWalker{r: a, hasPassword: hasPassword, cleanup: cleanup}, nil
 }
 
-// rarWalker is an archiveWalker for Rar files.
+// rarWalker is a Walker for Rar files.
 type rarWalker struct {
-	r *rardecode.Reader
+	r           *rardecode.Reader
+	hasPassword bool
+	volumes     bool
+	cleanup     func()
+}
+
+// Close closes the rar decoder and removes the cached input, if any.
+func (rw *rarWalker) Close() error {
+	if rw.cleanup != nil {
+		rw.cleanup()
+	}
+	return nil
 }
 
 // Type returns the file extension for rar files.
@@ -77,19 +163,24 @@ func (rw *rarWalker) Type() string {
 }
 
 // Next returns the next entry in the rar file.
-func (rw *rarWalker) Next() (archiveEntry, error) {
+func (rw *rarWalker) Next() (Entry, error) {
 	fh, err := rw.r.Next()
 	if err != nil {
-		return nil, err
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, rarError(err)
 	}
-	re := &rarEntry{fh, rw.r}
+	re := &rarEntry{f: fh, r: rw.r, hasPassword: rw.hasPassword, volumes: rw.volumes}
 	return re, nil
 }
 
-// rarEntry is an archiveEntry for Rar files.
+// rarEntry is an Entry for Rar files.
 type rarEntry struct {
-	f *rardecode.FileHeader
-	r io.Reader
+	f           *rardecode.FileHeader
+	r           io.Reader
+	hasPassword bool
+	volumes     bool
 }
 
 // Name returns the name of the file.
@@ -102,6 +193,15 @@ func (r *rarEntry) Size() int64 {
 	return r.f.UnPackedSize
 }
 
+// compressedSize returns the packed size of the file. Files of multi-volume archives return -1,
+// because the packed size of a file, which spans volumes, is the size of its first block only.
+func (r *rarEntry) compressedSize() int64 {
+	if r.volumes {
+		return -1
+	}
+	return r.f.PackedSize
+}
+
 // Mode returns the mode of the file.
 func (r *rarEntry) Mode() os.FileMode {
 	return r.f.Mode()
@@ -122,6 +222,11 @@ func (r *rarEntry) IsDir() bool {
 	return r.f.IsDir
 }
 
+// IsHardlink hard links are not supported.
+func (r *rarEntry) IsHardlink() bool {
+	return false
+}
+
 // IsSymlink returns true if the file is a symlink.
 func (r *rarEntry) IsSymlink() bool {
 	return false
@@ -134,7 +239,29 @@ func (r *rarEntry) Type() fs.FileMode {
 
 // Open returns a reader for the file.
 func (r *rarEntry) Open() (io.ReadCloser, error) {
-	return io.NopCloser(r.r), nil
+	return io.NopCloser(&rarReader{r: r.r, encrypted: r.f.Encrypted, hasPassword: r.hasPassword}), nil
+}
+
+// rarReader wraps read errors of the content of a rar entry with password and
+// corrupt data errors.
+type rarReader struct {
+	r           io.Reader
+	encrypted   bool
+	hasPassword bool
+}
+
+// Read reads the content of the entry. Because the encryption of rar 4 archives does not
+// verify the password, a checksum mismatch of an encrypted entry, which is decrypted with
+// a password, is reported as [ErrWrongPassword] and [ErrCorruptData].
+func (r *rarReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err == nil || err == io.EOF {
+		return n, err
+	}
+	if r.encrypted && r.hasPassword && errors.Is(err, rardecode.ErrBadFileChecksum) {
+		return n, fmt.Errorf("%w or %w: %w", ErrWrongPassword, ErrCorruptData, err)
+	}
+	return n, rarError(err)
 }
 
 // AccessTime returns the access time of the file.
@@ -0,0 +1,176 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestUnpackEncryptedRar(t *testing.T) {
+	contents := []archiveContent{
+		{Name: "test", Content: []byte("hello world"), Mode: 0644},
+		{Name: "long", Content: bytes.Repeat([]byte("hello encrypted rar "), 10), Mode: 0644},
+	}
+
+	tests := []struct {
+		name        string
+		src         []byte
+		cfg         *extract.Config
+		expectedErr error
+	}{
+		{
+			name: "password",
+			src:  packEncryptedRar(t, "secret", false, contents),
+			cfg:  extract.NewConfig(extract.WithPassword("secret")),
+		},
+		{
+			name: "password provider",
+			src:  packEncryptedRar(t, "secret", false, contents),
+			cfg: extract.NewConfig(extract.WithPasswordProvider(func(entryName string) (string, error) {
+				if entryName != "" {
+					t.Errorf("expected empty entry name, got %q", entryName)
+				}
+				return "secret", nil
+			})),
+		},
+		{
+			name:        "password provider error",
+			src:         packEncryptedRar(t, "secret", false, contents),
+			cfg:         extract.NewConfig(extract.WithPasswordProvider(func(string) (string, error) { return "", errCancelled })),
+			expectedErr: errCancelled,
+		},
+		{
+			name:        "password required",
+			src:         packEncryptedRar(t, "secret", false, contents),
+			expectedErr: extract.ErrPasswordRequired,
+		},
+		{
+			name:        "wrong password",
+			src:         packEncryptedRar(t, "secret", false, contents),
+			cfg:         extract.NewConfig(extract.WithPassword("wrong")),
+			expectedErr: extract.ErrWrongPassword,
+		},
+		{
+			name:        "corrupt data",
+			src:         packEncryptedRar(t, "secret", true, contents),
+			cfg:         extract.NewConfig(extract.WithPassword("secret")),
+			expectedErr: extract.ErrCorruptData,
+		},
+	}
+
+	for _, tc := range tests {
+		for _, cacheFunction := range []func(*testing.T, []byte) io.Reader{asIoReader, asFileReader} {
+			t.Run(tc.name, func(t *testing.T) {
+				cfg := tc.cfg
+				if cfg == nil {
+					cfg = extract.NewConfig()
+				}
+				tm := extract.NewTargetMemory()
+				err := extract.UnpackTo(context.Background(), tm, "", cacheFunction(t, tc.src), cfg)
+				if tc.expectedErr != nil {
+					if !errors.Is(err, tc.expectedErr) {
+						t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				checkMemoryContent(t, tm, map[string]string{"test": "hello world", "long": string(contents[1].Content)})
+			})
+		}
+	}
+}
+
+// packEncryptedRar creates a rar 5 archive with the given content, which is stored
+// uncompressed and encrypted with password. If corrupt is set, the last byte of the
+// encrypted content of each file is flipped.
+func packEncryptedRar(t *testing.T, password string, corrupt bool, content []archiveContent) []byte {
+	t.Helper()
+	vint := func(b []byte, v uint64) []byte {
+		return binary.AppendUvarint(b, v)
+	}
+	b := bytes.NewBuffer([]byte{0x52, 0x61, 0x72, 0x21, 0x1a, 0x07, 0x01, 0x00})
+	block := func(body []byte) {
+		header := vint(nil, uint64(len(body)))
+		header = append(header, body...)
+		if err := binary.Write(b, binary.LittleEndian, crc32.ChecksumIEEE(header)); err != nil {
+			t.Fatalf("error writing header crc: %v", err)
+		}
+		b.Write(header)
+	}
+
+	// derive keys, the password check value is folded to 8 bytes followed by its checksum
+	const kdfCount = 15
+	salt := bytes.Repeat([]byte{0x42}, 16)
+	iv := bytes.Repeat([]byte{0x24}, 16)
+	key, err := pbkdf2.Key(sha256.New, password, salt, 1<<kdfCount, 32)
+	if err != nil {
+		t.Fatalf("error deriving key: %v", err)
+	}
+	check, err := pbkdf2.Key(sha256.New, password, salt, 1<<kdfCount+32, 32)
+	if err != nil {
+		t.Fatalf("error deriving password check: %v", err)
+	}
+	for i, v := range check[8:] {
+		check[i%8] ^= v
+	}
+	sum := sha256.Sum256(check[:8])
+	check = append(check[:8], sum[:4]...)
+
+	// main archive header
+	block([]byte{1, 0, 0})
+
+	for _, c := range content {
+		// encrypt content, which is padded to the block size
+		data := append(bytes.Clone(c.Content), make([]byte, (aes.BlockSize-len(c.Content)%aes.BlockSize)%aes.BlockSize)...)
+		cb, err := aes.NewCipher(key)
+		if err != nil {
+			t.Fatalf("error creating cipher: %v", err)
+		}
+		cipher.NewCBCEncrypter(cb, iv).CryptBlocks(data, data)
+		if corrupt {
+			data[len(data)-1] ^= 0xff
+		}
+
+		// encryption record
+		record := vint([]byte{0x01}, 0) // type and version
+		record = vint(record, 0x01)     // password check present
+		record = append(record, kdfCount)
+		record = append(append(append(record, salt...), iv...), check...)
+		extra := append(vint(nil, uint64(len(record))), record...)
+
+		// file header
+		fields := vint(nil, 0x04) // crc present
+		fields = vint(fields, uint64(len(c.Content)))
+		fields = vint(fields, uint64(0100000|c.Mode.Perm()))
+		fields = binary.LittleEndian.AppendUint32(fields, crc32.ChecksumIEEE(c.Content))
+		fields = vint(fields, 0) // stored
+		fields = vint(fields, 1) // unix
+		fields = vint(fields, uint64(len(c.Name)))
+		fields = append(fields, c.Name...)
+		body := vint(nil, 2)    // file header
+		body = vint(body, 0x03) // extra and data area present
+		body = vint(body, uint64(len(extra)))
+		body = vint(body, uint64(len(data)))
+		block(append(append(body, fields...), extra...))
+		b.Write(data)
+	}
+
+	// end of archive header
+	block([]byte{5, 0, 0})
+	return b.Bytes()
+}
@@ -0,0 +1,221 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// nestedArchiveSuffix is the suffix of the directory, into which a nested archive is extracted.
+const nestedArchiveSuffix = ".d"
+
+// recursiveExtraction holds the telemetry data of the nested archives, which are extracted
+// recursively during the extraction of an archive.
+type recursiveExtraction struct {
+	cfg        *Config
+	extractors extractors
+	totals     TelemetryData
+	levels     []LevelTelemetryData
+}
+
+// newRecursiveExtraction returns the recursive extraction for cfg, or nil if the recursive
+// extraction is disabled.
+func newRecursiveExtraction(cfg *Config) (*recursiveExtraction, error) {
+	if cfg.RecursiveExtraction() <= 0 {
+		return nil, nil
+	}
+	e, err := cfg.extractors()
+	if err != nil {
+		return nil, err
+	}
+	return &recursiveExtraction{cfg: cfg, extractors: e}, nil
+}
+
+// detect peeks the header of src and returns a nested archive, if the header matches a known
+// format and the maximum depth is not reached yet. The nested archive caches the content,
+// which is read from the returned reader. The returned reader replaces src.
+func (r *recursiveExtraction) detect(src io.Reader) (io.Reader, *nestedArchive, error) {
+	if r.cfg.depth >= r.cfg.RecursiveExtraction() {
+		return src, nil, nil
+	}
+	hr, err := newHeaderReader(src, r.extractors.maxHeaderLength())
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read header: %w", err)
+	}
+	ex, found := r.extractors.getExtractorByHeader(hr.PeekHeader())
+	if !found {
+		return hr, nil, nil
+	}
+
+	// cache content in memory or on disk
+	a := &nestedArchive{unpacker: ex.Unpacker}
+	if r.cfg.CacheInMemory() {
+		a.cache = new(bytes.Buffer)
+	} else {
+		f, err := os.CreateTemp("", "extractor-*")
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot create cache: %w", err)
+		}
+		a.cache, a.file = f, f
+	}
+	return io.TeeReader(hr, a.cache), a, nil
+}
+
+// unpack extracts the nested archive a, which is extracted as name to dst, into the directory
+// name.d. The maximum number of files and the maximum extraction size are reduced by files and
+// size, which are already used by the enclosing archives. The number of files and the size,
+// which are used by the nested archive, are returned.
+func (r *recursiveExtraction) unpack(ctx context.Context, t Target, dst string, name string, a *nestedArchive, files int64, size int64) (int64, int64, error) {
+	defer a.Close()
+	r.cfg.Logger().Info("extracting nested archive", "name", name)
+
+	// create directory next to the nested archive
+	dir := name + nestedArchiveSuffix
+	if err := createDir(t, dst, dir, r.cfg.CustomCreateDirMode(), r.cfg); err != nil {
+		return 0, 0, fmt.Errorf("cannot create directory: %w", err)
+	}
+
+	// extract nested archive with the remaining limits and collect its telemetry data
+	var td *TelemetryData
+	cfg := r.cfg.nested(dir, files, size, func(_ context.Context, d *TelemetryData) { td = d })
+	src, err := a.reader()
+	if err != nil {
+		return 0, 0, err
+	}
+	err = a.unpacker(ctx, t, filepath.Join(dst, filepath.Join(strings.Split(dir, "/")...)), src, cfg)
+	if td == nil {
+		return 0, 0, err
+	}
+	r.add(td)
+	return td.ExtractedFiles + td.ExtractedDirs + td.ExtractedSymlinks + td.ExtractedHardlinks, td.ExtractionSize, err
+}
+
+// add adds the telemetry data td of a nested archive to the levels below the archive.
+func (r *recursiveExtraction) add(td *TelemetryData) {
+	levels := td.Levels
+	if levels == nil {
+		levels = []LevelTelemetryData{levelTelemetryData(td)}
+	}
+	for i, l := range levels {
+		if i == len(r.levels) {
+			r.levels = append(r.levels, LevelTelemetryData{})
+		}
+		r.levels[i].Archives += l.Archives
+		r.levels[i].ExtractedDirs += l.ExtractedDirs
+		r.levels[i].ExtractionErrors += l.ExtractionErrors
+		r.levels[i].ExtractedFiles += l.ExtractedFiles
+		r.levels[i].ExtractionSize += l.ExtractionSize
+		r.levels[i].ExtractedHardlinks += l.ExtractedHardlinks
+		r.levels[i].ExtractedSymlinks += l.ExtractedSymlinks
+	}
+	r.totals.EntryHookRenames += td.EntryHookRenames
+	r.totals.EntryHookSkips += td.EntryHookSkips
+	r.totals.ExtractedDirs += td.ExtractedDirs
+	r.totals.ExtractionErrors += td.ExtractionErrors
+	r.totals.ExtractedFiles += td.ExtractedFiles
+	r.totals.ExtractionSize += td.ExtractionSize
+	r.totals.ExtractedHardlinks += td.ExtractedHardlinks
+	r.totals.ExtractedSymlinks += td.ExtractedSymlinks
+	r.totals.PatternExcludes += td.PatternExcludes
+	r.totals.PatternIncludes += td.PatternIncludes
+	r.totals.PatternMismatches += td.PatternMismatches
+	r.totals.UnsupportedFiles += td.UnsupportedFiles
+	r.totals.Manifest = append(r.totals.Manifest, td.Manifest...)
+}
+
+// report sets the levels of td, which holds the telemetry data of the archive itself, and adds
+// the telemetry data of the nested archives.
+func (r *recursiveExtraction) report(td *TelemetryData) {
+	td.Levels = append([]LevelTelemetryData{levelTelemetryData(td)}, r.levels...)
+	td.EntryHookRenames += r.totals.EntryHookRenames
+	td.EntryHookSkips += r.totals.EntryHookSkips
+	td.ExtractedDirs += r.totals.ExtractedDirs
+	td.ExtractionErrors += r.totals.ExtractionErrors
+	td.ExtractedFiles += r.totals.ExtractedFiles
+	td.ExtractionSize += r.totals.ExtractionSize
+	td.ExtractedHardlinks += r.totals.ExtractedHardlinks
+	td.ExtractedSymlinks += r.totals.ExtractedSymlinks
+	td.PatternExcludes += r.totals.PatternExcludes
+	td.PatternIncludes += r.totals.PatternIncludes
+	td.PatternMismatches += r.totals.PatternMismatches
+	td.UnsupportedFiles += r.totals.UnsupportedFiles
+	td.Manifest = append(td.Manifest, r.totals.Manifest...)
+}
+
+// levelTelemetryData returns the telemetry data of a single archive as level.
+func levelTelemetryData(td *TelemetryData) LevelTelemetryData {
+	return LevelTelemetryData{
+		Archives:           1,
+		ExtractedDirs:      td.ExtractedDirs,
+		ExtractionErrors:   td.ExtractionErrors,
+		ExtractedFiles:     td.ExtractedFiles,
+		ExtractionSize:     td.ExtractionSize,
+		ExtractedHardlinks: td.ExtractedHardlinks,
+		ExtractedSymlinks:  td.ExtractedSymlinks,
+	}
+}
+
+// nestedArchive is an archive inside of an archive, which content is cached while it is
+// extracted.
+type nestedArchive struct {
+	unpacker unpackFunc
+	cache    io.Writer
+	file     *os.File
+}
+
+// reader returns a reader for the cached content, which implements io.ReaderAt and io.Seeker.
+func (a *nestedArchive) reader() (*io.SectionReader, error) {
+	if b, ok := a.cache.(*bytes.Buffer); ok {
+		return io.NewSectionReader(bytes.NewReader(b.Bytes()), 0, int64(b.Len())), nil
+	}
+	size, err := a.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("cannot seek to end of cache: %w", err)
+	}
+	return io.NewSectionReader(a.file, 0, size), nil
+}
+
+// Close removes the cache from disk, if any.
+func (a *nestedArchive) Close() error {
+	if a.file == nil {
+		return nil
+	}
+	a.file.Close()
+	return os.Remove(a.file.Name())
+}
+
+// nested returns a copy of the configuration to extract a nested archive into the directory dir
+// of the destination. The maximum number of
+// files and the maximum extraction size are reduced by files and size, which are already used
+// by the enclosing archives. The extraction type, the patterns, the path rewrite and the compressed
+// input of the enclosing archive do not apply to the nested archive, and hook receives its telemetry
+// data.
+func (c *Config) nested(dir string, files int64, size int64, hook TelemetryHook) *Config {
+	n := *c
+	n.depth++
+	n.nestedDir = path.Join(c.nestedDir, dir)
+	n.compressedInput = nil
+	n.extractionType = ""
+	n.patterns = nil
+	n.excludePatterns = nil
+	n.regexpPatterns = nil
+	n.excludeRegexpPatterns = nil
+	n.pathRewriter = nil
+	n.stripComponents = 0
+	n.telemetryHook = hook
+	if n.maxFiles != -1 {
+		n.maxFiles -= files
+	}
+	if n.maxExtractionSize != -1 {
+		n.maxExtractionSize -= size
+	}
+	return &n
+}
@@ -0,0 +1,163 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestRecursiveExtraction(t *testing.T) {
+	innermost := packZip(t, []archiveContent{{Name: "deepest", Content: []byte("deepest"), Mode: 0644}})
+	inner := packZip(t, []archiveContent{
+		{Name: "a", Content: []byte("hello a"), Mode: 0644},
+		{Name: "b", Content: []byte("hello b"), Mode: 0644},
+		{Name: "innermost.zip", Content: innermost, Mode: 0644},
+	})
+	archive := compressGzip(t, packTar(t, []archiveContent{
+		{Name: "dir", Mode: fs.ModeDir | 0755},
+		{Name: "dir/inner.zip", Content: inner, Mode: 0644},
+		{Name: "dir/archive.7z", Content: pack7z(t, nil), Mode: 0644},
+		{Name: "plain.txt", Content: []byte("plain"), Mode: 0644},
+	}))
+
+	tests := []struct {
+		name        string
+		src         []byte
+		cfg         *extract.Config
+		expected    map[string]string
+		notExpected []string
+		expectedErr error
+	}{
+		{
+			name:        "disabled",
+			src:         archive,
+			cfg:         extract.NewConfig(),
+			expected:    map[string]string{"dir/inner.zip": string(inner), "plain.txt": "plain"},
+			notExpected: []string{"dir/inner.zip.d", "dir/archive.7z.d"},
+		},
+		{
+			name: "one level",
+			src:  archive,
+			cfg:  extract.NewConfig(extract.WithRecursiveExtraction(1)),
+			expected: map[string]string{
+				"dir/inner.zip":                 string(inner),
+				"dir/inner.zip.d/a":             "hello a",
+				"dir/inner.zip.d/b":             "hello b",
+				"dir/inner.zip.d/innermost.zip": string(innermost),
+				"dir/archive.7z.d/test":         "hello world\n",
+				"dir/archive.7z.d/dir/entry":    "hello world\n",
+				"plain.txt":                     "plain",
+			},
+			notExpected: []string{"dir/inner.zip.d/innermost.zip.d", "plain.txt.d"},
+		},
+		{
+			name: "two levels",
+			src:  archive,
+			cfg:  extract.NewConfig(extract.WithRecursiveExtraction(2)),
+			expected: map[string]string{
+				"dir/inner.zip.d/a":                       "hello a",
+				"dir/inner.zip.d/innermost.zip.d/deepest": "deepest",
+			},
+		},
+		{
+			name:        "max files shared across levels",
+			src:         archive,
+			cfg:         extract.NewConfig(extract.WithRecursiveExtraction(2), extract.WithMaxFiles(7)),
+			expectedErr: extract.ErrMaxFilesExceeded,
+		},
+		{
+			name:        "max extraction size shared across levels",
+			src:         archive,
+			cfg:         extract.NewConfig(extract.WithRecursiveExtraction(1), extract.WithMaxExtractionSize(int64(len(inner)+len(pack7z(t, nil))+10))),
+			expectedErr: extract.ErrMaxExtractionSizeExceeded,
+		},
+		{
+			name: "nested zip bomb",
+			src: packZip(t, []archiveContent{{Name: "bomb.zip", Mode: 0644, Content: packZip(t, []archiveContent{
+				{Name: "zeros", Content: bytes.Repeat([]byte{0}, 1<<20), Mode: 0644},
+			})}}),
+			cfg:         extract.NewConfig(extract.WithRecursiveExtraction(1), extract.WithMaxExtractionSize(1<<19)),
+			expectedErr: extract.ErrMaxExtractionSizeExceeded,
+		},
+		{
+			name:        "corrupt nested archive",
+			src:         packZip(t, []archiveContent{{Name: "corrupt.zip", Content: []byte("PK\x03\x04corrupt"), Mode: 0644}}),
+			cfg:         extract.NewConfig(extract.WithRecursiveExtraction(1)),
+			expectedErr: extract.ErrFailedToUnpack,
+		},
+		{
+			name:     "corrupt nested archive with continue on error",
+			src:      packZip(t, []archiveContent{{Name: "corrupt.zip", Content: []byte("PK\x03\x04corrupt"), Mode: 0644}}),
+			cfg:      extract.NewConfig(extract.WithRecursiveExtraction(1), extract.WithContinueOnError(true)),
+			expected: map[string]string{"corrupt.zip": "PK\x03\x04corrupt"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackTo(context.Background(), tm, "", bytes.NewReader(tc.src), tc.cfg)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkMemoryContent(t, tm, tc.expected)
+			for _, name := range tc.notExpected {
+				if _, err := fs.Stat(tm, name); err == nil {
+					t.Errorf("expected %q not to exist", name)
+				}
+			}
+		})
+	}
+}
+
+func TestRecursiveExtractionTelemetry(t *testing.T) {
+	innermost := packZip(t, []archiveContent{{Name: "deepest", Content: []byte("deepest"), Mode: 0644}})
+	archive := packZip(t, []archiveContent{
+		{Name: "first.zip", Content: packZip(t, []archiveContent{
+			{Name: "a", Content: []byte("hello a"), Mode: 0644},
+			{Name: "innermost.zip", Content: innermost, Mode: 0644},
+		}), Mode: 0644},
+		{Name: "second.zip", Content: packZip(t, []archiveContent{{Name: "b", Content: []byte("hello b"), Mode: 0644}}), Mode: 0644},
+	})
+
+	var td *extract.TelemetryData
+	cfg := extract.NewConfig(
+		extract.WithRecursiveExtraction(5),
+		extract.WithTelemetryHook(func(_ context.Context, d *extract.TelemetryData) { td = d }),
+	)
+	if err := extract.UnpackTo(context.Background(), extract.NewTargetMemory(), "", bytes.NewReader(archive), cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if td.ExtractedType != "zip" {
+		t.Errorf("expected type zip, got %q", td.ExtractedType)
+	}
+	expectedLevels := []struct{ archives, files int64 }{{1, 2}, {2, 3}, {1, 1}}
+	if len(td.Levels) != len(expectedLevels) {
+		t.Fatalf("expected %d levels, got %+v", len(expectedLevels), td.Levels)
+	}
+	var files, size int64
+	for i, l := range td.Levels {
+		if l.Archives != expectedLevels[i].archives || l.ExtractedFiles != expectedLevels[i].files {
+			t.Errorf("expected level %d with %d archives and %d files, got %+v", i, expectedLevels[i].archives, expectedLevels[i].files, l)
+		}
+		files += l.ExtractedFiles
+		size += l.ExtractionSize
+	}
+	if td.ExtractedFiles != files || td.ExtractionSize != size {
+		t.Errorf("expected totals of %d files and %d bytes, got %d files and %d bytes", files, size, td.ExtractedFiles, td.ExtractionSize)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// rewrittenEntry is an [Entry] with a rewritten name and link target.
+type rewrittenEntry struct {
+	Entry
+	name     string
+	linkname string
+}
+
+// Name returns the rewritten name of the entry.
+func (e *rewrittenEntry) Name() string {
+	return e.name
+}
+
+// Linkname returns the rewritten link target of the entry.
+func (e *rewrittenEntry) Linkname() string {
+	return e.linkname
+}
+
+// compressedSize returns the compressed size of the underlying entry.
+func (e *rewrittenEntry) compressedSize() int64 {
+	return compressedSize(e.Entry)
+}
+
+// Metadata returns the format specific metadata of the underlying entry, if any.
+func (e *rewrittenEntry) Metadata() map[string]string {
+	if me, ok := e.Entry.(MetadataEntry); ok {
+		return me.Metadata()
+	}
+	return nil
+}
+
+// rewritePath strips the configured number of leading path components from name and applies
+// the configured path rewriter. If the entry with name is skipped, false is returned.
+func (c *Config) rewritePath(name string) (string, bool) {
+	if n := c.StripComponents(); n > 0 {
+		parts := strings.Split(path.Clean(name), "/")
+		if len(parts) <= n {
+			return "", false
+		}
+		name = strings.Join(parts[n:], "/")
+	}
+	if rewriter := c.PathRewriter(); rewriter != nil {
+		return rewriter(name)
+	}
+	return name, true
+}
+
+// rewriteEntry returns ae with its name rewritten by [Config.rewritePath]. The targets of links
+// are rewritten by [renameEntry]. If ae is skipped, false is returned.
+func rewriteEntry(cfg *Config, ae Entry) (Entry, bool, error) {
+	if cfg.StripComponents() <= 0 && cfg.PathRewriter() == nil {
+		return ae, true, nil
+	}
+	name, ok := cfg.rewritePath(ae.Name())
+	if !ok {
+		return nil, false, nil
+	}
+	rewritten, err := renameEntry(ae, name, cfg.rewritePath)
+	return rewritten, true, err
+}
+
+// renameEntry returns ae with the new name. The target of a hard link is rewritten by rewrite
+// like a name. The target of a symlink is adjusted, so that it points from the new name to the
+// target, which is rewritten by rewrite, if the target is inside of the archive and not skipped.
+func renameEntry(ae Entry, name string, rewrite func(name string) (string, bool)) (Entry, error) {
+	linkname := ae.Linkname()
+	switch {
+	case ae.IsHardlink():
+		var ok bool
+		if linkname, ok = rewrite(linkname); !ok {
+			return nil, fmt.Errorf("target %s of hard link %s is skipped by path rewrite", ae.Linkname(), ae.Name())
+		}
+
+	case ae.IsSymlink() && !path.IsAbs(linkname):
+		target := path.Join(path.Dir(ae.Name()), linkname)
+		if !fs.ValidPath(target) {
+			break
+		}
+		if target, ok := rewrite(target); ok {
+			linkname = relativePath(path.Dir(name), target)
+		}
+	}
+
+	return &rewrittenEntry{Entry: ae, name: name, linkname: linkname}, nil
+}
+
+// relativePath returns the slash separated path of target relative to the directory dir. Both
+// paths are relative to the same root.
+func relativePath(dir string, target string) string {
+	dirParts := strings.Split(path.Clean(dir), "/")
+	targetParts := strings.Split(path.Clean(target), "/")
+	if dirParts[0] == "." {
+		dirParts = nil
+	}
+	if targetParts[0] == "." {
+		targetParts = nil
+	}
+
+	// remove common prefix
+	i := 0
+	for i < len(dirParts) && i < len(targetParts) && dirParts[i] == targetParts[i] {
+		i++
+	}
+	parts := make([]string, 0, len(dirParts)-i+len(targetParts)-i)
+	for range dirParts[i:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, targetParts[i:]...)
+	if len(parts) == 0 {
+		return "."
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,122 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestPathRewrite(t *testing.T) {
+	archive := packTar(t, []archiveContent{
+		{Name: "README", Content: []byte("readme"), Mode: 0644},
+		{Name: "project-v1.2.3/", Mode: fs.ModeDir | 0755},
+		{Name: "project-v1.2.3/a", Content: []byte("hello a"), Mode: 0644},
+		{Name: "project-v1.2.3/doc.md", Content: []byte("doc"), Mode: 0644},
+		{Name: "project-v1.2.3/bin/link", Linktarget: "../a", Mode: fs.ModeSymlink | 0777},
+		{Name: "project-v1.2.3/lib/hardlink", Linktarget: "project-v1.2.3/a", Hardlink: true, Mode: 0644},
+	})
+
+	tests := []struct {
+		name          string
+		cfg           *extract.Config
+		expected      map[string]string
+		expectedLinks map[string]string
+		notExpected   []string
+		expectedErr   error
+	}{
+		{
+			name:          "strip components",
+			cfg:           extract.NewConfig(extract.WithStripComponents(1)),
+			expected:      map[string]string{"a": "hello a", "doc.md": "doc", "bin/link": "hello a", "lib/hardlink": "hello a"},
+			expectedLinks: map[string]string{"bin/link": "../a"},
+			notExpected:   []string{"README", "project-v1.2.3"},
+		},
+		{
+			name:        "strip components with patterns",
+			cfg:         extract.NewConfig(extract.WithStripComponents(1), extract.WithPatterns("*.md")),
+			expected:    map[string]string{"doc.md": "doc"},
+			notExpected: []string{"a", "bin"},
+		},
+		{
+			name: "path rewriter",
+			cfg: extract.NewConfig(extract.WithPathRewriter(func(name string) (string, bool) {
+				if strings.HasSuffix(name, ".md") {
+					return "", false
+				}
+				return "vendor/" + strings.TrimPrefix(name, "project-v1.2.3/bin/"), true
+			})),
+			expected:      map[string]string{"vendor/README": "readme", "vendor/link": "hello a", "vendor/project-v1.2.3/lib/hardlink": "hello a"},
+			expectedLinks: map[string]string{"vendor/link": "project-v1.2.3/a"},
+			notExpected:   []string{"vendor/project-v1.2.3/doc.md", "README"},
+		},
+		{
+			name: "strip components before path rewriter",
+			cfg: extract.NewConfig(extract.WithStripComponents(1), extract.WithPathRewriter(func(name string) (string, bool) {
+				return "out/" + name, true
+			})),
+			expected:      map[string]string{"out/a": "hello a", "out/bin/link": "hello a"},
+			expectedLinks: map[string]string{"out/bin/link": "../a"},
+		},
+		{
+			name: "rewritten path is security checked",
+			cfg: extract.NewConfig(extract.WithPathRewriter(func(name string) (string, bool) {
+				return "../" + name, true
+			})),
+			expectedErr: extract.ErrFailedToUnpack,
+		},
+		{
+			name: "hard link target skipped",
+			cfg: extract.NewConfig(extract.WithPathRewriter(func(name string) (string, bool) {
+				return name, name != "project-v1.2.3/a"
+			})),
+			expectedErr: extract.ErrFailedToUnpack,
+		},
+		{
+			name: "hard link target skipped with continue on error",
+			cfg: extract.NewConfig(extract.WithContinueOnError(true), extract.WithPathRewriter(func(name string) (string, bool) {
+				return name, name != "project-v1.2.3/a"
+			})),
+			expected:    map[string]string{"project-v1.2.3/doc.md": "doc"},
+			notExpected: []string{"project-v1.2.3/lib/hardlink"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackTo(context.Background(), tm, "", bytes.NewReader(archive), tc.cfg)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkMemoryContent(t, tm, tc.expected)
+			for name, target := range tc.expectedLinks {
+				link, err := tm.Readlink(name)
+				if err != nil {
+					t.Fatalf("failed to read link %s: %v", name, err)
+				}
+				if link != target {
+					t.Errorf("expected link %s to point to %q, got %q", name, target, link)
+				}
+			}
+			for _, name := range tc.notExpected {
+				if _, err := tm.Lstat(name); err == nil {
+					t.Errorf("expected %q not to exist", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// fileExtensionRpm is the file extension for RPM packages
+const fileExtensionRpm = "rpm"
+
+// magicBytesRpm are the magic bytes of the lead of RPM packages
+//
+// https://rpm-software-management.github.io/rpm/manual/format_v4.html
+var magicBytesRpm = [][]byte{
+	{0xed, 0xab, 0xee, 0xdb},
+}
+
+const (
+	// rpmLeadLength is the length of the lead of an RPM package
+	rpmLeadLength = 96
+
+	// rpmSignatureTypeHeader is the only supported signature type in the lead
+	rpmSignatureTypeHeader = 5
+
+	// rpmMaxIndexCount is the maximum number of index entries in a header
+	rpmMaxIndexCount = 0xffff
+
+	// rpmMaxDataSize is the maximum size of the data section of a header, which is read
+	// into memory. Headers of packages with many files have a few MiB.
+	rpmMaxDataSize = 8 << 20 // 8 MiB
+)
+
+// magicBytesRpmHeader are the magic bytes of the signature and header sections
+var magicBytesRpmHeader = []byte{0x8e, 0xad, 0xe8, 0x01}
+
+// header tags of RPM packages
+const (
+	rpmTagName              = 1000
+	rpmTagVersion           = 1001
+	rpmTagRelease           = 1002
+	rpmTagArch              = 1022
+	rpmTagPayloadFormat     = 1124
+	rpmTagPayloadCompressor = 1125
+)
+
+// data types of header tags
+const (
+	rpmTypeString      = 6
+	rpmTypeStringArray = 8
+	rpmTypeI18NString  = 9
+)
+
+// metadata keys of entries in RPM packages
+const (
+	// MetadataRpmName is the metadata key for the name of an RPM package
+	MetadataRpmName = "rpm.name"
+
+	// MetadataRpmVersion is the metadata key for the version of an RPM package
+	MetadataRpmVersion = "rpm.version"
+
+	// MetadataRpmRelease is the metadata key for the release of an RPM package
+	MetadataRpmRelease = "rpm.release"
+
+	// MetadataRpmArch is the metadata key for the architecture of an RPM package
+	MetadataRpmArch = "rpm.arch"
+
+	// MetadataRpmPayloadCompressor is the metadata key for the payload compressor of an RPM package
+	MetadataRpmPayloadCompressor = "rpm.payload_compressor"
+)
+
+// rpmDecompressors maps the payload compressors to decompression functions. An
+// uncompressed payload is decompressed with nil.
+var rpmDecompressors = map[string]decompressionFunc{
+	"identity": nil,
+	"bzip2":    decompressBz2Stream,
+	"gzip":     decompressGZipStream,
+	"lzma":     decompressLzmaStream,
+	"xz":       decompressXzStream,
+	"zstd":     decompressZstdStream,
+}
+
+// isRpm checks if the header matches the magic bytes for RPM packages
+func isRpm(header []byte) bool {
+	return matchesMagicBytes(header, 0, magicBytesRpm)
+}
+
+// unpackRpm sets a timeout for the ctx and extracts the payload of the RPM package in src to dst.
+func unpackRpm(ctx context.Context, t Target, dst string, src io.Reader, cfg *Config) error {
+	// prepare telemetry capturing
+	td := &TelemetryData{ExtractedType: fileExtensionRpm}
+	defer cfg.TelemetryHook()(ctx, td)
+	defer captureExtractionDuration(td, now())
+
+	// prepare reader
+	limitedReader := newLimitErrorReader(src, cfg.MaxInputSize())
+	defer captureInputSize(td, limitedReader)
+
+	// open payload
+	w, err := newRpmWalker(limitedReader, cfg)
+	if err != nil {
+		return handleError(cfg, td, "cannot open rpm package", err)
+	}
+	defer w.Close()
+
+	// start extraction
+	return extract(ctx, t, dst, w, cfg.withCompressedInput(consumedInput(limitedReader)), td)
+}
+
+// walkRpm returns a Walker for the payload of the RPM package in src.
+func walkRpm(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return newRpmWalker(newLimitErrorReader(src, cfg.MaxInputSize()), cfg)
+}
+
+// decompressLzmaStream returns an io.Reader that decompresses src with lzma algorithm
+func decompressLzmaStream(src io.Reader) (io.Reader, error) {
+	return lzma.NewReader(src)
+}
+
+// newRpmWalker reads the lead, signature and header of the RPM package in src and
+// returns a walker for the cpio payload, which is limited by cfg.
+func newRpmWalker(src io.Reader, cfg *Config) (*rpmWalker, error) {
+	// read lead
+	lead := make([]byte, rpmLeadLength)
+	if _, err := io.ReadFull(src, lead); err != nil {
+		return nil, fmt.Errorf("rpm: cannot read lead: %w", err)
+	}
+	if !isRpm(lead) {
+		return nil, fmt.Errorf("rpm: invalid magic bytes")
+	}
+	if signatureType := binary.BigEndian.Uint16(lead[78:80]); signatureType != rpmSignatureTypeHeader {
+		return nil, fmt.Errorf("rpm: unsupported signature type %d", signatureType)
+	}
+
+	// read signature, which is padded to a multiple of 8 bytes
+	if _, err := readRpmHeader(src, true); err != nil {
+		return nil, fmt.Errorf("rpm: cannot read signature: %w", err)
+	}
+
+	// read header
+	hdr, err := readRpmHeader(src, false)
+	if err != nil {
+		return nil, fmt.Errorf("rpm: cannot read header: %w", err)
+	}
+
+	// collect metadata
+	metadata := map[string]string{
+		MetadataRpmName:              hdr.String(rpmTagName),
+		MetadataRpmVersion:           hdr.String(rpmTagVersion),
+		MetadataRpmRelease:           hdr.String(rpmTagRelease),
+		MetadataRpmArch:              hdr.String(rpmTagArch),
+		MetadataRpmPayloadCompressor: hdr.String(rpmTagPayloadCompressor),
+	}
+
+	// check payload format
+	if format := hdr.String(rpmTagPayloadFormat); format != "" && format != fileExtensionCpio {
+		return nil, fmt.Errorf("rpm: unsupported payload format %q", format)
+	}
+
+	// prepare decompression of payload, which defaults to gzip
+	compressor := metadata[MetadataRpmPayloadCompressor]
+	if compressor == "" {
+		compressor = "gzip"
+	}
+	decFunc, found := rpmDecompressors[compressor]
+	if !found {
+		return nil, fmt.Errorf("rpm: unsupported payload compressor %q", compressor)
+	}
+	stream := src
+	if decFunc != nil {
+		if stream, err = decFunc(src); err != nil {
+			return nil, fmt.Errorf("rpm: cannot decompress payload: %w", err)
+		}
+	}
+
+	return &rpmWalker{cpioWalker: newCpioWalker(stream, cfg), stream: stream, metadata: metadata}, nil
+}
+
+// rpmHeader is a decoded signature or header section of an RPM package
+type rpmHeader struct {
+	index []rpmIndexEntry
+	data  []byte
+}
+
+// rpmIndexEntry is an entry in the index of a signature or header section
+type rpmIndexEntry struct {
+	Tag    int32
+	Type   uint32
+	Offset int32
+	Count  uint32
+}
+
+// readRpmHeader reads a signature or header section from src.
+func readRpmHeader(src io.Reader, padded bool) (*rpmHeader, error) {
+	intro := make([]byte, 16)
+	if _, err := io.ReadFull(src, intro); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(intro[:4], magicBytesRpmHeader) {
+		return nil, fmt.Errorf("invalid magic bytes")
+	}
+	indexCount := binary.BigEndian.Uint32(intro[8:12])
+	dataSize := binary.BigEndian.Uint32(intro[12:16])
+	if indexCount > rpmMaxIndexCount || dataSize > rpmMaxDataSize {
+		return nil, fmt.Errorf("header exceeds maximum size")
+	}
+
+	hdr := &rpmHeader{index: make([]rpmIndexEntry, indexCount)}
+	if err := binary.Read(src, binary.BigEndian, hdr.index); err != nil {
+		return nil, err
+	}
+	size := int64(dataSize)
+	if padded {
+		size += (8 - size%8) % 8
+	}
+	data, err := io.ReadAll(io.LimitReader(src, size))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	hdr.data = data[:dataSize]
+	return hdr, nil
+}
+
+// String returns the value of the string tag, or the first value of a string array
+// tag. An empty string is returned, if the tag is not found or invalid.
+func (h *rpmHeader) String(tag int32) string {
+	for _, e := range h.index {
+		if e.Tag != tag {
+			continue
+		}
+		if e.Type != rpmTypeString && e.Type != rpmTypeStringArray && e.Type != rpmTypeI18NString {
+			return ""
+		}
+		if e.Offset < 0 || int(e.Offset) >= len(h.data) {
+			return ""
+		}
+		value := h.data[e.Offset:]
+		if end := bytes.IndexByte(value, 0); end >= 0 {
+			value = value[:end]
+		}
+		return string(value)
+	}
+	return ""
+}
+
+// rpmWalker is a walker for the cpio payload of an RPM package
+type rpmWalker struct {
+	*cpioWalker
+	stream   io.Reader
+	metadata map[string]string
+}
+
+// Type returns the file extension for RPM packages
+func (r *rpmWalker) Type() string {
+	return fileExtensionRpm
+}
+
+// Next returns the next entry of the payload together with the package metadata
+func (r *rpmWalker) Next() (Entry, error) {
+	ae, err := r.cpioWalker.Next()
+	if err != nil {
+		return nil, err
+	}
+	return &rpmEntry{Entry: ae, metadata: r.metadata}, nil
+}
+
+// Close closes the decompressed payload stream.
+func (r *rpmWalker) Close() error {
+	return closeStream(r.stream)
+}
+
+// rpmEntry is an entry in the payload of an RPM package
+type rpmEntry struct {
+	Entry
+	metadata map[string]string
+}
+
+// Metadata returns the metadata of the RPM package
+func (r *rpmEntry) Metadata() map[string]string {
+	return maps.Clone(r.metadata)
+}
@@ -0,0 +1,187 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestUnpackRpm(t *testing.T) {
+	payload := packCpio(t, "newc", []archiveContent{
+		{Name: "./usr", Mode: fs.ModeDir | 0755},
+		{Name: "./usr/bin", Mode: fs.ModeDir | 0755},
+		{Name: "./usr/bin/tool", Content: []byte("hello rpm"), Mode: 0755},
+		{Name: "./usr/bin/alias", Linktarget: "tool", Mode: fs.ModeSymlink | 0777},
+	})
+
+	tests := []struct {
+		name        string
+		src         []byte
+		cfg         *extract.Config
+		expectError bool
+	}{
+		{name: "gzip", src: packRpm(t, "gzip", compressGzip(t, payload))},
+		{name: "default compressor", src: packRpm(t, "", compressGzip(t, payload))},
+		{name: "xz", src: packRpm(t, "xz", compressXz(t, payload))},
+		{name: "zstd", src: packRpm(t, "zstd", compressZstd(t, payload))},
+		{name: "bzip2", src: packRpm(t, "bzip2", compressBzip2(t, payload))},
+		{name: "uncompressed", src: packRpm(t, "identity", payload)},
+		{
+			name:        "unsupported compressor",
+			src:         packRpm(t, "foo", payload),
+			expectError: true,
+		},
+		{
+			name:        "wrong compressor",
+			src:         packRpm(t, "xz", compressGzip(t, payload)),
+			expectError: true,
+		},
+		{
+			name:        "truncated header",
+			src:         packRpm(t, "gzip", compressGzip(t, payload))[:120],
+			expectError: true,
+		},
+		{
+			name:        "max files",
+			src:         packRpm(t, "gzip", compressGzip(t, payload)),
+			cfg:         extract.NewConfig(extract.WithMaxFiles(2)),
+			expectError: true,
+		},
+		{
+			name:        "max extraction size",
+			src:         packRpm(t, "gzip", compressGzip(t, payload)),
+			cfg:         extract.NewConfig(extract.WithMaxExtractionSize(5)),
+			expectError: true,
+		},
+		{
+			name:        "deny symlinks",
+			src:         packRpm(t, "gzip", compressGzip(t, payload)),
+			cfg:         extract.NewConfig(extract.WithDenySymlinkExtraction(true)),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var td *extract.TelemetryData
+			cfg := tc.cfg
+			if cfg == nil {
+				cfg = extract.NewConfig()
+			}
+			extract.WithTelemetryHook(func(ctx context.Context, d *extract.TelemetryData) { td = d })(cfg)
+
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackTo(context.Background(), tm, "", asIoReader(t, tc.src), cfg)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if td.ExtractedType != "rpm" {
+				t.Errorf("expected type %q, got %q", "rpm", td.ExtractedType)
+			}
+			checkMemoryContent(t, tm, map[string]string{"usr/bin/tool": "hello rpm"})
+		})
+	}
+}
+
+func TestListRpm(t *testing.T) {
+	payload := packCpio(t, "newc", []archiveContent{
+		{Name: "./tool", Content: []byte("hello rpm"), Mode: 0755},
+	})
+	entries, err := extract.List(context.Background(), asIoReader(t, packRpm(t, "xz", compressXz(t, payload))), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	expected := map[string]string{
+		extract.MetadataRpmName:              "tool",
+		extract.MetadataRpmVersion:           "1.2.3",
+		extract.MetadataRpmRelease:           "1",
+		extract.MetadataRpmArch:              "x86_64",
+		extract.MetadataRpmPayloadCompressor: "xz",
+	}
+	for k, v := range expected {
+		if entries[0].Metadata[k] != v {
+			t.Errorf("expected metadata %q to be %q, got %q", k, v, entries[0].Metadata[k])
+		}
+	}
+}
+
+func TestRpmHeaderLimit(t *testing.T) {
+	payload := packCpio(t, "newc", []archiveContent{{Name: "./tool", Content: []byte("hello rpm"), Mode: 0755}})
+	src := packRpm(t, "gzip", compressGzip(t, payload))
+
+	// the data section of the signature exceeds the maximum size, which is read into memory
+	binary.BigEndian.PutUint32(src[108:], 8<<20+1)
+	src = append(src, make([]byte, 9<<20)...)
+	_, err := extract.List(context.Background(), asIoReader(t, src), nil)
+	if err == nil || !strings.Contains(err.Error(), "exceeds maximum size") {
+		t.Fatalf("expected error for header exceeding the maximum size, got %v", err)
+	}
+}
+
+// packRpm creates an RPM package for tool-1.2.3-1.x86_64 with the given payload compressor
+// and payload. If the compressor is empty, the tag is omitted.
+func packRpm(t *testing.T, compressor string, payload []byte) []byte {
+	t.Helper()
+	b := &bytes.Buffer{}
+
+	// lead
+	lead := make([]byte, 96)
+	copy(lead, []byte{0xed, 0xab, 0xee, 0xdb, 3, 0})
+	copy(lead[10:], "tool-1.2.3-1")
+	binary.BigEndian.PutUint16(lead[78:], 5) // header style signature
+	b.Write(lead)
+
+	// header section with string tags
+	section := func(tags map[int32]string, padded bool) {
+		var index, data bytes.Buffer
+		for _, tag := range []int32{1000, 1001, 1002, 1022, 1124, 1125} {
+			value, found := tags[tag]
+			if !found {
+				continue
+			}
+			for _, v := range []any{tag, uint32(6), int32(data.Len()), uint32(1)} {
+				if err := binary.Write(&index, binary.BigEndian, v); err != nil {
+					t.Fatalf("error writing index: %v", err)
+				}
+			}
+			data.WriteString(value + "\x00")
+		}
+		b.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0})
+		if err := binary.Write(b, binary.BigEndian, []uint32{uint32(index.Len() / 16), uint32(data.Len())}); err != nil {
+			t.Fatalf("error writing header: %v", err)
+		}
+		b.Write(index.Bytes())
+		b.Write(data.Bytes())
+		if padded {
+			b.Write(make([]byte, (8-data.Len()%8)%8))
+		}
+	}
+
+	// signature and header
+	section(map[int32]string{1000: "sig"}, true)
+	tags := map[int32]string{1000: "tool", 1001: "1.2.3", 1002: "1", 1022: "x86_64", 1124: "cpio"}
+	if compressor != "" {
+		tags[1125] = compressor
+	}
+	section(tags, false)
+
+	b.Write(payload)
+	return b.Bytes()
+}
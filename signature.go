@@ -0,0 +1,108 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SignatureVerifier verifies the detached signature of an archive, before it is extracted.
+type SignatureVerifier interface {
+	// Verify reads the signed content from r and returns an error, if the signature is not
+	// valid for the content.
+	Verify(ctx context.Context, r io.Reader) error
+}
+
+// ed25519Verifier verifies a plain Ed25519 signature of the whole content.
+type ed25519Verifier struct {
+	publicKey ed25519.PublicKey
+	signature []byte
+}
+
+// maxEd25519MessageSize is the maximum size of content, which is signed with plain Ed25519. As
+// plain Ed25519 signs the message itself and not a hash of it, the content is read into memory
+// for the verification.
+const maxEd25519MessageSize = 32 << 20
+
+// NewEd25519Verifier returns a [SignatureVerifier], which verifies the raw Ed25519 signature of
+// the whole archive with publicKey. As Ed25519 signs the message itself and not a hash of it,
+// the archive is read into memory for the verification, so that archives larger than 32 MiB are
+// rejected.
+func NewEd25519Verifier(publicKey ed25519.PublicKey, signature []byte) (SignatureVerifier, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key size: %d", len(publicKey))
+	}
+	if len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid ed25519 signature size: %d", len(signature))
+	}
+	return &ed25519Verifier{publicKey: publicKey, signature: signature}, nil
+}
+
+// Verify implements [SignatureVerifier].
+func (v *ed25519Verifier) Verify(_ context.Context, r io.Reader) error {
+	message, err := readEd25519Message(r)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(v.publicKey, message, v.signature) {
+		return errors.New("ed25519 signature does not match")
+	}
+	return nil
+}
+
+// readEd25519Message reads the content, which is signed with plain Ed25519, from r into memory.
+// Content larger than maxEd25519MessageSize is rejected.
+func readEd25519Message(r io.Reader) ([]byte, error) {
+	message, err := io.ReadAll(io.LimitReader(r, maxEd25519MessageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read signed content: %w", err)
+	}
+	if len(message) > maxEd25519MessageSize {
+		return nil, fmt.Errorf("signed content exceeds the maximum of %d bytes for plain ed25519 signatures", maxEd25519MessageSize)
+	}
+	return message, nil
+}
+
+// verifySignature verifies the signature of src with the signature verifier of cfg, while src
+// is cached with [cacheInput], so that src is read only once. The cached input is returned
+// after the signature is verified, together with a function, which removes the cache.
+func verifySignature(ctx context.Context, cfg *Config, src io.Reader) (io.Reader, func(), error) {
+	pr, pw := io.Pipe()
+	verified := make(chan error, 1)
+	go func() {
+		err := cfg.SignatureVerifier().Verify(ctx, pr)
+
+		// consume the input, which is not read by the verifier, so that the cache is complete
+		_, _ = io.Copy(io.Discard, pr)
+		verified <- err
+	}()
+
+	// the tee reader is never cached as is, so that the whole input passes the verifier
+	sra, cleanup, err := cacheInput(cfg, io.TeeReader(src, pw))
+	pw.CloseWithError(err)
+	verr := <-verified
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: cannot cache input: %w", ErrSignatureVerificationFailed, err)
+	}
+	if verr != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("%w: %w", ErrSignatureVerificationFailed, verr)
+	}
+	if err := ctx.Err(); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	// hide the cache file, so that its name is not used for the extraction
+	size, err := sra.Seek(0, io.SeekEnd)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("cannot seek to end of cache: %w", err)
+	}
+	return io.NewSectionReader(sra, 0, size), cleanup, nil
+}
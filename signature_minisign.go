@@ -0,0 +1,137 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// minisignAlgorithm is the algorithm of minisign keys and legacy signatures of the whole content
+	minisignAlgorithm = "Ed"
+
+	// minisignHashedAlgorithm is the algorithm of minisign signatures of the BLAKE2b-512 hash of the content
+	minisignHashedAlgorithm = "ED"
+
+	// minisignUntrustedComment is the prefix of the first line of minisign keys and signatures
+	minisignUntrustedComment = "untrusted comment:"
+
+	// minisignTrustedComment is the prefix of the trusted comment of minisign signatures
+	minisignTrustedComment = "trusted comment: "
+)
+
+// minisignVerifier verifies a minisign signature.
+type minisignVerifier struct {
+	keyID           []byte
+	publicKey       ed25519.PublicKey
+	algorithm       string
+	signatureKeyID  []byte
+	signature       []byte
+	trustedComment  string
+	globalSignature []byte
+}
+
+// NewMinisignVerifier returns a [SignatureVerifier], which verifies a minisign signature, like the
+// content of a .minisig file, with the minisign public key. The public key is either the content of
+// a minisign.pub file or the base64 encoded key itself. Besides the signature of the archive, the
+// signature of the trusted comment is verified. Legacy signatures of the whole archive, which are
+// not prehashed, require to read the archive into memory, so that such archives are limited to
+// 32 MiB.
+func NewMinisignVerifier(publicKey string, signature []byte) (SignatureVerifier, error) {
+	key, err := decodeMinisignLine(publicKey, 2+8+ed25519.PublicKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minisign public key: %w", err)
+	}
+	if string(key[:2]) != minisignAlgorithm {
+		return nil, fmt.Errorf("unsupported minisign key algorithm: %q", key[:2])
+	}
+
+	// a signature consists of the untrusted comment, the signature, the trusted comment
+	// and the global signature
+	lines := strings.Split(strings.ReplaceAll(string(bytes.TrimSpace(signature)), "\r\n", "\n"), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], minisignUntrustedComment) || !strings.HasPrefix(lines[2], minisignTrustedComment) {
+		return nil, errors.New("invalid minisign signature format")
+	}
+	sig, err := decodeMinisignLine(lines[1], 2+8+ed25519.SignatureSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minisign signature: %w", err)
+	}
+	if a := string(sig[:2]); a != minisignAlgorithm && a != minisignHashedAlgorithm {
+		return nil, fmt.Errorf("unsupported minisign signature algorithm: %q", a)
+	}
+	globalSignature, err := decodeMinisignLine(lines[3], ed25519.SignatureSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minisign global signature: %w", err)
+	}
+
+	return &minisignVerifier{
+		keyID:           key[2:10],
+		publicKey:       ed25519.PublicKey(key[10:]),
+		algorithm:       string(sig[:2]),
+		signatureKeyID:  sig[2:10],
+		signature:       sig[10:],
+		trustedComment:  strings.TrimPrefix(lines[2], minisignTrustedComment),
+		globalSignature: globalSignature,
+	}, nil
+}
+
+// decodeMinisignLine decodes the last line of s, which is not a comment, from base64 and checks
+// that it has the given size.
+func decodeMinisignLine(s string, size int) ([]byte, error) {
+	var line string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); len(l) > 0 && !strings.HasPrefix(l, minisignUntrustedComment) {
+			line = l
+		}
+	}
+	b, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != size {
+		return nil, fmt.Errorf("invalid size %d", len(b))
+	}
+	return b, nil
+}
+
+// Verify implements [SignatureVerifier].
+func (v *minisignVerifier) Verify(_ context.Context, r io.Reader) error {
+	if !bytes.Equal(v.keyID, v.signatureKeyID) {
+		return fmt.Errorf("minisign signature key ID %016X does not match public key ID %016X",
+			binary.LittleEndian.Uint64(v.signatureKeyID), binary.LittleEndian.Uint64(v.keyID))
+	}
+
+	// determine the signed message
+	var message []byte
+	if v.algorithm == minisignHashedAlgorithm {
+		h, _ := blake2b.New512(nil)
+		if _, err := io.Copy(h, r); err != nil {
+			return fmt.Errorf("cannot read signed content: %w", err)
+		}
+		message = h.Sum(nil)
+	} else {
+		var err error
+		if message, err = readEd25519Message(r); err != nil {
+			return err
+		}
+	}
+
+	if !ed25519.Verify(v.publicKey, message, v.signature) {
+		return errors.New("minisign signature does not match")
+	}
+	if !ed25519.Verify(v.publicKey, append(bytes.Clone(v.signature), v.trustedComment...), v.globalSignature) {
+		return errors.New("minisign signature of trusted comment does not match")
+	}
+	return nil
+}
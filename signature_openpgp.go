@@ -0,0 +1,54 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// armorPrefix is the prefix of ASCII armored OpenPGP data
+var armorPrefix = []byte("-----BEGIN PGP")
+
+// openPGPVerifier verifies a detached OpenPGP signature.
+type openPGPVerifier struct {
+	keyring   openpgp.EntityList
+	signature []byte
+}
+
+// NewOpenPGPVerifier returns a [SignatureVerifier], which verifies a detached OpenPGP signature,
+// like the content of a .sig or .asc file, with the public keys of keyring. Both, the keyring and
+// the signature, can be binary or ASCII armored.
+func NewOpenPGPVerifier(keyring io.Reader, signature []byte) (SignatureVerifier, error) {
+	b, err := io.ReadAll(keyring)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read keyring: %w", err)
+	}
+	var keys openpgp.EntityList
+	if bytes.HasPrefix(bytes.TrimSpace(b), armorPrefix) {
+		keys, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(b))
+	} else {
+		keys, err = openpgp.ReadKeyRing(bytes.NewReader(b))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid keyring: %w", err)
+	}
+	return &openPGPVerifier{keyring: keys, signature: signature}, nil
+}
+
+// Verify implements [SignatureVerifier].
+func (v *openPGPVerifier) Verify(_ context.Context, r io.Reader) error {
+	check := openpgp.CheckDetachedSignature
+	if bytes.HasPrefix(bytes.TrimSpace(v.signature), armorPrefix) {
+		check = openpgp.CheckArmoredDetachedSignature
+	}
+	if _, err := check(v.keyring, r, bytes.NewReader(v.signature), nil); err != nil {
+		return fmt.Errorf("openpgp signature does not match: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/hashicorp/go-extract"
+	"golang.org/x/crypto/blake2b"
+)
+
+// minisignKey returns a minisign public key and a function, which signs a message like minisign.
+func minisignKey(t *testing.T) (string, func(message []byte, prehash bool, trustedComment string) []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	publicKey := fmt.Sprintf("untrusted comment: minisign public key\n%s\n",
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...)))
+
+	sign := func(message []byte, prehash bool, trustedComment string) []byte {
+		algorithm := "Ed"
+		if prehash {
+			sum := blake2b.Sum512(message)
+			message, algorithm = sum[:], "ED"
+		}
+		sig := ed25519.Sign(priv, message)
+		global := ed25519.Sign(priv, append(bytes.Clone(sig), trustedComment...))
+		return fmt.Appendf(nil, "untrusted comment: signature\n%s\ntrusted comment: %s\n%s\n",
+			base64.StdEncoding.EncodeToString(append(append([]byte(algorithm), keyID...), sig...)),
+			trustedComment,
+			base64.StdEncoding.EncodeToString(global))
+	}
+	return publicKey, sign
+}
+
+// openPGPKey returns an ASCII armored OpenPGP public key and the entity with the private key.
+func openPGPKey(t *testing.T) ([]byte, *openpgp.Entity) {
+	t.Helper()
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+	var b bytes.Buffer
+	w, err := armor.Encode(&b, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("cannot armor key: %v", err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("cannot serialize key: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("cannot close armor: %v", err)
+	}
+	return b.Bytes(), entity
+}
+
+func TestSignatureVerifier(t *testing.T) {
+	archive := packTar(t, []archiveContent{{Name: "file", Content: []byte("content"), Mode: 0644}})
+	tampered := packTar(t, []archiveContent{{Name: "file", Content: []byte("tampered"), Mode: 0644}})
+
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+	edVerifier, err := extract.NewEd25519Verifier(edPub, ed25519.Sign(edPriv, archive))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	minisignPub, minisign := minisignKey(t)
+	newMinisignVerifier := func(signature []byte) extract.SignatureVerifier {
+		v, err := extract.NewMinisignVerifier(minisignPub, signature)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return v
+	}
+	_, otherMinisign := minisignKey(t)
+	forgedComment := bytes.Replace(minisign(archive, true, "release"), []byte("trusted comment: release"), []byte("trusted comment: forged"), 1)
+
+	pgpKey, pgpEntity := openPGPKey(t)
+	var armored, binary bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&armored, pgpEntity, bytes.NewReader(archive), nil); err != nil {
+		t.Fatalf("cannot sign: %v", err)
+	}
+	if err := openpgp.DetachSign(&binary, pgpEntity, bytes.NewReader(archive), nil); err != nil {
+		t.Fatalf("cannot sign: %v", err)
+	}
+	newOpenPGPVerifier := func(signature []byte) extract.SignatureVerifier {
+		v, err := extract.NewOpenPGPVerifier(bytes.NewReader(pgpKey), signature)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name     string
+		verifier extract.SignatureVerifier
+		src      []byte
+		valid    bool
+	}{
+		{name: "ed25519", verifier: edVerifier, src: archive, valid: true},
+		{name: "ed25519 tampered", verifier: edVerifier, src: tampered},
+		{name: "minisign prehashed", verifier: newMinisignVerifier(minisign(archive, true, "release")), src: archive, valid: true},
+		{name: "minisign legacy", verifier: newMinisignVerifier(minisign(archive, false, "release")), src: archive, valid: true},
+		{name: "minisign tampered", verifier: newMinisignVerifier(minisign(archive, true, "release")), src: tampered},
+		{name: "minisign forged trusted comment", verifier: newMinisignVerifier(forgedComment), src: archive},
+		{name: "minisign other key", verifier: newMinisignVerifier(otherMinisign(archive, true, "release")), src: archive},
+		{name: "openpgp armored", verifier: newOpenPGPVerifier(armored.Bytes()), src: archive, valid: true},
+		{name: "openpgp binary", verifier: newOpenPGPVerifier(binary.Bytes()), src: archive, valid: true},
+		{name: "openpgp tampered", verifier: newOpenPGPVerifier(armored.Bytes()), src: tampered},
+	}
+
+	for _, tc := range tests {
+		for _, inMemory := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s (cache in memory: %t)", tc.name, inMemory), func(t *testing.T) {
+				dir := t.TempDir()
+				cfg := extract.NewConfig(extract.WithSignatureVerifier(tc.verifier), extract.WithCacheInMemory(inMemory))
+
+				// the input is read as stream
+				err := extract.Unpack(context.Background(), dir, io.MultiReader(bytes.NewReader(tc.src)), cfg)
+				if !tc.valid {
+					if !errors.Is(err, extract.ErrSignatureVerificationFailed) {
+						t.Fatalf("expected error %v, got %v", extract.ErrSignatureVerificationFailed, err)
+					}
+					if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+						t.Errorf("expected nothing to be extracted, got %d entries", len(entries))
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if b, err := os.ReadFile(filepath.Join(dir, "file")); err != nil || string(b) != "content" {
+					t.Errorf("expected file to be extracted, got %q: %v", b, err)
+				}
+			})
+		}
+	}
+}
+
+func TestSignatureVerifierLimits(t *testing.T) {
+	archive := packTar(t, []archiveContent{{Name: "file", Content: bytes.Repeat([]byte("x"), 4096), Mode: 0644}})
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+	verifier, err := extract.NewEd25519Verifier(pub, ed25519.Sign(priv, archive))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the maximum input size applies to the verified input
+	cfg := extract.NewConfig(extract.WithSignatureVerifier(verifier), extract.WithMaxInputSize(1024))
+	tm := extract.NewTargetMemory()
+	if err := extract.UnpackTo(context.Background(), tm, "", bytes.NewReader(archive), cfg); !errors.Is(err, extract.ErrSignatureVerificationFailed) {
+		t.Errorf("expected error %v, got %v", extract.ErrSignatureVerificationFailed, err)
+	}
+
+	// content of plain ed25519 signatures is read into memory, which is limited
+	large := make([]byte, 32<<20+1)
+	if err := verifier.Verify(context.Background(), bytes.NewReader(large)); err == nil || !strings.Contains(err.Error(), "exceeds the maximum") {
+		t.Errorf("expected error for content exceeding the maximum size, got %v", err)
+	}
+	minisignPub, sign := minisignKey(t)
+	legacy, err := extract.NewMinisignVerifier(minisignPub, sign(large, false, "legacy"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := legacy.Verify(context.Background(), bytes.NewReader(large)); err == nil || !strings.Contains(err.Error(), "exceeds the maximum") {
+		t.Errorf("expected error for content exceeding the maximum size, got %v", err)
+	}
+
+	// multi-volume archives are not supported
+	cfg = extract.NewConfig(extract.WithSignatureVerifier(verifier))
+	if err := extract.UnpackVolumes(context.Background(), tm, "", []io.Reader{bytes.NewReader(archive)}, cfg); !errors.Is(err, extract.ErrSignatureVerificationFailed) {
+		t.Errorf("expected error %v, got %v", extract.ErrSignatureVerificationFailed, err)
+	}
+
+	// invalid keys and signatures are rejected
+	if _, err := extract.NewEd25519Verifier(pub[:10], nil); err == nil {
+		t.Errorf("expected error for invalid ed25519 key")
+	}
+	if _, err := extract.NewMinisignVerifier("invalid", nil); err == nil {
+		t.Errorf("expected error for invalid minisign key")
+	}
+	if _, err := extract.NewOpenPGPVerifier(bytes.NewReader([]byte("invalid")), nil); err == nil {
+		t.Errorf("expected error for invalid keyring")
+	}
+}
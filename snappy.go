@@ -28,6 +28,11 @@ func unpackSnappy(ctx context.Context, t Target, dst string, src io.Reader, cfg
 	return decompress(ctx, t, dst, src, cfg, decompressSnappyStream, fileExtensionSnappy)
 }
 
+// walkSnappy opens the snappy compressed src and returns a Walker for the decompressed content.
+func walkSnappy(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return walkDecompressed(ctx, src, cfg, decompressSnappyStream, fileExtensionSnappy)
+}
+
 // decompressSnappyStream returns an io.Reader that decompresses src with snappy algorithm
 func decompressSnappyStream(src io.Reader) (io.Reader, error) {
 	return snappy.NewReader(src), nil
@@ -42,7 +42,7 @@ func unpackTar(ctx context.Context, t Target, dst string, src io.Reader, cfg *Co
 	defer captureInputSize(td, limitedReader)
 
 	// start extraction
-	return processTar(ctx, t, limitedReader, dst, cfg, td)
+	return processTar(ctx, t, limitedReader, dst, cfg.withCompressedInput(consumedInput(limitedReader)), td)
 }
 
 // processTar extracts the tar archive from src to dst
@@ -50,6 +50,11 @@ func processTar(ctx context.Context, t Target, src io.Reader, dst string, c *Con
 	return extract(ctx, t, dst, &tarWalker{tr: tar.NewReader(src)}, c, td)
 }
 
+// walkTar returns a Walker for the tar archive in src.
+func walkTar(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return &tarWalker{tr: tar.NewReader(newLimitErrorReader(src, cfg.MaxInputSize()))}, nil
+}
+
 // tarWalker is a walker for tar files
 type tarWalker struct {
 	tr *tar.Reader
@@ -61,7 +66,7 @@ func (t *tarWalker) Type() string {
 }
 
 // Next returns the next entry in the tar archive
-func (t *tarWalker) Next() (archiveEntry, error) {
+func (t *tarWalker) Next() (Entry, error) {
 	hdr, err := t.tr.Next()
 	if err != nil {
 		return nil, err
@@ -105,6 +110,11 @@ func (t *tarEntry) IsDir() bool {
 	return t.hdr.Typeflag == tar.TypeDir
 }
 
+// IsHardlink returns true if the entry is a hard link
+func (t *tarEntry) IsHardlink() bool {
+	return t.hdr.Typeflag == tar.TypeLink
+}
+
 // IsSymlink returns true if the entry is a symlink
 func (t *tarEntry) IsSymlink() bool {
 	return t.hdr.Typeflag == tar.TypeSymlink
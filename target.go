@@ -4,17 +4,20 @@
 package extract
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 )
 
-// Target specifies all function that are needed to be implemented to extract contents from an archive
+// Target specifies all function that are needed to be implemented to extract contents from an archive.
+// Targets, which support hard links, additionally implement [HardlinkTarget].
 type Target interface {
 	// CreateFile creates a file at the specified path with src as content. The mode parameter is the file mode that
 	// should be set on the file. If the file already exists and overwrite is false, an error should be returned. If the
@@ -53,6 +56,19 @@ type Target interface {
 	Chown(name string, uid, gid int) error
 }
 
+// HardlinkTarget is a [Target], which can create hard links. The hard links of an archive are only
+// extracted to targets, which implement this interface, like [TargetDisk], [TargetRoot] and
+// [TargetMemory]. For other targets, hard links are unsupported files, see
+// [WithContinueOnUnsupportedFiles].
+type HardlinkTarget interface {
+	Target
+
+	// CreateHardlink creates newname as a hard link to the oldname file. If newname already exists and overwrite is
+	// false, the function returns an error. If newname already exists and overwrite is true, the function may overwrite
+	// the existing entry.
+	CreateHardlink(oldname string, newname string, overwrite bool) error
+}
+
 // createFile is a wrapper around the CreateFile function
 //
 // If the name is empty, the function returns an error.
@@ -66,13 +82,29 @@ type Target interface {
 // If the path contains a symlink and config.TraverseSymlinks() returns true, a warning is logged and the
 // function continues.
 //
+// If a content filter is configured, the content is read through the filter. If the filter rejects the
+// content, the file is removed and an error, which wraps ErrContentRejected, is returned.
+//
+// If digests are configured, the digests of the written content are computed and the file is added
+// to the manifest of td. If an expected digest is configured for the file and the digest of the written
+// content does not match, the file is removed and an error, which wraps ErrDigestMismatch, is returned.
+//
 // If the file is created successfully, the function returns the number of bytes written and nil.
-func createFile(t Target, dst string, name string, src io.Reader, mode fs.FileMode, maxSize int64, cfg *Config) (int64, error) {
+func createFile(ctx context.Context, t Target, dst string, ei EntryInfo, src io.Reader, maxSize int64, cfg *Config, td *TelemetryData) (int64, error) {
+	name := ei.Name
+
 	// check if a name is provided
 	if len(name) == 0 {
 		return 0, fmt.Errorf("cannot create file without name")
 	}
 
+	// look up the expected digest of the file
+	entryPath := cfg.entryPath(name)
+	expected, verify, err := cfg.verifier.lookup(entryPath)
+	if err != nil {
+		return 0, err
+	}
+
 	// adjust path to by os specific
 	parts := strings.Split(name, "/")
 	name = filepath.Join(parts...)
@@ -91,7 +123,62 @@ func createFile(t Target, dst string, name string, src io.Reader, mode fs.FileMo
 		return 0, fmt.Errorf("security check path failed: %w", err)
 	}
 	path := filepath.Join(dst, name)
-	return t.CreateFile(path, src, mode, cfg.Overwrite(), maxSize)
+
+	// inspect or transform the content with the content filter
+	src, err = filterContent(ctx, cfg, ei, src)
+	if err != nil {
+		return 0, err
+	}
+
+	// compute the digests of the written content
+	algorithms := cfg.Digests()
+	if verify && !slices.Contains(algorithms, expected.Algorithm) {
+		algorithms = append(slices.Clip(algorithms), expected.Algorithm)
+	}
+	d, err := newDigester(algorithms)
+	if err != nil {
+		return 0, err
+	}
+	if d != nil {
+		src = d.reader(src)
+	}
+
+	n, err := t.CreateFile(path, src, ei.Mode, cfg.Overwrite(), maxSize)
+	if errors.Is(err, ErrContentRejected) {
+		removeFile(t, path, cfg)
+	}
+	if err != nil || d == nil {
+		return n, err
+	}
+
+	// verify the digest and add the file to the manifest
+	sums := d.sums()
+	if verify {
+		if err := cfg.verifier.verify(entryPath, expected, sums[expected.Algorithm]); err != nil {
+			removeFile(t, path, cfg)
+			return n, err
+		}
+		if !slices.Contains(cfg.Digests(), expected.Algorithm) {
+			delete(sums, expected.Algorithm)
+		}
+	}
+	if len(cfg.Digests()) > 0 {
+		td.Manifest = append(td.Manifest, ManifestEntry{Path: entryPath, Size: n, Mode: ei.Mode, Digests: sums})
+	}
+	return n, nil
+}
+
+// removeFile removes the file at path, which is rejected after it is written, if the target
+// supports the removal of files.
+func removeFile(t Target, path string, cfg *Config) {
+	r, ok := t.(remover)
+	if !ok {
+		cfg.Logger().Warn("cannot remove rejected file, target does not support removal", "path", path)
+		return
+	}
+	if err := r.Remove(path); err != nil {
+		cfg.Logger().Warn("cannot remove rejected file", "path", path, "error", err)
+	}
 }
 
 // createDir is a wrapper around the CreateDir function
@@ -146,6 +233,9 @@ func createDir(t Target, dst string, name string, mode fs.FileMode, cfg *Config)
 // If the symlink extraction is denied, the function returns an error. If the link target is an
 // absolute path, the function returns an error.
 //
+// If unexpected files are denied by the digest verification, the function returns an error, which
+// wraps ErrUnexpectedFile.
+//
 // If the name is empty, the function returns an error .
 //
 // If the directory for the symlink does not exist, it will be created with the config.CustomCreateDirMode().
@@ -164,6 +254,11 @@ func createSymlink(t Target, dst string, name string, linkTarget string, cfg *Co
 		return unsupportedFile(name)
 	}
 
+	// check if unverifiable symlinks are denied
+	if err := cfg.verifier.checkSymlink(cfg.entryPath(name)); err != nil {
+		return err
+	}
+
 	// check if a name is provided
 	if len(name) == 0 {
 		return fmt.Errorf("empty name")
@@ -204,6 +299,95 @@ func createSymlink(t Target, dst string, name string, linkTarget string, cfg *Co
 	return t.CreateSymlink(linkTarget, filepath.Join(dst, name), cfg.Overwrite())
 }
 
+// createHardlink is a wrapper around the CreateHardlink function
+//
+// The link target of a hard link is relative to the root of the archive. If the link
+// target is an absolute path, the function returns an error.
+//
+// If the target does not implement HardlinkTarget, the function returns an error, which wraps
+// ErrUnsupportedFile.
+//
+// If the name is empty, the function returns an error.
+//
+// If the directory for the hard link does not exist, it will be created with the config.CustomCreateDirMode().
+//
+// If the path or the link target contains path traversal or a symlink, the function returns an error.
+//
+// If the path contains a symlink and config.TraverseSymlinks() returns true, a warning is logged and the
+// function continues.
+//
+// If the link target is the hard link itself, does not exist or is not a regular file, the function
+// returns an error.
+//
+// If the hard link is created successfully, the function returns nil.
+func createHardlink(t Target, dst string, name string, linkTarget string, cfg *Config) error {
+	// check if the target supports hard links
+	hl, ok := t.(HardlinkTarget)
+	if !ok {
+		return unsupportedFile(name)
+	}
+
+	// check if a name is provided
+	if len(name) == 0 {
+		return fmt.Errorf("empty name")
+	}
+
+	// check if a link target is provided
+	if len(linkTarget) == 0 {
+		return fmt.Errorf("empty link target")
+	}
+
+	// check if link target is absolute path
+	if filepath.IsAbs(linkTarget) {
+		return fmt.Errorf("hard link with absolute path as target: %s", linkTarget)
+	}
+
+	// verify the hard link against the expected digest of the link target
+	if err := cfg.verifier.verifyLink(cfg.entryPath(name), cfg.entryPath(linkTarget)); err != nil {
+		return err
+	}
+
+	// convert name and link target to platform specific path
+	parts := strings.Split(name, "/")
+	name = filepath.Join(parts...)
+	parts = strings.Split(linkTarget, "/")
+	linkTarget = filepath.Join(parts...)
+
+	// check if the hard link points to itself, which would remove the link target on overwrite
+	if name == linkTarget {
+		return fmt.Errorf("hard link to itself: %s", linkTarget)
+	}
+
+	// create target dir && check for traversal in file name
+	linkDirectory := filepath.Dir(name)
+	if err := createDir(t, dst, linkDirectory, cfg.CustomCreateDirMode(), cfg); err != nil {
+		return fmt.Errorf("cannot create directory (%s) for hard link: %w", fmt.Sprintf("%s%s", linkDirectory, string(os.PathSeparator)), err)
+	}
+
+	// ensure that if the hard link exist that it is not a symlink
+	if err := securityCheck(t, dst, name, cfg); err != nil {
+		return fmt.Errorf("security check path failed: %w", err)
+	}
+
+	// check link target for traversal and symlinks
+	if err := securityCheck(t, dst, linkTarget, cfg); err != nil {
+		return fmt.Errorf("hard link target security check path failed: %w", err)
+	}
+
+	// ensure that the link target is an already extracted regular file
+	targetPath := filepath.Join(dst, linkTarget)
+	stat, err := t.Lstat(targetPath)
+	if err != nil {
+		return fmt.Errorf("hard link target does not exist: %w", err)
+	}
+	if !stat.Mode().IsRegular() {
+		return fmt.Errorf("hard link target is not a regular file: %s", linkTarget)
+	}
+
+	// create hard link
+	return hl.CreateHardlink(targetPath, filepath.Join(dst, name), cfg.Overwrite())
+}
+
 // securityCheck checks if the targetDirectory contains path traversal
 // and if the path contains a symlink.
 //
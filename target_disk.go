@@ -98,6 +98,36 @@ func (d *TargetDisk) CreateSymlink(oldname string, newname string, overwrite boo
 
 }
 
+// CreateHardlink creates newname as a hard link to the oldname file. If
+// newname already exists and overwrite is false, an error should be returned.
+// If newname is already the oldname file, nothing is done.
+func (d *TargetDisk) CreateHardlink(oldname string, newname string, overwrite bool) error {
+
+	// Check for file existence and if it should be overwritten
+	if stat, err := os.Lstat(newname); !os.IsNotExist(err) {
+		if !overwrite {
+			return fmt.Errorf("file already exist")
+		}
+
+		// keep the link target, which must not be removed
+		if old, err := os.Lstat(oldname); err == nil && stat != nil && os.SameFile(old, stat) {
+			return nil
+		}
+
+		// delete existing entry
+		if err := os.Remove(newname); err != nil {
+			return fmt.Errorf("failed to overwrite file: %w", err)
+		}
+	}
+
+	// create link
+	if err := os.Link(oldname, newname); err != nil {
+		return fmt.Errorf("failed to create hard link: %w", err)
+	}
+
+	return nil
+}
+
 // Lstat returns the FileInfo structure describing the named file.
 // If there is an error, it will be of type *PathError.
 func (d *TargetDisk) Lstat(name string) (fs.FileInfo, error) {
@@ -127,3 +157,13 @@ func (d *TargetDisk) Lchtimes(name string, atime, mtime time.Time) error {
 	}
 	return nil
 }
+
+// Remove removes the named file or empty directory.
+func (d *TargetDisk) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// Rename renames (moves) oldpath to newpath.
+func (d *TargetDisk) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
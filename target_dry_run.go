@@ -0,0 +1,370 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// maxDryRunSymlinks is the maximum number of symlinks, which are followed by [TargetDryRun.Stat].
+const maxDryRunSymlinks = 255
+
+// OperationType is the type of an operation in the plan of a [TargetDryRun].
+type OperationType int
+
+const (
+	// OperationCreateFile is the creation of a file.
+	OperationCreateFile OperationType = iota
+
+	// OperationCreateDir is the creation of a directory and its missing parents.
+	OperationCreateDir
+
+	// OperationCreateSymlink is the creation of a symbolic link.
+	OperationCreateSymlink
+
+	// OperationCreateHardlink is the creation of a hard link.
+	OperationCreateHardlink
+
+	// OperationChmod is the change of the file mode.
+	OperationChmod
+
+	// OperationChown is the change of the owner and group.
+	OperationChown
+
+	// OperationRemove is the removal of a file, e.g. a file rejected by the content filter.
+	OperationRemove
+)
+
+// String returns a string representation of [OperationType].
+func (ot OperationType) String() string {
+	switch ot {
+	case OperationCreateFile:
+		return "create_file"
+	case OperationCreateDir:
+		return "create_dir"
+	case OperationCreateSymlink:
+		return "create_symlink"
+	case OperationCreateHardlink:
+		return "create_hardlink"
+	case OperationChmod:
+		return "chmod"
+	case OperationChown:
+		return "chown"
+	case OperationRemove:
+		return "remove"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements the [encoding.TextMarshaler] interface.
+func (ot OperationType) MarshalText() ([]byte, error) {
+	return []byte(ot.String()), nil
+}
+
+// PlannedOperation is an operation, which is recorded by [TargetDryRun] instead of being performed.
+type PlannedOperation struct {
+	// Type is the type of the operation
+	Type OperationType `json:"type"`
+
+	// Path is the path of the file, directory or link, as passed to the target
+	Path string `json:"path"`
+
+	// Mode is the file mode of created files and directories and of chmod operations
+	Mode fs.FileMode `json:"mode,omitempty"`
+
+	// Size is the number of bytes, which would be written to a created file
+	Size int64 `json:"size,omitempty"`
+
+	// Linkname is the target of a symlink or hard link
+	Linkname string `json:"linkname,omitempty"`
+
+	// Overwrite is true, if an existing file is overwritten
+	Overwrite bool `json:"overwrite,omitempty"`
+
+	// Uid is the user id of chown operations, and -1 for other operations, like for [os.Chown]
+	Uid int `json:"uid"`
+
+	// Gid is the group id of chown operations, and -1 for other operations, like for [os.Chown]
+	Gid int `json:"gid"`
+}
+
+// TargetDryRun is a [Target], which does not change anything. Instead, all operations of an
+// extraction are recorded in a plan, which is returned by [TargetDryRun.Plan], so that it can be
+// reviewed, before the archive is extracted to a shared volume.
+//
+// The content of files is read and discarded, so that the sizes of the files are determined and
+// the limits of the configuration are enforced like for an extraction. The planned files,
+// directories and links are tracked in memory on top of an optional base target, which provides
+// the existing entries, e.g. [TargetDisk]. Hence, the security checks, e.g. for symlinks in the
+// path of an entry or for existing files, see the same state as an extraction to the base target.
+// Changes of the file times are not recorded.
+type TargetDryRun struct {
+	base    Target
+	mu      sync.Mutex
+	entries map[string]*memoryFileInfo
+	links   map[string]string
+	plan    []PlannedOperation
+}
+
+// NewTargetDryRun creates a new TargetDryRun on top of base. If base is nil, the dry run starts
+// with an empty filesystem, like [TargetMemory].
+func NewTargetDryRun(base Target) *TargetDryRun {
+	return &TargetDryRun{
+		base:    base,
+		entries: make(map[string]*memoryFileInfo),
+		links:   make(map[string]string),
+	}
+}
+
+// Plan returns the recorded operations in the order in which they are called.
+func (d *TargetDryRun) Plan() []PlannedOperation {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]PlannedOperation(nil), d.plan...)
+}
+
+// record adds the operation to the plan.
+func (d *TargetDryRun) record(op PlannedOperation) {
+	if op.Type != OperationChown {
+		op.Uid, op.Gid = -1, -1
+	}
+	d.plan = append(d.plan, op)
+}
+
+// lstat returns the planned or existing entry at path. A nil entry in the planned entries marks
+// an entry of the base target as removed.
+func (d *TargetDryRun) lstat(path string) (fs.FileInfo, error) {
+	path = filepath.Clean(path)
+	if fi, ok := d.entries[path]; ok {
+		if fi == nil {
+			return nil, &fs.PathError{Op: "lstat", Path: path, Err: fs.ErrNotExist}
+		}
+		return fi, nil
+	}
+	if d.base == nil {
+		if path == "." {
+			return &memoryFileInfo{name: ".", mode: fs.ModeDir | 0755}, nil
+		}
+		return nil, &fs.PathError{Op: "lstat", Path: path, Err: fs.ErrNotExist}
+	}
+	return d.base.Lstat(path)
+}
+
+// checkOverwrite returns true, if path exists and is overwritten, or an error, if path exists
+// and overwrite is false.
+func (d *TargetDryRun) checkOverwrite(path string, overwrite bool) (bool, error) {
+	if _, err := d.lstat(path); !errors.Is(err, fs.ErrNotExist) {
+		if err != nil {
+			return false, fmt.Errorf("invalid path: %w", err)
+		}
+		if !overwrite {
+			return false, fmt.Errorf("file already exists")
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// CreateFile reads src up to maxSize bytes without writing it and records the creation of the file.
+func (d *TargetDryRun) CreateFile(path string, src io.Reader, mode fs.FileMode, overwrite bool, maxSize int64) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	overwritten, err := d.checkOverwrite(path, overwrite)
+	if err != nil {
+		return 0, err
+	}
+	if fi, err := d.lstat(path); err == nil && fi.IsDir() {
+		return 0, fmt.Errorf("failed to create file: %w", &fs.PathError{Op: "open", Path: path, Err: fs.ErrExist})
+	}
+
+	// drain data for size accounting
+	n, err := io.Copy(limitWriter(io.Discard, maxSize), src)
+	if err != nil {
+		return n, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	d.entries[filepath.Clean(path)] = &memoryFileInfo{name: filepath.Base(path), size: n, mode: mode.Perm(), modTime: time.Now()}
+	d.record(PlannedOperation{Type: OperationCreateFile, Path: path, Mode: mode.Perm(), Size: n, Overwrite: overwritten})
+	return n, nil
+}
+
+// CreateDir records the creation of the directory at path and its missing parents. If the
+// directory already exists, nothing is recorded.
+func (d *TargetDryRun) CreateDir(path string, mode fs.FileMode) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// determine missing directories, like os.MkdirAll
+	var missing []string
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		fi, err := d.stat(p)
+		if err == nil {
+			if !fi.IsDir() {
+				return fmt.Errorf("failed to create directory (%w)", &fs.PathError{Op: "mkdir", Path: p, Err: errors.New("not a directory")})
+			}
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to create directory (%w)", err)
+		}
+		missing = append(missing, p)
+		if filepath.Dir(p) == p {
+			break
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	for _, p := range missing {
+		d.entries[p] = &memoryFileInfo{name: filepath.Base(p), mode: fs.ModeDir | mode.Perm(), modTime: time.Now()}
+	}
+	d.record(PlannedOperation{Type: OperationCreateDir, Path: path, Mode: fs.ModeDir | mode.Perm()})
+	return nil
+}
+
+// CreateSymlink records the creation of the symbolic link newname to oldname.
+func (d *TargetDryRun) CreateSymlink(oldname string, newname string, overwrite bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	overwritten, err := d.checkOverwrite(newname, overwrite)
+	if err != nil {
+		return err
+	}
+	p := filepath.Clean(newname)
+	d.entries[p] = &memoryFileInfo{name: filepath.Base(p), mode: fs.ModeSymlink | 0777, modTime: time.Now()}
+	d.links[p] = oldname
+	d.record(PlannedOperation{Type: OperationCreateSymlink, Path: newname, Linkname: oldname, Overwrite: overwritten})
+	return nil
+}
+
+// CreateHardlink records the creation of newname as hard link to the oldname file. If the base
+// target does not support hard links, an error, which wraps ErrUnsupportedFile, is returned.
+func (d *TargetDryRun) CreateHardlink(oldname string, newname string, overwrite bool) error {
+	if _, ok := d.base.(HardlinkTarget); d.base != nil && !ok {
+		return unsupportedFile(newname)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	overwritten, err := d.checkOverwrite(newname, overwrite)
+	if err != nil {
+		return err
+	}
+	target, err := d.lstat(oldname)
+	if err != nil {
+		return fmt.Errorf("failed to create hard link: %w", err)
+	}
+	p := filepath.Clean(newname)
+	d.entries[p] = &memoryFileInfo{name: filepath.Base(p), size: target.Size(), mode: target.Mode(), modTime: target.ModTime()}
+	d.record(PlannedOperation{Type: OperationCreateHardlink, Path: newname, Linkname: oldname, Overwrite: overwritten})
+	return nil
+}
+
+// Lstat returns the planned or existing entry at name, without following a symlink.
+func (d *TargetDryRun) Lstat(name string) (fs.FileInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lstat(name)
+}
+
+// Stat returns the planned or existing entry at name and follows planned symlinks.
+func (d *TargetDryRun) Stat(name string) (fs.FileInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stat(name)
+}
+
+// stat follows planned symlinks and returns the planned or existing entry they point to.
+func (d *TargetDryRun) stat(name string) (fs.FileInfo, error) {
+	for range maxDryRunSymlinks {
+		p := filepath.Clean(name)
+		fi, planned := d.entries[p]
+		if !planned {
+			if d.base == nil {
+				return d.lstat(p)
+			}
+			return d.base.Stat(p)
+		}
+		if fi == nil || fi.mode&fs.ModeSymlink == 0 {
+			return d.lstat(p)
+		}
+		target := d.links[p]
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(p), target)
+		}
+		name = target
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: errors.New("too many levels of symbolic links")}
+}
+
+// Chmod records the change of the file mode of name.
+func (d *TargetDryRun) Chmod(name string, mode fs.FileMode) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, err := d.stat(name); err != nil {
+		return err
+	}
+	if fi, ok := d.entries[filepath.Clean(name)]; ok && fi != nil && fi.mode&fs.ModeSymlink == 0 {
+		fi.mode = fi.mode&fs.ModeType | mode.Perm()
+	}
+	d.record(PlannedOperation{Type: OperationChmod, Path: name, Mode: mode.Perm()})
+	return nil
+}
+
+// Chtimes checks that name exists. The change of the file times is not recorded.
+func (d *TargetDryRun) Chtimes(name string, atime, mtime time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, err := d.stat(name)
+	return err
+}
+
+// Lchtimes checks that name exists. The change of the file times is not recorded.
+func (d *TargetDryRun) Lchtimes(name string, atime, mtime time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, err := d.lstat(name)
+	return err
+}
+
+// Chown records the change of the owner and group of name.
+func (d *TargetDryRun) Chown(name string, uid, gid int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, err := d.stat(name); err != nil {
+		return err
+	}
+	d.record(PlannedOperation{Type: OperationChown, Path: name, Uid: uid, Gid: gid})
+	return nil
+}
+
+// Remove records the removal of name, e.g. of a file, which is rejected by the content filter.
+func (d *TargetDryRun) Remove(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, err := d.lstat(name); err != nil {
+		return err
+	}
+	p := filepath.Clean(name)
+	d.entries[p] = nil
+	delete(d.links, p)
+	d.record(PlannedOperation{Type: OperationRemove, Path: name})
+	return nil
+}
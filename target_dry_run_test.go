@@ -0,0 +1,188 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestTargetDryRun(t *testing.T) {
+	archive := packTar(t, []archiveContent{
+		{Name: "dir", Mode: fs.ModeDir | 0755},
+		{Name: "dir/file", Content: []byte("hello world"), Mode: 0640},
+		{Name: "dir/link", Linktarget: "file", Mode: fs.ModeSymlink | 0777},
+		{Name: "dir/hardlink", Linktarget: "dir/file", Hardlink: true, Mode: 0640},
+	})
+	expected := []extract.PlannedOperation{
+		{Type: extract.OperationCreateDir, Path: "dir", Mode: fs.ModeDir | 0755, Uid: -1, Gid: -1},
+		{Type: extract.OperationCreateFile, Path: "dir/file", Mode: 0640, Size: 11, Uid: -1, Gid: -1},
+		{Type: extract.OperationCreateSymlink, Path: "dir/link", Linkname: "file", Uid: -1, Gid: -1},
+		{Type: extract.OperationCreateHardlink, Path: "dir/hardlink", Linkname: "dir/file", Uid: -1, Gid: -1},
+	}
+
+	d := extract.NewTargetDryRun(nil)
+	cfg := extract.NewConfig(extract.WithDropFileAttributes(true))
+	if err := extract.UnpackTo(context.Background(), d, "", bytes.NewReader(archive), cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	plan := d.Plan()
+	if len(plan) != len(expected) {
+		t.Fatalf("expected %d operations, got %d: %v", len(expected), len(plan), plan)
+	}
+	for i := range expected {
+		if plan[i] != expected[i] {
+			t.Errorf("expected operation %d to be %+v, got %+v", i, expected[i], plan[i])
+		}
+	}
+
+	// the planned entries are visible to the security checks
+	if fi, err := d.Stat("dir/link"); err != nil || fi.Size() != 11 {
+		t.Errorf("expected planned symlink to resolve to planned file, got %v, %v", fi, err)
+	}
+
+	// chmod of the file attributes is recorded
+	d = extract.NewTargetDryRun(nil)
+	if err := extract.UnpackTo(context.Background(), d, "", bytes.NewReader(archive), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var chmods int
+	for _, op := range d.Plan() {
+		if op.Type == extract.OperationChmod {
+			chmods++
+		}
+	}
+	if chmods != 2 {
+		t.Errorf("expected 2 chmod operations, got %d: %v", chmods, d.Plan())
+	}
+}
+
+func TestTargetDryRunDisk(t *testing.T) {
+	archive := packTar(t, []archiveContent{
+		{Name: "file", Content: []byte("hello world"), Mode: 0640},
+		{Name: "sub/file", Content: []byte("hello world"), Mode: 0640},
+	})
+
+	tests := []struct {
+		name        string
+		prepare     func(t *testing.T, dst string)
+		cfg         *extract.Config
+		expectError error
+		expectPlan  int
+		overwrites  int
+	}{
+		{
+			name:       "destination is created",
+			cfg:        extract.NewConfig(extract.WithCreateDestination(true)),
+			expectPlan: 6,
+		},
+		{
+			name: "existing file",
+			prepare: func(t *testing.T, dst string) {
+				createTestFile(t, filepath.Join(dst, "file"), "existing")
+			},
+			cfg: extract.NewConfig(extract.WithCreateDestination(true)),
+		},
+		{
+			name: "existing file is overwritten",
+			prepare: func(t *testing.T, dst string) {
+				createTestFile(t, filepath.Join(dst, "file"), "existing")
+			},
+			cfg:        extract.NewConfig(extract.WithCreateDestination(true), extract.WithOverwrite(true)),
+			expectPlan: 5,
+			overwrites: 1,
+		},
+		{
+			name: "symlink in destination",
+			prepare: func(t *testing.T, dst string) {
+				if err := os.MkdirAll(dst, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Symlink(t.TempDir(), filepath.Join(dst, "sub")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			cfg: extract.NewConfig(extract.WithCreateDestination(true), extract.WithOverwrite(true)),
+		},
+		{
+			name:        "max extraction size exceeded",
+			cfg:         extract.NewConfig(extract.WithCreateDestination(true), extract.WithMaxExtractionSize(15)),
+			expectError: extract.ErrMaxExtractionSizeExceeded,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "out")
+			if tc.prepare != nil {
+				tc.prepare(t, dst)
+			}
+			before := listDir(t, dst)
+
+			d := extract.NewTargetDryRun(extract.NewTargetDisk())
+			err := extract.UnpackTo(context.Background(), d, dst, bytes.NewReader(archive), tc.cfg)
+			switch {
+			case tc.expectError != nil && !errors.Is(err, tc.expectError):
+				t.Fatalf("expected error %v, got %v", tc.expectError, err)
+			case tc.expectError == nil && tc.expectPlan == 0 && err == nil:
+				t.Fatalf("expected error, got nil")
+			case tc.expectPlan > 0 && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectPlan > 0 && len(d.Plan()) != tc.expectPlan {
+				t.Errorf("expected %d operations, got %d: %v", tc.expectPlan, len(d.Plan()), d.Plan())
+			}
+
+			var overwrites int
+			for _, op := range d.Plan() {
+				if op.Overwrite {
+					overwrites++
+				}
+			}
+			if overwrites != tc.overwrites {
+				t.Errorf("expected %d overwrites, got %d", tc.overwrites, overwrites)
+			}
+
+			// nothing is written
+			if after := listDir(t, dst); after != before {
+				t.Errorf("expected destination to be unchanged, got %q, expected %q", after, before)
+			}
+		})
+	}
+}
+
+// createTestFile creates the file name with content and its parent directories.
+func createTestFile(t *testing.T, name string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// listDir returns the names of all entries below dir, or an empty string if dir does not exist.
+func listDir(t *testing.T, dir string) string {
+	t.Helper()
+	var names string
+	err := filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		names += path + "\n"
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal(err)
+	}
+	return names
+}
@@ -214,6 +214,88 @@ func (m *TargetMemory) CreateSymlink(oldName string, newName string, overwrite b
 	}
 }
 
+// CreateHardlink creates a new hard link in the in-memory filesystem. The hard link shares the
+// content of the regular file at oldName. If the overwrite flag is set to false and newName already
+// exists, an error is returned. If the overwrite flag is set to true, the entry is overwritten,
+// unless it is the entry at oldName. If the hard link is created successfully, nil is returned.
+func (m *TargetMemory) CreateHardlink(oldName string, newName string, overwrite bool) error {
+	if !fs.ValidPath(oldName) {
+		return &fs.PathError{Op: "CreateHardlink", Path: oldName, Err: fs.ErrInvalid}
+	}
+	if !fs.ValidPath(newName) {
+		return &fs.PathError{Op: "CreateHardlink", Path: newName, Err: fs.ErrInvalid}
+	}
+
+	// get link target, which must be a regular file
+	oldMe, err := m.resolveEntry(oldName)
+	if err != nil {
+		return &fs.PathError{Op: "CreateHardlink", Path: oldName, Err: err}
+	}
+	if !oldMe.fileInfo.Mode().IsRegular() {
+		return &fs.PathError{Op: "CreateHardlink", Path: oldName, Err: fs.ErrInvalid}
+	}
+
+	// get real path
+	dir, name := p.Split(newName)
+	dir = p.Clean(dir)
+	realDir, err := m.resolvePath(dir)
+	if err != nil {
+		return &fs.PathError{Op: "CreateHardlink", Path: newName, Err: err}
+	}
+
+	// verify that realDir is a directory
+	realDirMe, err := m.resolveEntry(realDir)
+	if err != nil {
+		return &fs.PathError{Op: "CreateHardlink", Path: newName, Err: err}
+	}
+	if !realDirMe.fileInfo.Mode().IsDir() {
+		return &fs.PathError{Op: "CreateHardlink", Path: newName, Err: fs.ErrInvalid}
+	}
+	realPath := p.Join(realDir, name)
+
+	// handle existing entry
+	if e, ok := m.files.Load(realPath); ok {
+		switch {
+
+		// directories cannot be overwritten
+		case e.(*memoryEntry).fileInfo.Mode().IsDir():
+			return &fs.PathError{Op: "CreateHardlink", Path: newName, Err: fs.ErrExist}
+
+		// error, if entry already exists
+		case !overwrite:
+			return &fs.PathError{Op: "CreateHardlink", Path: newName, Err: fs.ErrExist}
+
+		// keep the link target, which must not be removed
+		case e.(*memoryEntry) == oldMe:
+			return nil
+
+		// remove existing entry
+		default:
+			if err := m.Remove(realPath); err != nil {
+				return &fs.PathError{Op: "CreateHardlink", Path: newName, Err: err}
+			}
+		}
+	}
+
+	// create entry that shares the data of the link target
+	oldMe.lock.RLock()
+	defer oldMe.lock.RUnlock()
+	mfi := oldMe.fileInfo.(*memoryFileInfo)
+	m.files.Store(realPath, &memoryEntry{
+		fileInfo: &memoryFileInfo{
+			name:       name,
+			size:       mfi.size,
+			mode:       mfi.mode,
+			accessTime: mfi.accessTime,
+			modTime:    mfi.modTime,
+			gid:        mfi.gid,
+			uid:        mfi.uid,
+		},
+		data: oldMe.data,
+	})
+	return nil
+}
+
 // Open implements the [io/fs.FS] interface. It opens the file at the given path.
 // If the file does not exist, an error is returned. If the file is a directory,
 // an error is returned. If the file is a symlink, the target of the symlink is returned.
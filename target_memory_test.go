@@ -841,6 +841,72 @@ func TestCreateSymlink(t *testing.T) {
 	}
 }
 
+// TestCreateHardlink tests the CreateHardlink method
+func TestCreateHardlink(t *testing.T) {
+	// instantiate a new memory
+	tm := extract.NewTargetMemory()
+
+	// test data
+	testPath := "test"
+	testLink := "link"
+	testContent := "test"
+	testPerm := 0644
+
+	// create a file
+	if _, err := tm.CreateFile(testPath, bytes.NewReader([]byte(testContent)), fs.FileMode(testPerm), false, -1); err != nil {
+		t.Fatalf("CreateFile() failed: %s", err)
+	}
+
+	// create a hard link
+	if err := tm.CreateHardlink(testPath, testLink, false); err != nil {
+		t.Fatalf("CreateHardlink() failed: %s", err)
+	}
+
+	// lstat the hard link, which must be a regular file
+	stat, err := tm.Lstat(testLink)
+	if err != nil {
+		t.Fatalf("Lstat() failed: %s", err)
+	}
+	if !stat.Mode().IsRegular() {
+		t.Fatalf("Lstat() returned unexpected mode: expected regular file, got %s", stat.Mode())
+	}
+	if stat.Name() != testLink {
+		t.Fatalf("Name() returned unexpected value: expected %s, got %s", testLink, stat.Name())
+	}
+
+	// read the hard link
+	data, err := tm.ReadFile(testLink)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %s", err)
+	}
+	if !bytes.Equal(data, []byte(testContent)) {
+		t.Fatalf("unexpected file contents: expected %s, got %s", testContent, data)
+	}
+
+	// overwrite the hard link, but fail
+	if err := tm.CreateHardlink(testPath, testLink, false); err == nil {
+		t.Fatalf("CreateHardlink() failed: expected error, got nil")
+	}
+
+	// overwrite the hard link
+	if err := tm.CreateHardlink(testPath, testLink, true); err != nil {
+		t.Fatalf("CreateHardlink() failed: %s", err)
+	}
+
+	// create a hard link to a file that does not exist
+	if err := tm.CreateHardlink("does-not-exist", "other", false); err == nil {
+		t.Fatalf("CreateHardlink() failed: expected error, got nil")
+	}
+
+	// create a hard link to a directory
+	if err := tm.CreateDir("dir", 0755); err != nil {
+		t.Fatalf("CreateDir() failed: %s", err)
+	}
+	if err := tm.CreateHardlink("dir", "other", false); err == nil {
+		t.Fatalf("CreateHardlink() failed: expected error, got nil")
+	}
+}
+
 func TestUnpackToMemoryWithPreserveFileAttributesAndOwner(t *testing.T) {
 	type ownershipAccessor interface {
 		Uid() int
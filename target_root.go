@@ -0,0 +1,195 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// TargetRoot is a [Target] that performs all operations relative to a destination directory,
+// which is opened once as an [os.Root]. In contrast to [TargetDisk], containment is not only
+// enforced by checks before each operation, but also by the operating system: all paths are
+// resolved beneath the root directory (e.g. with openat2 and RESOLVE_BENEATH on Linux), so a
+// directory, which is swapped for a symlink during the extraction, cannot be used to escape
+// the destination.
+//
+// All paths passed to the methods of TargetRoot are relative to the root directory. Hence, the
+// destination passed to [UnpackTo] should be empty or a relative path inside the root.
+type TargetRoot struct {
+	root *os.Root
+}
+
+// NewTargetRoot opens the directory dir as root for a new TargetRoot. The directory must exist.
+// The returned TargetRoot should be closed with [TargetRoot.Close] after the extraction.
+func NewTargetRoot(dir string) (*TargetRoot, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open root: %w", err)
+	}
+	return &TargetRoot{root: root}, nil
+}
+
+// Name returns the name of the root directory.
+func (r *TargetRoot) Name() string {
+	return r.root.Name()
+}
+
+// Close closes the root directory.
+func (r *TargetRoot) Close() error {
+	return r.root.Close()
+}
+
+// CreateDir creates a directory at the specified path with the specified mode. If the directory already
+// exists, nothing is done.
+func (r *TargetRoot) CreateDir(path string, mode fs.FileMode) error {
+	if err := r.root.MkdirAll(path, mode.Perm()); err != nil {
+		return fmt.Errorf("failed to create directory (%w)", err)
+	}
+	return nil
+}
+
+// CreateFile creates a file at the specified path with src as content.
+// The mode parameter is the file mode that should be set on the file. If the file already exists and
+// overwrite is false, an error should be returned. If the file does not exist, it should be created.
+// The size of the file should not exceed maxSize. If the file is created successfully, the number of bytes written
+// should be returned. If an error occurs, the number of bytes written should be returned along with the error.
+// If maxSize < 0, the file size is not limited.
+func (r *TargetRoot) CreateFile(path string, src io.Reader, mode fs.FileMode, overwrite bool, maxSize int64) (int64, error) {
+	// Check for path validity and if file existence+overwrite
+	if _, err := r.root.Lstat(path); !errors.Is(err, fs.ErrNotExist) {
+
+		// something wrong with path
+		if err != nil {
+			return 0, fmt.Errorf("invalid path: %w", err)
+		}
+
+		// check for overwrite
+		if !overwrite {
+			return 0, fmt.Errorf("file already exists")
+		}
+	}
+
+	// create dst file
+	dstFile, err := r.root.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		dstFile.Close()
+	}()
+
+	// write data to file
+	writer := limitWriter(dstFile, maxSize)
+	n, err := io.Copy(writer, src)
+	if err != nil {
+		return n, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return n, err
+}
+
+// CreateSymlink creates a symbolic link from newname to oldname. If
+// newname already exists and overwrite is false, an error should be returned.
+func (r *TargetRoot) CreateSymlink(oldname string, newname string, overwrite bool) error {
+
+	// Check for file existence and if it should be overwritten
+	if _, err := r.root.Lstat(newname); !errors.Is(err, fs.ErrNotExist) {
+		if !overwrite {
+			return fmt.Errorf("file already exist")
+		}
+
+		// delete existing link
+		if err := r.root.Remove(newname); err != nil {
+			return fmt.Errorf("failed to overwrite file: %w", err)
+		}
+	}
+
+	// create link
+	if err := r.root.Symlink(oldname, newname); err != nil {
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+
+	return nil
+}
+
+// CreateHardlink creates newname as a hard link to the oldname file. If
+// newname already exists and overwrite is false, an error should be returned.
+// If newname is already the oldname file, nothing is done.
+func (r *TargetRoot) CreateHardlink(oldname string, newname string, overwrite bool) error {
+
+	// Check for file existence and if it should be overwritten
+	if stat, err := r.root.Lstat(newname); !errors.Is(err, fs.ErrNotExist) {
+		if !overwrite {
+			return fmt.Errorf("file already exist")
+		}
+
+		// keep the link target, which must not be removed
+		if old, err := r.root.Lstat(oldname); err == nil && stat != nil && os.SameFile(old, stat) {
+			return nil
+		}
+
+		// delete existing entry
+		if err := r.root.Remove(newname); err != nil {
+			return fmt.Errorf("failed to overwrite file: %w", err)
+		}
+	}
+
+	// create link
+	if err := r.root.Link(oldname, newname); err != nil {
+		return fmt.Errorf("failed to create hard link: %w", err)
+	}
+
+	return nil
+}
+
+// Lstat returns the FileInfo structure describing the named file.
+// If there is an error, it will be of type *PathError.
+func (r *TargetRoot) Lstat(name string) (fs.FileInfo, error) {
+	return r.root.Lstat(name)
+}
+
+// Stat returns the FileInfo structure describing the named file.
+// If there is an error, it will be of type *PathError.
+func (r *TargetRoot) Stat(name string) (fs.FileInfo, error) {
+	return r.root.Stat(name)
+}
+
+// Chmod changes the mode of the named file to mode.
+func (r *TargetRoot) Chmod(name string, mode fs.FileMode) error {
+	return r.root.Chmod(name, mode.Perm())
+}
+
+// Chtimes changes the access and modification times of the named file.
+func (r *TargetRoot) Chtimes(name string, atime, mtime time.Time) error {
+	return r.root.Chtimes(name, atime, mtime)
+}
+
+// Lchtimes changes the access and modification times of the named file. [os.Root] provides no
+// way to change the timestamps of a symlink itself, so symlink timestamps are not maintained.
+func (r *TargetRoot) Lchtimes(name string, atime, mtime time.Time) error {
+	return nil
+}
+
+// Chown changes the numeric uid and gid of the named file.
+func (r *TargetRoot) Chown(name string, uid, gid int) error {
+	if err := r.root.Lchown(name, uid, gid); err != nil {
+		return fmt.Errorf("chown failed: %w", err)
+	}
+	return nil
+}
+
+// Remove removes the named file or empty directory.
+func (r *TargetRoot) Remove(name string) error {
+	return r.root.Remove(name)
+}
+
+// Rename renames (moves) oldpath to newpath.
+func (r *TargetRoot) Rename(oldpath, newpath string) error {
+	return r.root.Rename(oldpath, newpath)
+}
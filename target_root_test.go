@@ -0,0 +1,127 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestTargetRoot(t *testing.T) {
+	archive := packTar(t, []archiveContent{
+		{Name: "dir", Mode: fs.ModeDir | 0755},
+		{Name: "dir/file", Content: []byte("hello world"), Mode: 0640, ModTime: time.Unix(1700000000, 0)},
+		{Name: "dir/link", Linktarget: "file", Mode: fs.ModeSymlink | 0777},
+		{Name: "dir/hardlink", Linktarget: "dir/file", Hardlink: true, Mode: 0640},
+	})
+
+	tests := []struct {
+		name      string
+		dst       string
+		expectErr bool
+	}{
+		{name: "root", dst: ""},
+		{name: "sub directory", dst: "sub"},
+		{name: "outside of root", dst: "..", expectErr: true},
+		{name: "absolute path", dst: os.TempDir(), expectErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tr, err := extract.NewTargetRoot(dir)
+			if err != nil {
+				t.Fatalf("failed to create target root: %v", err)
+			}
+			defer tr.Close()
+
+			cfg := extract.NewConfig(extract.WithCreateDestination(true))
+			err = extract.UnpackTo(context.Background(), tr, tc.dst, bytes.NewReader(archive), cfg)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, name := range []string{"dir/file", "dir/link", "dir/hardlink"} {
+				data, err := os.ReadFile(filepath.Join(dir, tc.dst, name))
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", name, err)
+				}
+				if string(data) != "hello world" {
+					t.Errorf("expected %s to contain %q, got %q", name, "hello world", data)
+				}
+			}
+			stat, err := os.Stat(filepath.Join(dir, tc.dst, "dir/file"))
+			if err != nil {
+				t.Fatalf("failed to stat file: %v", err)
+			}
+			if stat.Mode().Perm() != 0640 {
+				t.Errorf("expected mode %v, got %v", fs.FileMode(0640), stat.Mode().Perm())
+			}
+			if !stat.ModTime().Equal(time.Unix(1700000000, 0)) {
+				t.Errorf("expected modification time %v, got %v", time.Unix(1700000000, 0), stat.ModTime())
+			}
+		})
+	}
+}
+
+func TestTargetRootContainment(t *testing.T) {
+	outside := t.TempDir()
+	dir := t.TempDir()
+
+	// simulate a directory, which is swapped for a symlink after the security checks
+	if err := os.Symlink(outside, filepath.Join(dir, "swapped")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	tr, err := extract.NewTargetRoot(dir)
+	if err != nil {
+		t.Fatalf("failed to create target root: %v", err)
+	}
+	defer tr.Close()
+
+	if _, err := tr.CreateFile("swapped/file", strings.NewReader("escaped"), 0644, false, -1); err == nil {
+		t.Errorf("expected error when creating a file through a symlink to the outside")
+	}
+	if err := tr.CreateDir("swapped/dir", 0755); err == nil {
+		t.Errorf("expected error when creating a directory through a symlink to the outside")
+	}
+	if err := tr.CreateSymlink("target", "swapped/link", false); err == nil {
+		t.Errorf("expected error when creating a symlink through a symlink to the outside")
+	}
+	if err := tr.Chmod("swapped", 0777); err == nil {
+		t.Errorf("expected error when changing the mode through a symlink to the outside")
+	}
+	if _, err := tr.CreateFile("../file", strings.NewReader("escaped"), 0644, false, -1); err == nil {
+		t.Errorf("expected error when creating a file outside of the root")
+	}
+
+	entries, err := os.ReadDir(outside)
+	if err != nil {
+		t.Fatalf("failed to read outside directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected outside directory to be empty, got %d entries", len(entries))
+	}
+	stat, err := os.Stat(outside)
+	if err != nil {
+		t.Fatalf("failed to stat outside directory: %v", err)
+	}
+	if stat.Mode().Perm() == 0777 {
+		t.Errorf("expected mode of outside directory to be unchanged")
+	}
+}
@@ -11,6 +11,12 @@ import (
 
 // TelemetryData holds all telemetry data of an extraction.
 type TelemetryData struct {
+	// EntryHookRenames is the number of entries, which are renamed by the entry hook
+	EntryHookRenames int64 `json:"entry_hook_renames"`
+
+	// EntryHookSkips is the number of entries, which are skipped by the entry hook
+	EntryHookSkips int64 `json:"entry_hook_skips"`
+
 	// ExtractedDirs is the number of extracted directories
 	ExtractedDirs int64 `json:"extracted_dirs"`
 
@@ -26,6 +32,9 @@ type TelemetryData struct {
 	// ExtractionSize is the size of the extracted files
 	ExtractionSize int64 `json:"extraction_size"`
 
+	// ExtractedHardlinks is the number of extracted hard links
+	ExtractedHardlinks int64 `json:"extracted_hardlinks"`
+
 	// ExtractedSymlinks is the number of extracted symlinks
 	ExtractedSymlinks int64 `json:"extracted_symlinks"`
 
@@ -38,7 +47,21 @@ type TelemetryData struct {
 	// LastExtractionError is the last error during extraction
 	LastExtractionError error `json:"last_extraction_error"`
 
-	// PatternMismatches is the number of skipped files
+	// Levels holds the telemetry data per level of a recursive extraction, starting with the
+	// archive itself. The other fields sum up the telemetry data of all levels.
+	Levels []LevelTelemetryData `json:"levels,omitempty"`
+
+	// Manifest holds the path, size, mode and digests of each extracted file, if digests are
+	// configured with [WithDigests]
+	Manifest []ManifestEntry `json:"manifest,omitempty"`
+
+	// PatternExcludes is the number of files, which are skipped, because they match an exclude pattern
+	PatternExcludes int64 `json:"pattern_excludes"`
+
+	// PatternIncludes is the number of files, which match the include patterns and no exclude pattern
+	PatternIncludes int64 `json:"pattern_includes"`
+
+	// PatternMismatches is the number of files, which are skipped, because they match no include pattern
 	PatternMismatches int64 `json:"pattern_mismatches"`
 
 	// UnsupportedFiles is the number of skipped unsupported files
@@ -48,6 +71,30 @@ type TelemetryData struct {
 	LastUnsupportedFile string `json:"last_unsupported_file"`
 }
 
+// LevelTelemetryData holds the telemetry data of one level of a recursive extraction.
+type LevelTelemetryData struct {
+	// Archives is the number of archives on this level
+	Archives int64 `json:"archives"`
+
+	// ExtractedDirs is the number of extracted directories
+	ExtractedDirs int64 `json:"extracted_dirs"`
+
+	// ExtractionErrors is the number of errors during extraction
+	ExtractionErrors int64 `json:"extraction_errors"`
+
+	// ExtractedFiles is the number of extracted files
+	ExtractedFiles int64 `json:"extracted_files"`
+
+	// ExtractionSize is the size of the extracted files
+	ExtractionSize int64 `json:"extraction_size"`
+
+	// ExtractedHardlinks is the number of extracted hard links
+	ExtractedHardlinks int64 `json:"extracted_hardlinks"`
+
+	// ExtractedSymlinks is the number of extracted symlinks
+	ExtractedSymlinks int64 `json:"extracted_symlinks"`
+}
+
 // String returns a string representation of [TelemetryData].
 func (m TelemetryData) String() string {
 	b, _ := json.Marshal(m)
@@ -24,6 +24,12 @@ var (
 	// ErrFailedToExtract is returned when the file cannot be extracted.
 	ErrFailedToUnpack = fmt.Errorf("extract: failed to unpack")
 
+	// ErrFailedToList is returned when the entries of the archive cannot be listed.
+	ErrFailedToList = fmt.Errorf("extract: failed to list")
+
+	// ErrUnsafeEntryName indicates that the name or link target of an entry would escape the destination.
+	ErrUnsafeEntryName = fmt.Errorf("extract: unsafe entry name")
+
 	// ErrUnsupportedFile is an error that indicates that the file is not supported.
 	ErrUnsupportedFile = fmt.Errorf("extract: unsupported file")
 
@@ -32,6 +38,55 @@ var (
 
 	// ErrMaxExtractionSizeExceeded indicates that the maximum size is exceeded.
 	ErrMaxExtractionSizeExceeded = fmt.Errorf("extract: maximum extraction size exceeded")
+
+	// ErrCompressionRatioExceeded indicates that the maximum compression ratio is exceeded.
+	ErrCompressionRatioExceeded = fmt.Errorf("extract: maximum compression ratio exceeded")
+
+	// ErrZipOverlappingEntries indicates that entries of a zip archive overlap each other or the central directory.
+	ErrZipOverlappingEntries = fmt.Errorf("extract: overlapping zip entries")
+
+	// ErrZipDuplicateEntry indicates that a name is used by more than one entry of a zip archive.
+	ErrZipDuplicateEntry = fmt.Errorf("extract: duplicate zip entry")
+
+	// ErrZipNameMismatch indicates that the name of a zip entry differs between the local file header and the central directory.
+	ErrZipNameMismatch = fmt.Errorf("extract: zip entry name mismatch")
+
+	// ErrZipSizeMismatch indicates that the size of a zip entry differs between the local file header, the central directory
+	// and the actual content.
+	ErrZipSizeMismatch = fmt.Errorf("extract: zip entry size mismatch")
+
+	// ErrPasswordRequired indicates that an entry is encrypted, but no password is configured.
+	ErrPasswordRequired = fmt.Errorf("extract: password required")
+
+	// ErrWrongPassword indicates that an entry cannot be decrypted with the configured password.
+	ErrWrongPassword = fmt.Errorf("extract: wrong password")
+
+	// ErrAbortedByEntryHook indicates that the extraction is aborted by the entry hook.
+	ErrAbortedByEntryHook = fmt.Errorf("extract: aborted by entry hook")
+
+	// ErrAtomicExtractionUnsupported indicates that the target cannot roll back a failed atomic extraction.
+	ErrAtomicExtractionUnsupported = fmt.Errorf("extract: atomic extraction not supported by target")
+
+	// ErrContentRejected indicates that the content of a file is rejected by the content filter.
+	ErrContentRejected = fmt.Errorf("extract: content rejected")
+
+	// ErrUnsupportedDigest indicates that a digest algorithm is not supported.
+	ErrUnsupportedDigest = fmt.Errorf("extract: unsupported digest algorithm")
+
+	// ErrDigestMismatch indicates that the digest of an extracted file does not match the expected digest.
+	ErrDigestMismatch = fmt.Errorf("extract: digest mismatch")
+
+	// ErrUnexpectedFile indicates that a file without an expected digest is extracted.
+	ErrUnexpectedFile = fmt.Errorf("extract: unexpected file")
+
+	// ErrExpectedFileMissing indicates that a file with an expected digest is not extracted.
+	ErrExpectedFileMissing = fmt.Errorf("extract: expected file missing")
+
+	// ErrSignatureVerificationFailed indicates that the signature of the archive cannot be verified.
+	ErrSignatureVerificationFailed = fmt.Errorf("extract: signature verification failed")
+
+	// ErrCorruptData indicates that the checksum or authentication code of an entry does not match.
+	ErrCorruptData = fmt.Errorf("extract: corrupt data")
 )
 
 // Unpack unpacks the given source to the destination, according to the given configuration,
@@ -48,8 +103,34 @@ func UnpackTo(ctx context.Context, t Target, dst string, src io.Reader, cfg *Con
 	if cfg == nil {
 		cfg = NewConfig()
 	}
+	return unpackAtomic(t, dst, cfg, func(t Target, dst string, cfg *Config) error {
+		return unpackTo(ctx, t, dst, src, cfg)
+	})
+}
+
+// unpackTo unpacks src to dst on t, according to cfg.
+func unpackTo(ctx context.Context, t Target, dst string, src io.Reader, cfg *Config) error {
+	var name string
+	if f, ok := src.(*os.File); ok {
+		name = filepath.Ext(f.Name())
+	}
+
+	// verify the signature, before anything is extracted
+	if cfg.SignatureVerifier() != nil {
+		verified, cleanup, err := verifySignature(ctx, cfg, src)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		src = verified
+	}
+
+	available, err := cfg.extractors()
+	if err != nil {
+		return err
+	}
 	if et := cfg.ExtractType(); len(et) > 0 {
-		if ae, found := availableExtractors[et]; found {
+		if ae, found := available[et]; found {
 			if et == fileExtensionTarGZip {
 				cfg.SetNoUntarAfterDecompression(false)
 			}
@@ -61,20 +142,15 @@ func UnpackTo(ctx context.Context, t Target, dst string, src io.Reader, cfg *Con
 			return nil
 		}
 
-		return fmt.Errorf("%w: %q not in %q", ErrUnsupportedFileType, et, availableExtractors.Extensions())
+		return fmt.Errorf("%w: %q not in %q", ErrUnsupportedFileType, et, available.Extensions())
 	}
 
-	header, reader, err := getHeader(src)
+	header, reader, err := getHeader(src, available)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrFailedToReadHeader, err)
 	}
 
-	var name string
-	if f, ok := src.(*os.File); ok {
-		name = filepath.Ext(f.Name())
-	}
-
-	unpacker := availableExtractors.GetUnpackFunction(header, name)
+	unpacker := available.GetUnpackFunction(header, name)
 	if unpacker != nil {
 		err := unpacker(ctx, t, dst, reader, cfg)
 		if err != nil {
@@ -86,7 +162,22 @@ func UnpackTo(ctx context.Context, t Target, dst string, src io.Reader, cfg *Con
 	return ErrNoExtractorFound
 }
 
-// HasKnownArchiveExtension returns true if the given name has a known archive extension.
+// HasKnownArchiveExtension returns true if the given name has a known archive extension of a
+// built-in or globally registered format. Use [Config.HasKnownArchiveExtension] to include the
+// formats registered with [WithFormat].
 func HasKnownArchiveExtension(name string) bool {
+	extractorsMutex.RLock()
+	defer extractorsMutex.RUnlock()
 	return availableExtractors.GetUnpackFunctionByFileName(name) != nil
 }
+
+// HasKnownArchiveExtension returns true if the given name has a known archive extension of a
+// format, which is available for the configuration, including the formats registered with
+// [WithFormat]. If a format of the configuration is invalid, false is returned.
+func (c *Config) HasKnownArchiveExtension(name string) bool {
+	e, err := c.extractors()
+	if err != nil {
+		return false
+	}
+	return e.GetUnpackFunctionByFileName(name) != nil
+}
@@ -526,6 +526,43 @@ func TestUnpackMaliciousArchive(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "hard link to file in archive",
+			entries: []archiveContent{
+				{Name: "test", Mode: 0640, Content: []byte("foobar content")},
+				{Name: "sub/link", Mode: 0640, Hardlink: true, Linktarget: "test"},
+			},
+		},
+		{
+			name: "hard link with path traversal in target",
+			entries: []archiveContent{
+				{Name: "link", Mode: 0640, Hardlink: true, Linktarget: "../escaped"},
+			},
+			expectError: true,
+		},
+		{
+			name: "hard link with absolute path as target",
+			entries: []archiveContent{
+				{Name: "link", Mode: 0640, Hardlink: true, Linktarget: "/etc/passwd"},
+			},
+			expectError: runtime.GOOS != "windows", // on windows, this is not an absolute path
+		},
+		{
+			name: "hard link to target behind symlink",
+			entries: []archiveContent{
+				{Name: "sub", Mode: fs.ModeSymlink | 0755, Linktarget: "."},
+				{Name: "test", Mode: 0640, Content: []byte("foobar content")},
+				{Name: "link", Mode: 0640, Hardlink: true, Linktarget: "sub/test"},
+			},
+			expectError: true,
+		},
+		{
+			name: "hard link to missing target",
+			entries: []archiveContent{
+				{Name: "link", Mode: 0640, Hardlink: true, Linktarget: "does-not-exist"},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -553,6 +590,97 @@ func TestUnpackMaliciousArchive(t *testing.T) {
 	}
 }
 
+// TestUnpackHardlinkToItself tests that a hard link, which points to itself, does not remove
+// the already extracted file, when it is overwritten.
+func TestUnpackHardlinkToItself(t *testing.T) {
+	for _, linkTarget := range []string{"test", "./test"} {
+		t.Run(linkTarget, func(t *testing.T) {
+			entries := []archiveContent{
+				{Name: "test", Mode: 0640, Content: []byte("foobar content")},
+				{Name: "test", Mode: 0640, Hardlink: true, Linktarget: linkTarget},
+			}
+
+			// disk
+			dst := t.TempDir()
+			cfg := extract.NewConfig(extract.WithOverwrite(true))
+			if err := extract.Unpack(context.Background(), dst, asIoReader(t, packTar(t, entries)), cfg); err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			if data, err := os.ReadFile(filepath.Join(dst, "test")); err != nil || string(data) != "foobar content" {
+				t.Errorf("expected original content, got %q, %v", data, err)
+			}
+
+			// memory
+			tm := extract.NewTargetMemory()
+			if err := extract.UnpackTo(context.Background(), tm, "", asIoReader(t, packTar(t, entries)), cfg); err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			checkMemoryContent(t, tm, map[string]string{"test": "foobar content"})
+		})
+	}
+
+	// targets keep the link target, if it is overwritten by a link to itself
+	path := filepath.Join(t.TempDir(), "test")
+	createTestFile(t, path, "foobar content")
+	if err := extract.NewTargetDisk().CreateHardlink(path, path, true); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "foobar content" {
+		t.Errorf("expected original content, got %q, %v", data, err)
+	}
+	tm := extract.NewTargetMemory()
+	if _, err := tm.CreateFile("test", strings.NewReader("foobar content"), 0640, false, -1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := tm.CreateHardlink("test", "test", true); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	checkMemoryContent(t, tm, map[string]string{"test": "foobar content"})
+}
+
+// TestUnpackHardlinkUnsupportedTarget tests that hard links are unsupported files for targets,
+// which cannot create hard links.
+func TestUnpackHardlinkUnsupportedTarget(t *testing.T) {
+	entries := []archiveContent{
+		{Name: "test", Mode: 0640, Content: []byte("foobar content")},
+		{Name: "link", Mode: 0640, Hardlink: true, Linktarget: "test"},
+	}
+
+	// the built-in targets support hard links
+	for _, target := range []extract.Target{extract.NewTargetDisk(), extract.NewTargetMemory(), extract.NewTargetDryRun(nil)} {
+		if _, ok := target.(extract.HardlinkTarget); !ok {
+			t.Errorf("expected %T to implement HardlinkTarget", target)
+		}
+	}
+
+	// the embedded interface hides the CreateHardlink method of the memory target
+	tm := extract.NewTargetMemory()
+	target := struct{ extract.Target }{tm}
+
+	err := extract.UnpackTo(context.Background(), target, "", asIoReader(t, packTar(t, entries)), extract.NewConfig())
+	if !errors.Is(err, extract.ErrUnsupportedFile) {
+		t.Fatalf("expected error %v, got %v", extract.ErrUnsupportedFile, err)
+	}
+
+	// unsupported files can be skipped
+	tm = extract.NewTargetMemory()
+	target = struct{ extract.Target }{tm}
+	td := &extract.TelemetryData{}
+	cfg := extract.NewConfig(
+		extract.WithContinueOnUnsupportedFiles(true),
+		extract.WithTelemetryHook(func(_ context.Context, d *extract.TelemetryData) { *td = *d }),
+	)
+	if err := extract.UnpackTo(context.Background(), target, "", asIoReader(t, packTar(t, entries)), cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if td.UnsupportedFiles != 1 || td.LastUnsupportedFile != "link" {
+		t.Errorf("expected hard link to be skipped as unsupported file, got %d, %q", td.UnsupportedFiles, td.LastUnsupportedFile)
+	}
+	if _, err := tm.Stat("link"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected hard link not to be created, got %v", err)
+	}
+}
+
 // TestZipUnpackIllegalNames tests, with various cases, the implementation of zip.Unpack
 func TestUnpackWithIllegalNames(t *testing.T) {
 
@@ -623,6 +751,9 @@ func TestUnpackWithConfig(t *testing.T) {
 		testArchive []archiveContent
 		dst         string
 		expectError bool
+
+		// expectZipError is set, if only the zip archive is rejected, e.g. for duplicate names
+		expectZipError bool
 	}{
 		{
 			name: "unpack normal",
@@ -709,8 +840,9 @@ func TestUnpackWithConfig(t *testing.T) {
 				{Name: "test", Content: []byte("hello world"), Mode: 0644},
 				{Name: "test", Content: []byte("hello world"), Mode: 0644},
 			},
-			cfg:         extract.NewConfig(extract.WithOverwrite(true)),
-			expectError: false,
+			cfg:            extract.NewConfig(extract.WithOverwrite(true)),
+			expectError:    false,
+			expectZipError: true,
 		},
 		{
 			name: "unpack with overwrite enabled (symlink)",
@@ -719,8 +851,9 @@ func TestUnpackWithConfig(t *testing.T) {
 				{Name: "link", Mode: fs.ModeSymlink | 0755, Linktarget: "test"},
 				{Name: "link", Mode: fs.ModeSymlink | 0755, Linktarget: "test"},
 			},
-			cfg:         extract.NewConfig(extract.WithOverwrite(true)),
-			expectError: false,
+			cfg:            extract.NewConfig(extract.WithOverwrite(true)),
+			expectError:    false,
+			expectZipError: true,
 		},
 		{
 			name: "traverse symlink disabled",
@@ -777,10 +910,11 @@ func TestUnpackWithConfig(t *testing.T) {
 					cfg = tc.cfg
 				)
 				err := extract.Unpack(ctx, dst, src, cfg)
-				if tc.expectError && err == nil {
+				expectError := tc.expectError || (tc.expectZipError && p.name == "zip")
+				if expectError && err == nil {
 					t.Fatalf("expected error, got nil")
 				}
-				if !tc.expectError && err != nil {
+				if !expectError && err != nil {
 					t.Fatalf("unexpected error: %v", err)
 				}
 			})
@@ -1018,6 +1152,18 @@ func TestTelemetryHook(t *testing.T) {
 				ExtractedType:    "tar",
 			},
 		},
+		{
+			name: "normal tar with hard link",
+			archive: packTar(t, append(oneFile, archiveContent{
+				Name: "link", Mode: 0644, Hardlink: true, Linktarget: "test",
+			})),
+			expectedTelemetryData: &extract.TelemetryData{
+				ExtractedFiles:     1,
+				ExtractedHardlinks: 1,
+				ExtractionSize:     1024,
+				ExtractedType:      "tar",
+			},
+		},
 		{
 			name:    "normal zip file",
 			archive: packZip(t, oneFile),
@@ -1051,6 +1197,7 @@ func TestTelemetryHook(t *testing.T) {
 			td.ExtractionErrors == other.ExtractionErrors &&
 			td.ExtractedFiles == other.ExtractedFiles &&
 			td.ExtractionSize == other.ExtractionSize &&
+			td.ExtractedHardlinks == other.ExtractedHardlinks &&
 			td.ExtractedSymlinks == other.ExtractedSymlinks &&
 			td.ExtractedType == other.ExtractedType &&
 			td.PatternMismatches == other.PatternMismatches &&
@@ -1431,6 +1578,7 @@ type archiveContent struct {
 	Name       string
 	Content    []byte
 	Linktarget string
+	Hardlink   bool
 	Mode       fs.FileMode
 	AccessTime time.Time
 	ModTime    time.Time
@@ -1446,6 +1594,8 @@ func packTar(t *testing.T, content []archiveContent) []byte {
 	for _, c := range content {
 		var tFlag byte
 		switch {
+		case c.Hardlink:
+			tFlag = tar.TypeLink
 		case c.Mode.IsDir():
 			tFlag = tar.TypeDir
 		case c.Mode&fs.ModeSymlink != 0:
@@ -1463,10 +1613,14 @@ func packTar(t *testing.T, content []archiveContent) []byte {
 		default:
 			t.Fatalf("unsupported file mode: %v", c.Mode)
 		}
+		size := int64(len(c.Content))
+		if c.Hardlink {
+			size = 0
+		}
 		header := &tar.Header{
 			Name:     c.Name,
 			Mode:     int64(c.Mode & fs.ModePerm),
-			Size:     int64(len(c.Content)),
+			Size:     size,
 			Linkname: c.Linktarget,
 			Typeflag: tFlag,
 		}
@@ -1488,7 +1642,7 @@ func packTar(t *testing.T, content []archiveContent) []byte {
 		if err := w.WriteHeader(header); err != nil {
 			t.Fatalf("error writing tar header: %v", err)
 		}
-		if !c.Mode.IsRegular() {
+		if !c.Mode.IsRegular() || c.Hardlink {
 			continue
 		}
 		if _, err := w.Write(c.Content); err != nil {
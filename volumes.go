@@ -0,0 +1,561 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/nwaples/rardecode/v2"
+)
+
+// UnpackVolumes unpacks the multi-volume archive, which consists of the given volumes in
+// their order, to the destination, according to the given configuration, using the given
+// [Target]. Multi-volume Rar archives, split 7zip archives and split or spanned zip archives
+// are supported, as well as all other formats, which volumes are plain parts of the archive,
+// e.g. a split tar.gz. The maximum input size applies to the combined size of all volumes.
+// If cfg is nil, the default configuration is used for extraction. If an error occurs, it
+// is returned.
+func UnpackVolumes(ctx context.Context, t Target, dst string, volumes []io.Reader, cfg *Config) error {
+	if cfg == nil {
+		cfg = NewConfig()
+	}
+	return unpackAtomic(t, dst, cfg, func(t Target, dst string, cfg *Config) error {
+		return unpackVolumes(ctx, t, dst, volumes, cfg)
+	})
+}
+
+// unpackVolumes unpacks volumes to dst on t, according to cfg.
+func unpackVolumes(ctx context.Context, t Target, dst string, volumes []io.Reader, cfg *Config) error {
+	if cfg.SignatureVerifier() != nil {
+		return fmt.Errorf("%w: not supported for multi-volume archives", ErrSignatureVerificationFailed)
+	}
+	vr, err := newVolumeReader(cfg, volumes)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToUnpack, err)
+	}
+	defer vr.Close()
+
+	// read the header of the first volume
+	header := make([]byte, len(magicBytesRar[1]))
+	n, err := vr.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("%w: %w", ErrFailedToReadHeader, err)
+	}
+	header = header[:n]
+
+	switch et := cfg.ExtractType(); {
+	case et == fileExtensionRar || len(et) == 0 && isRar(header):
+		err = unpackRarVolumes(ctx, t, dst, &readerVolumesFS{volumes: vr.volumes, names: map[string]int{}}, "archive.part1.rar", cfg)
+	case et == fileExtensionZip || len(et) == 0 && (isZip(header) || isSpannedZip(header)):
+		var zr *volumeReader
+		if zr, err = joinSpannedZip(vr, cfg); err == nil {
+			err = unpackZip(ctx, t, dst, zr, cfg)
+		}
+	default:
+		return unpackTo(ctx, t, dst, vr, cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToUnpack, err)
+	}
+	return nil
+}
+
+// UnpackVolumesFS unpacks the multi-volume archive, which first volume is name in fsys, to
+// the destination, according to the given configuration, using the given [Target]. The
+// following volumes are opened from fsys by their names, e.g. archive.part2.rar follows
+// archive.part1.rar, archive.r00 follows archive.rar, archive.7z.002 follows archive.7z.001,
+// and archive.z01, archive.z02, ... are followed by archive.zip. Otherwise, it behaves like
+// [UnpackVolumes].
+func UnpackVolumesFS(ctx context.Context, t Target, dst string, fsys fs.FS, name string, cfg *Config) error {
+	if cfg == nil {
+		cfg = NewConfig()
+	}
+	return unpackAtomic(t, dst, cfg, func(t Target, dst string, cfg *Config) error {
+		return unpackVolumesFS(ctx, t, dst, fsys, name, cfg)
+	})
+}
+
+// unpackVolumesFS unpacks the volumes, which start with name in fsys, to dst on t, according to cfg.
+func unpackVolumesFS(ctx context.Context, t Target, dst string, fsys fs.FS, name string, cfg *Config) error {
+	if cfg.SignatureVerifier() != nil {
+		return fmt.Errorf("%w: not supported for multi-volume archives", ErrSignatureVerificationFailed)
+	}
+
+	// rar archives open their volumes by themselves
+	header, err := readVolumeHeader(fsys, name, len(magicBytesRar[1]))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToReadHeader, err)
+	}
+	if et := cfg.ExtractType(); et == fileExtensionRar || len(et) == 0 && isRar(header) {
+		if err := unpackRarVolumes(ctx, t, dst, fsys, name, cfg); err != nil {
+			return fmt.Errorf("%w: %w", ErrFailedToUnpack, err)
+		}
+		return nil
+	}
+
+	// open all volumes
+	names, err := volumeNames(fsys, name)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToUnpack, err)
+	}
+	volumes := make([]io.Reader, 0, len(names))
+	for _, n := range names {
+		f, err := fsys.Open(n)
+		if err != nil {
+			return fmt.Errorf("%w: cannot open volume: %w", ErrFailedToUnpack, err)
+		}
+		defer f.Close()
+		volumes = append(volumes, f)
+	}
+	return unpackVolumes(ctx, t, dst, volumes, cfg)
+}
+
+// readVolumeHeader reads the first n bytes of the volume name in fsys.
+func readVolumeHeader(fsys fs.FS, name string, n int) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	header := make([]byte, n)
+	m, err := io.ReadFull(f, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return header[:m], nil
+}
+
+var (
+	// volumeNumberPattern matches the number of split volumes, e.g. archive.7z.001.
+	volumeNumberPattern = regexp.MustCompile(`^(.*\.)(\d+)$`)
+
+	// zipVolumePattern matches the volumes of split or spanned zip archives, e.g. archive.z01.
+	zipVolumePattern = regexp.MustCompile(`^(.*\.)[zZ](\d{2,})$`)
+)
+
+// volumeNames returns the names of the volumes in fsys, which belong to the split or spanned
+// archive with the first volume name.
+func volumeNames(fsys fs.FS, name string) ([]string, error) {
+	// next returns the name of the following volume, which number has the same width
+	next := func(prefix, number string) (string, string) {
+		n, _ := strconv.Atoi(number)
+		number = fmt.Sprintf("%0*d", len(number), n+1)
+		return prefix + number, number
+	}
+	// collect adds the following volumes of name, which exist in fsys
+	collect := func(names []string, prefix, number string) ([]string, error) {
+		for {
+			var name string
+			name, number = next(prefix, number)
+			if _, err := fs.Stat(fsys, name); err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return names, nil
+				}
+				return nil, err
+			}
+			names = append(names, name)
+		}
+	}
+
+	// zip volumes are followed by the .zip file
+	if path.Ext(name) == ".zip" {
+		first := name[:len(name)-len("zip")] + "z01"
+		if _, err := fs.Stat(fsys, first); err != nil {
+			return []string{name}, nil
+		}
+		name = first
+	}
+	if m := zipVolumePattern.FindStringSubmatch(name); m != nil {
+		names, err := collect([]string{name}, m[1]+name[len(m[1]):len(m[1])+1], m[2])
+		if err != nil {
+			return nil, err
+		}
+		return append(names, m[1]+"zip"), nil
+	}
+
+	if m := volumeNumberPattern.FindStringSubmatch(name); m != nil {
+		return collect([]string{name}, m[1], m[2])
+	}
+	return []string{name}, nil
+}
+
+// unpackRarVolumes extracts the multi-volume Rar archive, which first volume is name in fsys,
+// to dst.
+func unpackRarVolumes(ctx context.Context, t Target, dst string, fsys fs.FS, name string, cfg *Config) error {
+	// prepare telemetry data collection and emit
+	td := &TelemetryData{ExtractedType: fileExtensionRar}
+	defer cfg.TelemetryHook()(ctx, td)
+	defer captureExtractionDuration(td, now())
+
+	// log extraction
+	cfg.Logger().Info("extracting rar volumes")
+
+	// prepare decryption
+	opts, hasPassword, err := rarOptions(cfg)
+	if err != nil {
+		return handleError(cfg, td, "cannot get password", err)
+	}
+
+	// open volumes, while the combined input size is checked
+	vfs := &volumeFS{fsys: fsys, maxInputSize: cfg.MaxInputSize(), sizes: map[string]int64{}}
+	defer func() { td.InputSize = vfs.size }()
+	a, err := rardecode.OpenReader(name, append(opts, rardecode.FileSystem(vfs))...)
+	if err != nil {
+		return handleError(cfg, td, "cannot create rar decoder", rarError(err))
+	}
+	defer a.Close()
+	input := func() int64 { return vfs.size }
+	return extract(ctx, t, dst, &rarWalker{r: &a.Reader, hasPassword: hasPassword, volumes: true}, cfg.withCompressedInput(input), td)
+}
+
+// volumeFS wraps the file system with the volumes of a multi-volume archive and checks, if
+// the combined size of the opened volumes exceeds the maximum input size.
+type volumeFS struct {
+	fsys         fs.FS
+	maxInputSize int64
+	sizes        map[string]int64
+	size         int64
+}
+
+// Open opens the volume name and adds its size to the combined size, if it is opened the
+// first time.
+func (v *volumeFS) Open(name string) (fs.File, error) {
+	f, err := v.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.sizes[name]; ok {
+		return f, nil
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	v.sizes[name] = fi.Size()
+	v.size += fi.Size()
+	if v.maxInputSize != -1 && v.size > v.maxInputSize {
+		f.Close()
+		return nil, fmt.Errorf("input size exceeds maximum input size")
+	}
+	return f, nil
+}
+
+// readerVolumesFS is a file system, which serves the volumes of a multi-volume archive in
+// the order, in which their names are opened first.
+type readerVolumesFS struct {
+	volumes []*io.SectionReader
+	names   map[string]int
+}
+
+// Open returns the volume, which belongs to name. If name is opened the first time, it
+// belongs to the next volume.
+func (v *readerVolumesFS) Open(name string) (fs.File, error) {
+	i, ok := v.names[name]
+	if !ok {
+		if len(v.names) == len(v.volumes) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		i = len(v.names)
+		v.names[name] = i
+	}
+	r := v.volumes[i]
+	return &volumeFile{SectionReader: io.NewSectionReader(r, 0, r.Size()), name: name}, nil
+}
+
+// volumeFile is a volume, which is served by readerVolumesFS.
+type volumeFile struct {
+	*io.SectionReader
+	name string
+}
+
+// Stat returns the file info of the volume.
+func (f *volumeFile) Stat() (fs.FileInfo, error) {
+	return &volumeFileInfo{name: path.Base(f.name), size: f.Size()}, nil
+}
+
+// Close does nothing, the volumes are closed by their owner.
+func (f *volumeFile) Close() error {
+	return nil
+}
+
+// volumeFileInfo is the file info of a volumeFile.
+type volumeFileInfo struct {
+	name string
+	size int64
+}
+
+// Name returns the name of the volume.
+func (fi *volumeFileInfo) Name() string {
+	return fi.name
+}
+
+// Size returns the size of the volume.
+func (fi *volumeFileInfo) Size() int64 {
+	return fi.size
+}
+
+// Mode returns the mode of a regular file.
+func (fi *volumeFileInfo) Mode() fs.FileMode {
+	return 0
+}
+
+// ModTime returns the zero time.
+func (fi *volumeFileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+// IsDir returns false.
+func (fi *volumeFileInfo) IsDir() bool {
+	return false
+}
+
+// Sys returns nil.
+func (fi *volumeFileInfo) Sys() interface{} {
+	return nil
+}
+
+// volumeReader concatenates the volumes of a multi-volume archive to one reader, which
+// implements io.ReaderAt and io.Seeker.
+type volumeReader struct {
+	volumes []*io.SectionReader
+	offsets []int64
+	size    int64
+	off     int64
+	cleanup []func()
+}
+
+// newVolumeReader converts the volumes with readerToReaderAtSeeker and concatenates them. The
+// combined size of the volumes must not exceed the maximum input size.
+func newVolumeReader(cfg *Config, volumes []io.Reader) (*volumeReader, error) {
+	if len(volumes) == 0 {
+		return nil, fmt.Errorf("no volumes")
+	}
+	vr := &volumeReader{}
+	for i, v := range volumes {
+		// limit the cached input to the remaining input size
+		if _, ok := v.(io.Seeker); !ok && cfg.MaxInputSize() != -1 {
+			v = newLimitErrorReader(v, cfg.MaxInputSize()-vr.size)
+		}
+		sra, cleanup, err := cacheInput(cfg, v)
+		if err != nil {
+			vr.Close()
+			return nil, fmt.Errorf("cannot cache volume %d: %w", i+1, err)
+		}
+		vr.cleanup = append(vr.cleanup, cleanup)
+		size, err := sra.Seek(0, io.SeekEnd)
+		if err != nil {
+			vr.Close()
+			return nil, fmt.Errorf("cannot seek to end of volume %d: %w", i+1, err)
+		}
+		vr.volumes = append(vr.volumes, io.NewSectionReader(sra, 0, size))
+		vr.offsets = append(vr.offsets, vr.size)
+		vr.size += size
+		if cfg.MaxInputSize() != -1 && vr.size > cfg.MaxInputSize() {
+			vr.Close()
+			return nil, fmt.Errorf("input size exceeds maximum input size")
+		}
+	}
+	return vr, nil
+}
+
+// concatVolumes concatenates the parts to one volumeReader, which does not own the parts.
+func concatVolumes(parts ...*io.SectionReader) *volumeReader {
+	vr := &volumeReader{}
+	for _, p := range parts {
+		vr.volumes = append(vr.volumes, p)
+		vr.offsets = append(vr.offsets, vr.size)
+		vr.size += p.Size()
+	}
+	return vr
+}
+
+// ReadAt reads len(p) bytes from the concatenated volumes starting at off.
+func (v *volumeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+	var n int
+	i := sort.Search(len(v.volumes), func(i int) bool { return v.offsets[i]+v.volumes[i].Size() > off })
+	for ; i < len(v.volumes) && n < len(p); i++ {
+		m, err := v.volumes[i].ReadAt(p[n:], off+int64(n)-v.offsets[i])
+		n += m
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Read reads from the concatenated volumes at the current offset.
+func (v *volumeReader) Read(p []byte) (int, error) {
+	if v.off >= v.size {
+		return 0, io.EOF
+	}
+	n, err := v.ReadAt(p, v.off)
+	v.off += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek sets the offset for the next Read.
+func (v *volumeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += v.off
+	case io.SeekEnd:
+		offset += v.size
+	default:
+		return 0, fmt.Errorf("invalid whence")
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position")
+	}
+	v.off = offset
+	return offset, nil
+}
+
+// Close removes the cached volumes, if any.
+func (v *volumeReader) Close() error {
+	for _, cleanup := range v.cleanup {
+		cleanup()
+	}
+	v.cleanup = nil
+	return nil
+}
+
+const (
+	// zipDirectoryHeaderLength is the length of a central directory header without its
+	// variable fields.
+	zipDirectoryHeaderLength = 46
+
+	// zipDirectoryEndLength is the length of the end of central directory record without
+	// its comment.
+	zipDirectoryEndLength = 22
+)
+
+var (
+	// zipSpanningSignatures are the signatures at the start of the first volume of split or
+	// spanned zip archives.
+	zipSpanningSignatures = [][]byte{
+		{0x50, 0x4B, 0x07, 0x08}, // split or spanned archive
+		{0x50, 0x4B, 0x30, 0x30}, // split archive, which fits into one volume
+	}
+
+	// zipDirectoryHeaderSignature is the signature of a central directory header.
+	zipDirectoryHeaderSignature = []byte{0x50, 0x4B, 0x01, 0x02}
+
+	// zipDirectoryEndSignature is the signature of the end of central directory record.
+	zipDirectoryEndSignature = []byte{0x50, 0x4B, 0x05, 0x06}
+)
+
+// isSpannedZip checks if the header matches the signature of split or spanned zip archives.
+func isSpannedZip(data []byte) bool {
+	return matchesMagicBytes(data, 0, zipSpanningSignatures)
+}
+
+// joinSpannedZip returns the zip archive in vr, which central directory refers to the offsets
+// in the concatenated volumes instead of the offsets in the volumes of a split or spanned zip
+// archive. The central directory is rewritten in memory. If vr is not a split or spanned zip
+// archive, vr is returned unchanged. The central directory must be located within vr and must
+// not exceed the maximum input size. Split or spanned zip64 archives are not supported.
+func joinSpannedZip(vr *volumeReader, cfg *Config) (*volumeReader, error) {
+	// find end of central directory record in last volume
+	last := vr.volumes[len(vr.volumes)-1]
+	buf := make([]byte, min(last.Size(), zipDirectoryEndLength+0xffff))
+	if _, err := last.ReadAt(buf, last.Size()-int64(len(buf))); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("cannot read end of central directory: %w", err)
+	}
+	pos := bytes.LastIndex(buf, zipDirectoryEndSignature)
+	if pos < 0 || len(buf)-pos < zipDirectoryEndLength {
+		return vr, nil
+	}
+	end := bytes.Clone(buf[pos:])
+	disk := binary.LittleEndian.Uint16(end[4:])
+	directoryDisk := binary.LittleEndian.Uint16(end[6:])
+	entries := binary.LittleEndian.Uint16(end[10:])
+	directorySize := binary.LittleEndian.Uint32(end[12:])
+	directoryOffset := binary.LittleEndian.Uint32(end[16:])
+	if disk == 0 {
+		return vr, nil
+	}
+	if disk == 0xffff || entries == 0xffff || directorySize == 0xffffffff || directoryOffset == 0xffffffff {
+		return nil, fmt.Errorf("split or spanned zip64 archives are not supported")
+	}
+	if int(disk) != len(vr.volumes)-1 {
+		return nil, fmt.Errorf("zip archive has %d volumes, got %d", disk+1, len(vr.volumes))
+	}
+	if directoryDisk > disk {
+		return nil, fmt.Errorf("invalid central directory volume %d", directoryDisk+1)
+	}
+
+	// read central directory and replace the volume numbers and offsets of its headers
+	start := vr.offsets[directoryDisk] + int64(directoryOffset)
+	if start < 0 || start+int64(directorySize) > vr.size {
+		return nil, fmt.Errorf("central directory exceeds volumes")
+	}
+	if cfg.MaxInputSize() != -1 && int64(directorySize) > cfg.MaxInputSize() {
+		return nil, fmt.Errorf("central directory size exceeds maximum input size")
+	}
+	directory := make([]byte, directorySize)
+	if _, err := vr.ReadAt(directory, start); err != nil {
+		return nil, fmt.Errorf("cannot read central directory: %w", err)
+	}
+	for h, i := directory, 0; i < int(entries); i++ {
+		if len(h) < zipDirectoryHeaderLength || !bytes.Equal(h[:4], zipDirectoryHeaderSignature) {
+			return nil, fmt.Errorf("invalid central directory header %d", i+1)
+		}
+		headerDisk := binary.LittleEndian.Uint16(h[34:])
+		headerOffset := binary.LittleEndian.Uint32(h[42:])
+		if headerDisk == 0xffff || headerOffset == 0xffffffff {
+			return nil, fmt.Errorf("split or spanned zip64 archives are not supported")
+		}
+		if headerDisk > disk {
+			return nil, fmt.Errorf("invalid volume %d of central directory header %d", headerDisk+1, i+1)
+		}
+		offset := vr.offsets[headerDisk] + int64(headerOffset)
+		if offset > 0xffffffff {
+			return nil, fmt.Errorf("split or spanned zip64 archives are not supported")
+		}
+		binary.LittleEndian.PutUint16(h[34:], 0)
+		binary.LittleEndian.PutUint32(h[42:], uint32(offset))
+		length := zipDirectoryHeaderLength + int(binary.LittleEndian.Uint16(h[28:])) + int(binary.LittleEndian.Uint16(h[30:])) + int(binary.LittleEndian.Uint16(h[32:]))
+		if len(h) < length {
+			return nil, fmt.Errorf("invalid central directory header %d", i+1)
+		}
+		h = h[length:]
+	}
+	if start > 0xffffffff {
+		return nil, fmt.Errorf("split or spanned zip64 archives are not supported")
+	}
+
+	// replace the volume numbers and offset of the end of central directory record
+	binary.LittleEndian.PutUint16(end[4:], 0)
+	binary.LittleEndian.PutUint16(end[6:], 0)
+	binary.LittleEndian.PutUint16(end[8:], entries)
+	binary.LittleEndian.PutUint32(end[16:], uint32(start))
+
+	directory = append(directory, end...)
+	return concatVolumes(
+		io.NewSectionReader(vr, 0, start),
+		io.NewSectionReader(bytes.NewReader(directory), 0, int64(len(directory))),
+	), nil
+}
@@ -0,0 +1,436 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"runtime"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestUnpackVolumes(t *testing.T) {
+	contents := []archiveContent{
+		{Name: "test", Content: []byte("hello world"), Mode: 0644},
+		{Name: "empty", Mode: 0644},
+		{Name: "long", Content: bytes.Repeat([]byte("hello volumes "), 20), Mode: 0644},
+	}
+	expected := map[string]string{"test": "hello world", "empty": "", "long": string(contents[2].Content)}
+	sevenZip := pack7z(t, nil)
+	spannedZip := packSpannedZip(t, contents, 100)
+
+	tests := []struct {
+		name          string
+		volumes       [][]byte
+		cfg           *extract.Config
+		expected      map[string]string
+		expectedType  string
+		expectedError error
+	}{
+		{
+			name:         "split 7zip",
+			volumes:      splitVolumes(sevenZip, 64),
+			expected:     map[string]string{"test": "hello world\n", "dir/entry": "hello world\n"},
+			expectedType: "7z",
+		},
+		{
+			name:         "split zip",
+			volumes:      splitVolumes(packZip(t, contents), 100),
+			expected:     expected,
+			expectedType: "zip",
+		},
+		{
+			name:         "spanned zip",
+			volumes:      spannedZip,
+			expected:     expected,
+			expectedType: "zip",
+		},
+		{
+			name:         "spanned zip in one volume",
+			volumes:      packSpannedZip(t, contents, 1<<20),
+			expected:     expected,
+			expectedType: "zip",
+		},
+		{
+			name:         "multi-volume rar",
+			volumes:      packRarVolumes(t, contents, 100),
+			expected:     expected,
+			expectedType: "rar",
+		},
+		{
+			name:         "split tar.gz",
+			volumes:      splitVolumes(compressGzip(t, packTar(t, contents)), 50),
+			expected:     expected,
+			expectedType: "tar.gz",
+		},
+		{
+			name:         "single volume",
+			volumes:      [][]byte{packZip(t, contents)},
+			expected:     expected,
+			expectedType: "zip",
+		},
+		{
+			name:          "no volumes",
+			expectedError: extract.ErrFailedToUnpack,
+		},
+		{
+			name:          "missing zip volume",
+			volumes:       append(spannedZip[:1:1], spannedZip[2:]...),
+			expectedError: extract.ErrFailedToUnpack,
+		},
+		{
+			name:          "spanned zip with oversized central directory",
+			volumes:       patchSpannedZipDirectory(spannedZip, 0xfffffff0, -1),
+			expectedError: extract.ErrFailedToUnpack,
+		},
+		{
+			name:          "spanned zip with central directory beyond volumes",
+			volumes:       patchSpannedZipDirectory(spannedZip, -1, 0xfffffff0),
+			expectedError: extract.ErrFailedToUnpack,
+		},
+		{
+			name:          "combined size exceeds max input size",
+			volumes:       splitVolumes(sevenZip, 64),
+			cfg:           extract.NewConfig(extract.WithMaxInputSize(int64(len(sevenZip) - 1))),
+			expectedError: extract.ErrFailedToUnpack,
+		},
+		{
+			name:          "rar exceeds max input size",
+			volumes:       packRarVolumes(t, contents, 100),
+			cfg:           extract.NewConfig(extract.WithMaxInputSize(200)),
+			expectedError: extract.ErrFailedToUnpack,
+		},
+	}
+
+	for _, tc := range tests {
+		for _, cacheFunction := range []func(*testing.T, []byte) io.Reader{asIoReader, asFileReader} {
+			t.Run(tc.name, func(t *testing.T) {
+				var td *extract.TelemetryData
+				cfg := tc.cfg
+				if cfg == nil {
+					cfg = extract.NewConfig()
+				}
+				extract.WithTelemetryHook(func(_ context.Context, d *extract.TelemetryData) { td = d })(cfg)
+
+				var volumes []io.Reader
+				for _, v := range tc.volumes {
+					volumes = append(volumes, cacheFunction(t, v))
+				}
+				tm := extract.NewTargetMemory()
+				err := extract.UnpackVolumes(context.Background(), tm, "", volumes, cfg)
+				if tc.expectedError != nil {
+					if !errors.Is(err, tc.expectedError) {
+						t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				checkMemoryContent(t, tm, tc.expected)
+				if td == nil || td.ExtractedType != tc.expectedType {
+					t.Errorf("expected extracted type %q, got %+v", tc.expectedType, td)
+				}
+			})
+		}
+	}
+}
+
+func TestUnpackVolumesFS(t *testing.T) {
+	contents := []archiveContent{
+		{Name: "test", Content: []byte("hello world"), Mode: 0644},
+		{Name: "long", Content: bytes.Repeat([]byte("hello volumes "), 20), Mode: 0644},
+	}
+	expected := map[string]string{"test": "hello world", "long": string(contents[1].Content)}
+
+	// volumesFS returns a file system, which contains the volumes with the names returned by name
+	volumesFS := func(volumes [][]byte, name func(i, n int) string) fstest.MapFS {
+		fsys := fstest.MapFS{"unrelated.txt": {Data: []byte("unrelated")}}
+		for i, v := range volumes {
+			fsys[name(i, len(volumes))] = &fstest.MapFile{Data: v}
+		}
+		return fsys
+	}
+	rarNames := func(i, _ int) string { return fmt.Sprintf("archive.part%d.rar", i+1) }
+	oldRarNames := func(i, _ int) string {
+		if i == 0 {
+			return "archive.rar"
+		}
+		return fmt.Sprintf("archive.r%02d", i-1)
+	}
+	zipNames := func(i, n int) string {
+		if i == n-1 {
+			return "archive.zip"
+		}
+		return fmt.Sprintf("archive.z%02d", i+1)
+	}
+	missingZip := volumesFS(packSpannedZip(t, contents, 100), zipNames)
+	delete(missingZip, "archive.zip")
+
+	tests := []struct {
+		name          string
+		fsys          fstest.MapFS
+		volume        string
+		cfg           *extract.Config
+		expected      map[string]string
+		expectedError error
+	}{
+		{
+			name:   "rar",
+			fsys:   volumesFS(packRarVolumes(t, contents, 100), rarNames),
+			volume: "archive.part1.rar",
+		},
+		{
+			name:   "rar with old volume names",
+			fsys:   volumesFS(packRarVolumes(t, contents, 100), oldRarNames),
+			volume: "archive.rar",
+		},
+		{
+			name:     "7zip",
+			fsys:     volumesFS(splitVolumes(pack7z(t, nil), 100), func(i, _ int) string { return fmt.Sprintf("dir/archive.7z.%03d", i+1) }),
+			volume:   "dir/archive.7z.001",
+			expected: map[string]string{"test": "hello world\n", "dir/entry": "hello world\n"},
+		},
+		{
+			name:   "zip",
+			fsys:   volumesFS(packSpannedZip(t, contents, 100), zipNames),
+			volume: "archive.zip",
+		},
+		{
+			name:   "zip from first volume",
+			fsys:   volumesFS(packSpannedZip(t, contents, 100), zipNames),
+			volume: "archive.z01",
+		},
+		{
+			name:   "single zip",
+			fsys:   volumesFS([][]byte{packZip(t, contents)}, zipNames),
+			volume: "archive.zip",
+		},
+		{
+			name:          "rar exceeds max input size",
+			fsys:          volumesFS(packRarVolumes(t, contents, 100), rarNames),
+			volume:        "archive.part1.rar",
+			cfg:           extract.NewConfig(extract.WithMaxInputSize(200)),
+			expectedError: extract.ErrFailedToUnpack,
+		},
+		{
+			name:          "missing zip",
+			fsys:          missingZip,
+			volume:        "archive.z01",
+			expectedError: extract.ErrFailedToUnpack,
+		},
+		{
+			name:          "missing first volume",
+			fsys:          fstest.MapFS{},
+			volume:        "archive.part1.rar",
+			expectedError: extract.ErrFailedToReadHeader,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var td *extract.TelemetryData
+			cfg := tc.cfg
+			if cfg == nil {
+				cfg = extract.NewConfig()
+			}
+			extract.WithTelemetryHook(func(_ context.Context, d *extract.TelemetryData) { td = d })(cfg)
+
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackVolumesFS(context.Background(), tm, "", tc.fsys, tc.volume, cfg)
+			if tc.expectedError != nil {
+				if !errors.Is(err, tc.expectedError) {
+					t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expected == nil {
+				tc.expected = expected
+			}
+			checkMemoryContent(t, tm, tc.expected)
+
+			// the input size is the combined size of the volumes
+			var size int64
+			for name, f := range tc.fsys {
+				if name != "unrelated.txt" {
+					size += int64(len(f.Data))
+				}
+			}
+			if td == nil || td.InputSize != size {
+				t.Errorf("expected input size %d, got %+v", size, td)
+			}
+		})
+	}
+}
+
+// splitVolumes splits b into volumes of volumeSize bytes.
+func splitVolumes(b []byte, volumeSize int) [][]byte {
+	var volumes [][]byte
+	for len(b) > volumeSize {
+		volumes = append(volumes, b[:volumeSize])
+		b = b[volumeSize:]
+	}
+	return append(volumes, b)
+}
+
+// packSpannedZip creates a spanned zip archive with the given content, which is split into
+// volumes of volumeSize bytes. The central directory is stored in the last volume.
+func packSpannedZip(t *testing.T, content []archiveContent, volumeSize int) [][]byte {
+	t.Helper()
+	data := append([]byte{0x50, 0x4b, 0x07, 0x08}, packZip(t, content)...)
+	end := bytes.LastIndex(data, []byte{0x50, 0x4b, 0x05, 0x06})
+	directory := int(binary.LittleEndian.Uint32(data[end+16:])) + 4
+
+	// locate returns the volume and the offset in the volume of offset
+	locate := func(offset int) (uint16, uint32) {
+		return uint16(offset / volumeSize), uint32(offset % volumeSize)
+	}
+
+	// replace the offsets in the central directory
+	for h := data[directory:end]; len(h) > 0; {
+		disk, offset := locate(int(binary.LittleEndian.Uint32(h[42:])) + 4)
+		binary.LittleEndian.PutUint16(h[34:], disk)
+		binary.LittleEndian.PutUint32(h[42:], offset)
+		h = h[46+int(binary.LittleEndian.Uint16(h[28:]))+int(binary.LittleEndian.Uint16(h[30:]))+int(binary.LittleEndian.Uint16(h[32:])):]
+	}
+	disk, offset := locate(directory)
+	binary.LittleEndian.PutUint16(data[end+4:], disk)
+	binary.LittleEndian.PutUint16(data[end+6:], disk)
+	binary.LittleEndian.PutUint32(data[end+16:], offset)
+
+	// the central directory is appended to the last volume or stored in a new volume
+	volumes := splitVolumes(data[:directory], volumeSize)
+	if directory%volumeSize == 0 {
+		return append(volumes, data[directory:])
+	}
+	volumes[len(volumes)-1] = append(bytes.Clone(volumes[len(volumes)-1]), data[directory:]...)
+	return volumes
+}
+
+// TestUnpackSpannedZipOversizedDirectory tests that the size of the central directory of a
+// spanned zip archive is checked before it is read into memory.
+func TestUnpackSpannedZipOversizedDirectory(t *testing.T) {
+	volumes := patchSpannedZipDirectory(packSpannedZip(t, []archiveContent{
+		{Name: "test", Content: bytes.Repeat([]byte("hello volumes "), 20), Mode: 0644},
+	}, 100), 0xfffffff0, -1)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	var readers []io.Reader
+	for _, v := range volumes {
+		readers = append(readers, bytes.NewReader(v))
+	}
+	cfg := extract.NewConfig(extract.WithMaxInputSize(-1))
+	err := extract.UnpackVolumes(context.Background(), extract.NewTargetMemory(), "", readers, cfg)
+	runtime.ReadMemStats(&after)
+	if !errors.Is(err, extract.ErrFailedToUnpack) {
+		t.Fatalf("expected error %v, got %v", extract.ErrFailedToUnpack, err)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<24 {
+		t.Errorf("expected central directory not to be allocated, got %d allocated bytes", allocated)
+	}
+}
+
+// patchSpannedZipDirectory returns a copy of the volumes of a spanned zip archive with the
+// size and offset of the central directory replaced, unless they are negative.
+func patchSpannedZipDirectory(volumes [][]byte, size int64, offset int64) [][]byte {
+	volumes = slices.Clone(volumes)
+	last := bytes.Clone(volumes[len(volumes)-1])
+	end := bytes.LastIndex(last, []byte{0x50, 0x4b, 0x05, 0x06})
+	if size >= 0 {
+		binary.LittleEndian.PutUint32(last[end+12:], uint32(size))
+	}
+	if offset >= 0 {
+		binary.LittleEndian.PutUint32(last[end+16:], uint32(offset))
+	}
+	volumes[len(volumes)-1] = last
+	return volumes
+}
+
+// packRarVolumes creates a multi-volume rar 5 archive with the given content, which is
+// stored uncompressed. A volume is closed, when it exceeds volumeSize bytes, and the
+// content of a file is split across the volumes.
+func packRarVolumes(t *testing.T, content []archiveContent, volumeSize int) [][]byte {
+	t.Helper()
+	vint := func(b []byte, v uint64) []byte {
+		return binary.AppendUvarint(b, v)
+	}
+	var volumes [][]byte
+	var b *bytes.Buffer
+	block := func(body []byte) {
+		header := vint(nil, uint64(len(body)))
+		header = append(header, body...)
+		if err := binary.Write(b, binary.LittleEndian, crc32.ChecksumIEEE(header)); err != nil {
+			t.Fatalf("error writing header crc: %v", err)
+		}
+		b.Write(header)
+	}
+
+	// nextVolume closes the current volume and starts the next one
+	nextVolume := func() {
+		if b != nil {
+			block([]byte{5, 0, 1}) // end of archive header, archive continues
+			volumes = append(volumes, b.Bytes())
+		}
+		b = bytes.NewBuffer([]byte{0x52, 0x61, 0x72, 0x21, 0x1a, 0x07, 0x01, 0x00})
+		if len(volumes) == 0 {
+			block([]byte{1, 0, 0x01}) // main archive header of the first volume
+			return
+		}
+		block(vint([]byte{1, 0, 0x01 | 0x02}, uint64(len(volumes))))
+	}
+	nextVolume()
+
+	for _, c := range content {
+		for written := 0; ; {
+			if b.Len() >= volumeSize {
+				nextVolume()
+			}
+			n := min(len(c.Content)-written, max(volumeSize-b.Len(), 1))
+			flags := uint64(0x02) // data area present
+			if written > 0 {
+				flags |= 0x08 // data continues from previous volume
+			}
+			if written+n < len(c.Content) {
+				flags |= 0x10 // data continues in next volume
+			}
+
+			// file header
+			fields := vint(nil, 0x04) // crc present
+			fields = vint(fields, uint64(len(c.Content)))
+			fields = vint(fields, uint64(0100000|c.Mode.Perm()))
+			fields = binary.LittleEndian.AppendUint32(fields, crc32.ChecksumIEEE(c.Content))
+			fields = vint(fields, 0) // stored
+			fields = vint(fields, 1) // unix
+			fields = vint(fields, uint64(len(c.Name)))
+			fields = append(fields, c.Name...)
+			body := vint(nil, 2) // file header
+			body = vint(body, flags)
+			body = vint(body, uint64(n))
+			block(append(body, fields...))
+			b.Write(c.Content[written : written+n])
+
+			written += n
+			if written == len(c.Content) {
+				break
+			}
+		}
+	}
+
+	// end of archive header of the last volume
+	block([]byte{5, 0, 0})
+	return append(volumes, b.Bytes())
+}
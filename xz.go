@@ -29,6 +29,11 @@ func unpackXz(ctx context.Context, t Target, dst string, src io.Reader, cfg *Con
 	return decompress(ctx, t, dst, src, cfg, decompressXzStream, fileExtensionXz)
 }
 
+// walkXz opens the xz compressed src and returns a Walker for the decompressed content.
+func walkXz(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return walkDecompressed(ctx, src, cfg, decompressXzStream, fileExtensionXz)
+}
+
 // decompressZlibStream returns an io.Reader that decompresses src with xz algorithm
 func decompressXzStream(src io.Reader) (io.Reader, error) {
 	return xz.NewReader(src)
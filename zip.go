@@ -75,18 +75,79 @@ func processZip(ctx context.Context, t Target, src seekerReaderAt, dst string, c
 		return handleError(cfg, m, "cannot unpack zip", fmt.Errorf("input size exceeds maximum input size"))
 	}
 
-	// create zip reader and extract
+	// create zip reader
 	reader, err := zip.NewReader(ra, size)
 	if err != nil {
 		return handleError(cfg, m, "cannot create zip reader", err)
 	}
-	return extract(ctx, t, dst, &zipWalker{zr: reader}, cfg, m)
+
+	// validate entries, problems are only logged if the extraction continues on error
+	problems, err := validateZip(ra, size, reader.File)
+	if err != nil {
+		return handleError(cfg, m, "cannot validate zip archive", err)
+	}
+	for _, problem := range problems {
+		if err := handleError(cfg, m, "invalid zip archive", problem); err != nil {
+			return err
+		}
+	}
+
+	// extract entries
+	return extract(ctx, t, dst, &zipWalker{zr: reader, cfg: cfg}, cfg, m)
+}
+
+// walkZip returns a Walker for the zip archive in src. If src is not a
+// readerAt and a seeker, src is cached according to the configuration.
+func walkZip(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	sra, cleanup, err := cacheInput(cfg, src)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert reader to readerAt and seeker: %w", err)
+	}
+
+	// check input size
+	size, err := checkInputSize(cfg, sra)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	// create zip reader
+	reader, err := zip.NewReader(sra, size)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("cannot create zip reader: %w", err)
+	}
+
+	// validate entries, problems are only logged if the walk continues on error
+	problems, err := validateZip(sra, size, reader.File)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("cannot validate zip archive: %w", err)
+	}
+	for _, problem := range problems {
+		if !cfg.ContinueOnError() {
+			cleanup()
+			return nil, fmt.Errorf("invalid zip archive: %w", problem)
+		}
+		cfg.Logger().Error("invalid zip archive", "error", problem)
+	}
+	return &zipWalker{zr: reader, cfg: cfg, cleanup: cleanup}, nil
 }
 
 // zipWalker is a walker for zip files
 type zipWalker struct {
-	zr *zip.Reader
-	fp int
+	zr      *zip.Reader
+	cfg     *Config
+	fp      int
+	cleanup func()
+}
+
+// Close removes the cached input, if any.
+func (z *zipWalker) Close() error {
+	if z.cleanup != nil {
+		z.cleanup()
+	}
+	return nil
 }
 
 // Type returns the file extension for zip files
@@ -95,17 +156,29 @@ func (z zipWalker) Type() string {
 }
 
 // Next returns the next entry in the zip archive
-func (z *zipWalker) Next() (archiveEntry, error) {
+func (z *zipWalker) Next() (Entry, error) {
 	if z.fp >= len(z.zr.File) {
 		return nil, io.EOF
 	}
 	defer func() { z.fp++ }()
-	return &zipEntry{z.zr.File[z.fp]}, nil
+	return &zipEntry{zf: z.zr.File[z.fp], cfg: z.cfg}, nil
 }
 
+// metadata keys of entries in zip archives
+const (
+	// MetadataZipEncrypted is the metadata key, which indicates if a zip entry is encrypted ("true")
+	// or not ("false")
+	MetadataZipEncrypted = "zip.encrypted"
+
+	// MetadataZipEncryption is the metadata key for the encryption method of an encrypted zip
+	// entry (zipcrypto, aes128, aes192 or aes256)
+	MetadataZipEncryption = "zip.encryption"
+)
+
 // zipEntry is an entry in a zip archive
 type zipEntry struct {
-	zf *zip.File
+	zf  *zip.File
+	cfg *Config
 }
 
 // Name returns the name of the entry
@@ -118,6 +191,11 @@ func (z *zipEntry) Size() int64 {
 	return int64(z.zf.FileHeader.UncompressedSize64)
 }
 
+// compressedSize returns the compressed size of the entry
+func (z *zipEntry) compressedSize() int64 {
+	return int64(z.zf.FileHeader.CompressedSize64)
+}
+
 // Mode returns the mode of the entry
 func (z *zipEntry) Mode() os.FileMode {
 	return z.zf.FileHeader.Mode()
@@ -125,7 +203,10 @@ func (z *zipEntry) Mode() os.FileMode {
 
 // Linkname returns the linkname of the entry
 func (z *zipEntry) Linkname() string {
-	rc, _ := z.zf.Open()
+	rc, err := z.Open()
+	if err != nil {
+		return ""
+	}
 	defer func() { rc.Close() }()
 	data, _ := io.ReadAll(rc)
 	return string(data)
@@ -141,14 +222,48 @@ func (z *zipEntry) IsDir() bool {
 	return z.zf.FileHeader.Mode().Type() == os.ModeDir
 }
 
+// IsHardlink returns always false, because zip archives do not support hard links
+func (z *zipEntry) IsHardlink() bool {
+	return false
+}
+
 // IsSymlink returns true if the entry is a symlink
 func (z *zipEntry) IsSymlink() bool {
 	return z.zf.FileHeader.Mode().Type() == os.ModeSymlink
 }
 
-// Open returns a reader for the entry
+// Open returns a reader for the entry. Encrypted entries are decrypted with the
+// password of the configured [PasswordProvider]. The reader returns [ErrZipSizeMismatch],
+// if the content does not match the declared size.
 func (z *zipEntry) Open() (io.ReadCloser, error) {
-	return z.zf.Open()
+	if z.zf.Flags&zipFlagEncrypted == 0 {
+		rc, err := z.zf.Open()
+		if err != nil || z.IsDir() {
+			return rc, err
+		}
+		return &zipSizeReader{ReadCloser: rc, name: z.Name(), size: z.zf.UncompressedSize64}, nil
+	}
+	password, err := z.cfg.password(z.Name())
+	if err != nil {
+		return nil, err
+	}
+	rc, err := openEncryptedZip(z.zf, password)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt %s: %w", z.Name(), err)
+	}
+	return &zipSizeReader{ReadCloser: rc, name: z.Name(), size: z.zf.UncompressedSize64}, nil
+}
+
+// Metadata returns if the entry is encrypted and the encryption method
+func (z *zipEntry) Metadata() map[string]string {
+	if z.zf.Flags&zipFlagEncrypted == 0 {
+		return map[string]string{MetadataZipEncrypted: "false"}
+	}
+	m := map[string]string{MetadataZipEncrypted: "true"}
+	if encryption := zipEncryption(z.zf); encryption != "" {
+		m[MetadataZipEncryption] = encryption
+	}
+	return m
 }
 
 // Type returns the type of the entry
@@ -0,0 +1,352 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	// zipFlagEncrypted is the general purpose flag of encrypted entries
+	zipFlagEncrypted = 0x1
+
+	// zipFlagDataDescriptor is the general purpose flag of entries with a data descriptor
+	zipFlagDataDescriptor = 0x8
+
+	// zipMethodAES is the compression method of WinZip AES encrypted entries
+	zipMethodAES = 99
+
+	// zipExtraAES is the id of the WinZip AES extra field
+	zipExtraAES = 0x9901
+
+	// zipCryptoHeaderLength is the length of the encryption header of ZipCrypto entries
+	zipCryptoHeaderLength = 12
+
+	// zipAESVerifierLength is the length of the password verifier of WinZip AES entries
+	zipAESVerifierLength = 2
+
+	// zipAESAuthCodeLength is the length of the authentication code of WinZip AES entries
+	zipAESAuthCodeLength = 10
+
+	// zipAESIterations is the number of PBKDF2 iterations to derive WinZip AES keys
+	zipAESIterations = 1000
+)
+
+// names of the encryption methods of zip entries
+const (
+	zipEncryptionZipCrypto = "zipcrypto"
+	zipEncryptionAES128    = "aes128"
+	zipEncryptionAES192    = "aes192"
+	zipEncryptionAES256    = "aes256"
+)
+
+// zipAESExtra is the decoded WinZip AES extra field of an entry
+//
+// https://www.winzip.com/en/support/aes-encryption/
+type zipAESExtra struct {
+	version  uint16 // 1 (AE-1) or 2 (AE-2)
+	strength byte   // 1 (128 bit), 2 (192 bit) or 3 (256 bit)
+	method   uint16 // actual compression method
+}
+
+// zipEncryption returns the name of the encryption method of f, or an empty string
+// if f is not encrypted.
+func zipEncryption(f *zip.File) string {
+	if f.Flags&zipFlagEncrypted == 0 {
+		return ""
+	}
+	if f.Method != zipMethodAES {
+		return zipEncryptionZipCrypto
+	}
+	extra, err := readZipAESExtra(f.Extra)
+	if err != nil {
+		return ""
+	}
+	return []string{zipEncryptionAES128, zipEncryptionAES192, zipEncryptionAES256}[extra.strength-1]
+}
+
+// readZipAESExtra reads the WinZip AES extra field from the extra fields in extra.
+func readZipAESExtra(extra []byte) (*zipAESExtra, error) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if 4+size > len(extra) {
+			break
+		}
+		if id == zipExtraAES {
+			data := extra[4 : 4+size]
+			if size != 7 || !bytes.Equal(data[2:4], []byte("AE")) {
+				return nil, fmt.Errorf("%w: invalid aes extra field", ErrCorruptData)
+			}
+			e := &zipAESExtra{
+				version:  binary.LittleEndian.Uint16(data[0:2]),
+				strength: data[4],
+				method:   binary.LittleEndian.Uint16(data[5:7]),
+			}
+			if e.version != 1 && e.version != 2 {
+				return nil, fmt.Errorf("%w: unsupported aes version %d", ErrCorruptData, e.version)
+			}
+			if e.strength < 1 || e.strength > 3 {
+				return nil, fmt.Errorf("%w: unsupported aes strength %d", ErrCorruptData, e.strength)
+			}
+			return e, nil
+		}
+		extra = extra[4+size:]
+	}
+	return nil, fmt.Errorf("%w: missing aes extra field", ErrCorruptData)
+}
+
+// openEncryptedZip returns a reader for the decrypted and decompressed content of the
+// encrypted entry f. A [ErrWrongPassword] error is returned, if the password verification
+// fails, and a [ErrCorruptData] error is returned, if the checksum or authentication
+// code of the content does not match.
+func openEncryptedZip(f *zip.File, password string) (io.ReadCloser, error) {
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+	if f.Method == zipMethodAES {
+		return openZipAES(f, raw, password)
+	}
+	return openZipCrypto(f, raw, password)
+}
+
+// openZipCrypto returns a reader for the content of the ZipCrypto encrypted entry f,
+// which raw content is read from raw.
+//
+// https://pkwaredownloads.blob.core.windows.net/pem/APPNOTE.txt (section 6.1)
+func openZipCrypto(f *zip.File, raw io.Reader, password string) (io.ReadCloser, error) {
+	if f.CompressedSize64 < zipCryptoHeaderLength {
+		return nil, fmt.Errorf("%w: missing encryption header", ErrCorruptData)
+	}
+
+	// decrypt encryption header
+	keys := newZipCryptoKeys(password)
+	header := make([]byte, zipCryptoHeaderLength)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, err
+	}
+	keys.decrypt(header)
+
+	// the last byte of the header is the high order byte of the crc, or of the
+	// modification time if the entry has a data descriptor
+	check := byte(f.CRC32 >> 24)
+	if f.Flags&zipFlagDataDescriptor != 0 {
+		check = byte(f.ModifiedTime >> 8)
+	}
+	if header[zipCryptoHeaderLength-1] != check {
+		return nil, ErrWrongPassword
+	}
+
+	decrypted := &zipCryptoReader{src: io.LimitReader(raw, int64(f.CompressedSize64-zipCryptoHeaderLength)), keys: keys}
+	return newZipChecksumReader(decrypted, f.Method, f.CRC32, true)
+}
+
+// zipCryptoKeys are the keys of the traditional PKWARE encryption
+type zipCryptoKeys [3]uint32
+
+// newZipCryptoKeys returns the keys initialized with password
+func newZipCryptoKeys(password string) *zipCryptoKeys {
+	k := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		k.update(password[i])
+	}
+	return k
+}
+
+// update updates the keys with the plain text byte b
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32.IEEETable[byte(k[0])^b] ^ (k[0] >> 8)
+	k[1] = (k[1]+k[0]&0xff)*134775813 + 1
+	k[2] = crc32.IEEETable[byte(k[2])^byte(k[1]>>24)] ^ (k[2] >> 8)
+}
+
+// decrypt decrypts buf in place
+func (k *zipCryptoKeys) decrypt(buf []byte) {
+	for i := range buf {
+		t := k[2] | 2
+		buf[i] ^= byte((t * (t ^ 1)) >> 8)
+		k.update(buf[i])
+	}
+}
+
+// zipCryptoReader decrypts the content of a ZipCrypto encrypted entry
+type zipCryptoReader struct {
+	src  io.Reader
+	keys *zipCryptoKeys
+}
+
+// Read reads and decrypts the content
+func (z *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := z.src.Read(p)
+	z.keys.decrypt(p[:n])
+	return n, err
+}
+
+// openZipAES returns a reader for the content of the WinZip AES encrypted entry f,
+// which raw content is read from raw.
+//
+// https://www.winzip.com/en/support/aes-encryption/
+func openZipAES(f *zip.File, raw io.Reader, password string) (io.ReadCloser, error) {
+	extra, err := readZipAESExtra(f.Extra)
+	if err != nil {
+		return nil, err
+	}
+	keyLength := 8 + 8*int(extra.strength)
+	saltLength := keyLength / 2
+	overhead := uint64(saltLength + zipAESVerifierLength + zipAESAuthCodeLength)
+	if f.CompressedSize64 < overhead {
+		return nil, fmt.Errorf("%w: missing encryption header", ErrCorruptData)
+	}
+
+	// derive keys and verify password
+	header := make([]byte, saltLength+zipAESVerifierLength)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, err
+	}
+	keys, err := pbkdf2.Key(sha1.New, password, header[:saltLength], zipAESIterations, 2*keyLength+zipAESVerifierLength)
+	if err != nil {
+		return nil, fmt.Errorf("cannot derive key: %w", err)
+	}
+	if !hmac.Equal(keys[2*keyLength:], header[saltLength:]) {
+		return nil, ErrWrongPassword
+	}
+	block, err := aes.NewCipher(keys[:keyLength])
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cipher: %w", err)
+	}
+
+	decrypted := &zipAESReader{
+		src:    io.LimitReader(raw, int64(f.CompressedSize64-overhead)),
+		raw:    raw,
+		stream: &zipAESStream{block: block, pos: aes.BlockSize},
+		mac:    hmac.New(sha1.New, keys[keyLength:2*keyLength]),
+	}
+
+	// AE-2 entries do not store the crc, because the content is authenticated
+	return newZipChecksumReader(decrypted, extra.method, f.CRC32, extra.version == 1)
+}
+
+// zipAESStream is the AES counter mode used by WinZip, which increments the
+// counter in little endian byte order and starts with 1.
+type zipAESStream struct {
+	block   cipher.Block
+	counter [aes.BlockSize]byte
+	key     [aes.BlockSize]byte
+	pos     int
+}
+
+// XORKeyStream xors each byte in src with a byte of the key stream and writes it to dst
+func (s *zipAESStream) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.pos == aes.BlockSize {
+			for j := range s.counter {
+				s.counter[j]++
+				if s.counter[j] != 0 {
+					break
+				}
+			}
+			s.block.Encrypt(s.key[:], s.counter[:])
+			s.pos = 0
+		}
+		dst[i] = src[i] ^ s.key[s.pos]
+		s.pos++
+	}
+}
+
+// zipAESReader decrypts the content of a WinZip AES encrypted entry and verifies the
+// authentication code after the content is read completely.
+type zipAESReader struct {
+	src    io.Reader
+	raw    io.Reader
+	stream cipher.Stream
+	mac    hash.Hash
+}
+
+// Read reads and decrypts the content
+func (z *zipAESReader) Read(p []byte) (int, error) {
+	n, err := z.src.Read(p)
+	z.mac.Write(p[:n])
+	z.stream.XORKeyStream(p[:n], p[:n])
+	if err == io.EOF {
+		authCode := make([]byte, zipAESAuthCodeLength)
+		if _, err := io.ReadFull(z.raw, authCode); err != nil {
+			return n, err
+		}
+		if !hmac.Equal(z.mac.Sum(nil)[:zipAESAuthCodeLength], authCode) {
+			return n, fmt.Errorf("%w: authentication code mismatch", ErrCorruptData)
+		}
+	}
+	return n, err
+}
+
+// zipChecksumReader decompresses the decrypted content of an entry and verifies the
+// crc after the content is read completely.
+type zipChecksumReader struct {
+	r         io.Reader
+	src       io.Reader
+	closer    io.Closer
+	hash      hash.Hash32
+	crc       uint32
+	verifyCRC bool
+}
+
+// newZipChecksumReader returns a reader, which decompresses src with the compression
+// method and verifies the crc, if verifyCRC is set.
+func newZipChecksumReader(src io.Reader, method uint16, crc uint32, verifyCRC bool) (io.ReadCloser, error) {
+	z := &zipChecksumReader{src: src, hash: crc32.NewIEEE(), crc: crc, verifyCRC: verifyCRC}
+	switch method {
+	case zip.Store:
+		z.r = src
+	case zip.Deflate:
+		fr := flate.NewReader(src)
+		z.r, z.closer = fr, fr
+	default:
+		return nil, fmt.Errorf("%w: %d", zip.ErrAlgorithm, method)
+	}
+	return z, nil
+}
+
+// Read reads the decompressed content
+func (z *zipChecksumReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	z.hash.Write(p[:n])
+	if err != io.EOF {
+		var corrupt flate.CorruptInputError
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &corrupt) {
+			err = fmt.Errorf("%w: %w", ErrCorruptData, err)
+		}
+		return n, err
+	}
+
+	// drain the decrypted content, which verifies the authentication code of aes entries
+	if _, err := io.Copy(io.Discard, z.src); err != nil {
+		return n, err
+	}
+	if z.verifyCRC && z.hash.Sum32() != z.crc {
+		return n, fmt.Errorf("%w: checksum mismatch", ErrCorruptData)
+	}
+	return n, io.EOF
+}
+
+// Close closes the decompressor
+func (z *zipChecksumReader) Close() error {
+	if z.closer != nil {
+		return z.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,557 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"testing"
+
+	"github.com/hashicorp/go-extract"
+)
+
+func TestUnpackEncryptedZip(t *testing.T) {
+	contents := []archiveContent{
+		{Name: "test", Content: []byte("hello world")},
+		{Name: "compressed", Content: bytes.Repeat([]byte("hello encrypted zip "), 100)},
+	}
+	expected := map[string]string{"test": "hello world", "compressed": string(contents[1].Content)}
+
+	tests := []struct {
+		name        string
+		src         []byte
+		cfg         *extract.Config
+		expectedErr error
+	}{
+		{
+			name: "zipcrypto",
+			src:  packEncryptedZip(t, zipEncryptionOptions{method: "zipcrypto", password: "secret"}, contents),
+			cfg:  extract.NewConfig(extract.WithPassword("secret")),
+		},
+		{
+			name: "zipcrypto with data descriptor",
+			src:  packEncryptedZip(t, zipEncryptionOptions{method: "zipcrypto", password: "secret", dataDescriptor: true}, contents),
+			cfg:  extract.NewConfig(extract.WithPassword("secret")),
+		},
+		{
+			name: "aes128 AE-1",
+			src:  packEncryptedZip(t, zipEncryptionOptions{method: "aes128", version: 1, password: "secret"}, contents),
+			cfg:  extract.NewConfig(extract.WithPassword("secret")),
+		},
+		{
+			name: "aes192 AE-2",
+			src:  packEncryptedZip(t, zipEncryptionOptions{method: "aes192", version: 2, password: "secret"}, contents),
+			cfg:  extract.NewConfig(extract.WithPassword("secret")),
+		},
+		{
+			name: "aes256 AE-2",
+			src:  packEncryptedZip(t, zipEncryptionOptions{method: "aes256", version: 2, password: "secret"}, contents),
+			cfg:  extract.NewConfig(extract.WithPassword("secret")),
+		},
+		{
+			name: "password provider",
+			src:  packEncryptedZip(t, zipEncryptionOptions{method: "aes256", version: 2, password: "secret"}, contents),
+			cfg: extract.NewConfig(extract.WithPasswordProvider(func(entryName string) (string, error) {
+				if entryName != "test" && entryName != "compressed" {
+					return "", fmt.Errorf("unexpected entry %q", entryName)
+				}
+				return "secret", nil
+			})),
+		},
+		{
+			name:        "password provider error",
+			src:         packEncryptedZip(t, zipEncryptionOptions{method: "aes256", version: 2, password: "secret"}, contents),
+			cfg:         extract.NewConfig(extract.WithPasswordProvider(func(string) (string, error) { return "", errCancelled })),
+			expectedErr: errCancelled,
+		},
+		{
+			name:        "password required",
+			src:         packEncryptedZip(t, zipEncryptionOptions{method: "zipcrypto", password: "secret"}, contents),
+			expectedErr: extract.ErrPasswordRequired,
+		},
+		{
+			name:        "zipcrypto wrong password",
+			src:         packEncryptedZip(t, zipEncryptionOptions{method: "zipcrypto", password: "secret"}, contents),
+			cfg:         extract.NewConfig(extract.WithPassword("wrong")),
+			expectedErr: extract.ErrWrongPassword,
+		},
+		{
+			name:        "aes wrong password",
+			src:         packEncryptedZip(t, zipEncryptionOptions{method: "aes256", version: 2, password: "secret"}, contents),
+			cfg:         extract.NewConfig(extract.WithPassword("wrong")),
+			expectedErr: extract.ErrWrongPassword,
+		},
+		{
+			name:        "zipcrypto corrupt data",
+			src:         packEncryptedZip(t, zipEncryptionOptions{method: "zipcrypto", password: "secret", store: true, corrupt: true}, contents),
+			cfg:         extract.NewConfig(extract.WithPassword("secret")),
+			expectedErr: extract.ErrCorruptData,
+		},
+		{
+			name:        "aes corrupt data",
+			src:         packEncryptedZip(t, zipEncryptionOptions{method: "aes128", version: 2, password: "secret", corrupt: true}, contents),
+			cfg:         extract.NewConfig(extract.WithPassword("secret")),
+			expectedErr: extract.ErrCorruptData,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			if cfg == nil {
+				cfg = extract.NewConfig()
+			}
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackTo(context.Background(), tm, "", asFileReader(t, tc.src), cfg)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkMemoryContent(t, tm, expected)
+		})
+	}
+}
+
+func TestListEncryptedZip(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      []byte
+		expected map[string]string
+	}{
+		{
+			name:     "not encrypted",
+			src:      packZip(t, []archiveContent{{Name: "test", Content: []byte("hello world")}}),
+			expected: map[string]string{extract.MetadataZipEncrypted: "false"},
+		},
+		{
+			name:     "zipcrypto",
+			src:      packEncryptedZip(t, zipEncryptionOptions{method: "zipcrypto", password: "secret"}, []archiveContent{{Name: "test", Content: []byte("hello world")}}),
+			expected: map[string]string{extract.MetadataZipEncrypted: "true", extract.MetadataZipEncryption: "zipcrypto"},
+		},
+		{
+			name:     "aes256",
+			src:      packEncryptedZip(t, zipEncryptionOptions{method: "aes256", version: 2, password: "secret"}, []archiveContent{{Name: "test", Content: []byte("hello world")}}),
+			expected: map[string]string{extract.MetadataZipEncrypted: "true", extract.MetadataZipEncryption: "aes256"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// listing does not require a password
+			entries, err := extract.List(context.Background(), asIoReader(t, tc.src), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 entry, got %d", len(entries))
+			}
+			if len(entries[0].Metadata) != len(tc.expected) {
+				t.Errorf("expected metadata %v, got %v", tc.expected, entries[0].Metadata)
+			}
+			for k, v := range tc.expected {
+				if entries[0].Metadata[k] != v {
+					t.Errorf("expected metadata %q to be %q, got %q", k, v, entries[0].Metadata[k])
+				}
+			}
+		})
+	}
+}
+
+// errCancelled is returned by password providers in tests
+var errCancelled = errors.New("cancelled")
+
+// zipEncryptionOptions are the options to create an encrypted zip archive
+type zipEncryptionOptions struct {
+	// method is the encryption method (zipcrypto, aes128, aes192, aes256)
+	method string
+
+	// version is the WinZip AES version (1 or 2)
+	version uint16
+
+	// password is the password to encrypt the entries
+	password string
+
+	// store disables the compression of the entries
+	store bool
+
+	// dataDescriptor sets the data descriptor flag of the entries
+	dataDescriptor bool
+
+	// corrupt flips the last byte of the encrypted content of the entries
+	corrupt bool
+}
+
+// packEncryptedZip creates a zip archive with the given content, which entries are
+// encrypted according to opts. The salt and encryption header are deterministic.
+func packEncryptedZip(t *testing.T, opts zipEncryptionOptions, content []archiveContent) []byte {
+	t.Helper()
+	b := new(bytes.Buffer)
+	w := zip.NewWriter(b)
+	for _, c := range content {
+		// compress content
+		method := zip.Deflate
+		data := c.Content
+		if opts.store {
+			method = zip.Store
+		} else {
+			cb := new(bytes.Buffer)
+			fw, _ := flate.NewWriter(cb, flate.BestCompression)
+			if _, err := fw.Write(c.Content); err != nil {
+				t.Fatalf("error compressing data: %v", err)
+			}
+			if err := fw.Close(); err != nil {
+				t.Fatalf("error compressing data: %v", err)
+			}
+			data = cb.Bytes()
+		}
+
+		h := &zip.FileHeader{
+			Name:               c.Name,
+			Method:             method,
+			Flags:              0x1,
+			CRC32:              crc32.ChecksumIEEE(c.Content),
+			UncompressedSize64: uint64(len(c.Content)),
+			ModifiedTime:       3<<11 | 4<<5 | 6>>1, // 03:04:06 in ms-dos format
+			ModifiedDate:       44<<9 | 1<<5 | 2,    // 2024-01-02 in ms-dos format
+		}
+		h.SetMode(0644)
+		if opts.dataDescriptor {
+			h.Flags |= 0x8
+		}
+
+		var encrypted []byte
+		switch opts.method {
+		case "zipcrypto":
+			keys := [3]uint32{0x12345678, 0x23456789, 0x34567890}
+			update := func(b byte) {
+				keys[0] = crc32.IEEETable[byte(keys[0])^b] ^ (keys[0] >> 8)
+				keys[1] = (keys[1]+keys[0]&0xff)*134775813 + 1
+				keys[2] = crc32.IEEETable[byte(keys[2])^byte(keys[1]>>24)] ^ (keys[2] >> 8)
+			}
+			for i := 0; i < len(opts.password); i++ {
+				update(opts.password[i])
+			}
+			header := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, byte(h.CRC32 >> 24)}
+			if opts.dataDescriptor {
+				header[11] = byte(h.ModifiedTime >> 8)
+			}
+			for _, p := range append(header, data...) {
+				k := keys[2] | 2
+				encrypted = append(encrypted, p^byte((k*(k^1))>>8))
+				update(p)
+			}
+		case "aes128", "aes192", "aes256":
+			strength := map[string]int{"aes128": 1, "aes192": 2, "aes256": 3}[opts.method]
+			keyLength := 8 + 8*strength
+			salt := bytes.Repeat([]byte{0x42}, keyLength/2)
+			keys, err := pbkdf2.Key(sha1.New, opts.password, salt, 1000, 2*keyLength+2)
+			if err != nil {
+				t.Fatalf("error deriving key: %v", err)
+			}
+			block, err := aes.NewCipher(keys[:keyLength])
+			if err != nil {
+				t.Fatalf("error creating cipher: %v", err)
+			}
+			var counter, stream [aes.BlockSize]byte
+			cipherText := make([]byte, len(data))
+			for i := range data {
+				if i%aes.BlockSize == 0 {
+					binary.LittleEndian.PutUint64(counter[:], uint64(i/aes.BlockSize+1))
+					block.Encrypt(stream[:], counter[:])
+				}
+				cipherText[i] = data[i] ^ stream[i%aes.BlockSize]
+			}
+			mac := hmac.New(sha1.New, keys[keyLength:2*keyLength])
+			mac.Write(cipherText)
+			encrypted = append(append(append(salt, keys[2*keyLength:]...), cipherText...), mac.Sum(nil)[:10]...)
+
+			h.Extra = binary.LittleEndian.AppendUint16(nil, 0x9901)
+			h.Extra = binary.LittleEndian.AppendUint16(h.Extra, 7)
+			h.Extra = binary.LittleEndian.AppendUint16(h.Extra, opts.version)
+			h.Extra = append(h.Extra, 'A', 'E', byte(strength))
+			h.Extra = binary.LittleEndian.AppendUint16(h.Extra, method)
+			h.Method = 99
+			if opts.version == 2 {
+				h.CRC32 = 0
+			}
+		default:
+			t.Fatalf("unsupported encryption method: %s", opts.method)
+		}
+		if opts.corrupt {
+			position := len(encrypted) - 1
+			if opts.method != "zipcrypto" {
+				position -= 10
+			}
+			encrypted[position] ^= 0xff
+		}
+
+		h.CompressedSize64 = uint64(len(encrypted))
+		f, err := w.CreateRaw(h)
+		if err != nil {
+			t.Fatalf("error creating zip header: %v", err)
+		}
+		if _, err := f.Write(encrypted); err != nil {
+			t.Fatalf("error writing zip data: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("error closing zip writer: %v", err)
+	}
+	return b.Bytes()
+}
+
+func TestZipValidation(t *testing.T) {
+	valid := packZip(t, []archiveContent{
+		{Name: "a", Content: []byte("hello a"), Mode: 0644},
+		{Name: "b", Content: []byte("hello b"), Mode: 0644},
+	})
+
+	// local name of the first entry differs from the name in the central directory
+	nameMismatch := bytes.Clone(valid)
+	nameMismatch[30] = 'x'
+
+	// compressed data of the first entry extends into the local file header of the second entry
+	overlapping := bytes.Clone(valid)
+	directory := bytes.Index(overlapping, []byte{0x50, 0x4B, 0x01, 0x02})
+	binary.LittleEndian.PutUint32(overlapping[directory+20:], binary.LittleEndian.Uint32(overlapping[directory+20:])+100)
+
+	// uncompressed size of the first entry in the local header differs from the central directory
+	sizeMismatch := packRawZip(t, []rawZipEntry{{name: "a", data: []byte("hello a"), size: 7}})
+	binary.LittleEndian.PutUint32(sizeMismatch[22:], 1000)
+
+	tests := []struct {
+		name        string
+		src         []byte
+		expected    map[string]string
+		expectedErr error
+	}{
+		{
+			name:     "valid archive",
+			src:      valid,
+			expected: map[string]string{"a": "hello a", "b": "hello b"},
+		},
+		{
+			name:        "overlapping entries",
+			src:         overlapping,
+			expectedErr: extract.ErrZipOverlappingEntries,
+		},
+		{
+			name:        "overlapping entries with the same name",
+			src:         appendZipDirectoryEntry(t, valid, "a"),
+			expectedErr: extract.ErrZipDuplicateEntry,
+		},
+		{
+			name:        "overlapping entries with different names",
+			src:         appendZipDirectoryEntry(t, valid, "c"),
+			expectedErr: extract.ErrZipNameMismatch,
+		},
+		{
+			name: "duplicate names",
+			src: packZip(t, []archiveContent{
+				{Name: "a", Content: []byte("hello a"), Mode: 0644},
+				{Name: "a", Content: []byte("hello evil"), Mode: 0644},
+			}),
+			expectedErr: extract.ErrZipDuplicateEntry,
+		},
+		{
+			name: "duplicate names with the same content",
+			src: packZip(t, []archiveContent{
+				{Name: "a", Content: []byte("hello a"), Mode: 0644},
+				{Name: "a", Content: []byte("hello a"), Mode: 0644},
+			}),
+			expectedErr: extract.ErrZipDuplicateEntry,
+		},
+		{
+			name:        "name mismatch",
+			src:         nameMismatch,
+			expectedErr: extract.ErrZipNameMismatch,
+		},
+		{
+			name:        "size mismatch between local header and central directory",
+			src:         sizeMismatch,
+			expectedErr: extract.ErrZipSizeMismatch,
+		},
+		{
+			name:        "declared size larger than content",
+			src:         packRawZip(t, []rawZipEntry{{name: "a", data: []byte("hello a"), size: 100}}),
+			expectedErr: extract.ErrZipSizeMismatch,
+		},
+		{
+			name:        "declared size smaller than content",
+			src:         packRawZip(t, []rawZipEntry{{name: "a", data: compressFlate(t, []byte("hello a")), size: 3, method: zip.Deflate}}),
+			expectedErr: extract.ErrZipSizeMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tm := extract.NewTargetMemory()
+			err := extract.UnpackTo(context.Background(), tm, "", bytes.NewReader(tc.src), extract.NewConfig())
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+				}
+
+				// problems are reported as errors, if the extraction continues on error
+				var td *extract.TelemetryData
+				cfg := extract.NewConfig(
+					extract.WithContinueOnError(true),
+					extract.WithTelemetryHook(func(_ context.Context, d *extract.TelemetryData) { td = d }),
+				)
+				if err := extract.UnpackTo(context.Background(), extract.NewTargetMemory(), "", bytes.NewReader(tc.src), cfg); err != nil {
+					t.Fatalf("unexpected error with continue on error: %v", err)
+				}
+				if td.ExtractionErrors == 0 {
+					t.Errorf("expected extraction errors with continue on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkMemoryContent(t, tm, tc.expected)
+		})
+	}
+}
+
+func TestListZipValidation(t *testing.T) {
+	valid := packZip(t, []archiveContent{{Name: "a", Content: []byte("hello a"), Mode: 0644}})
+	tests := []struct {
+		name        string
+		src         []byte
+		expectedErr error
+	}{
+		{
+			name:        "overlapping entries with different names",
+			src:         appendZipDirectoryEntry(t, valid, "b"),
+			expectedErr: extract.ErrZipNameMismatch,
+		},
+		{
+			name: "duplicate names",
+			src: packZip(t, []archiveContent{
+				{Name: "a", Content: []byte("hello a"), Mode: 0644},
+				{Name: "a", Content: []byte("hello a"), Mode: 0644},
+			}),
+			expectedErr: extract.ErrZipDuplicateEntry,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := extract.List(ctx, bytes.NewReader(tc.src), nil); !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected list error %v, got %v", tc.expectedErr, err)
+			}
+			var walkErr error
+			for _, err := range extract.Walk(ctx, bytes.NewReader(tc.src), nil) {
+				if err != nil {
+					walkErr = err
+					break
+				}
+			}
+			if !errors.Is(walkErr, tc.expectedErr) {
+				t.Errorf("expected walk error %v, got %v", tc.expectedErr, walkErr)
+			}
+			if _, err := extract.Test(ctx, bytes.NewReader(tc.src), nil); !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected test error %v, got %v", tc.expectedErr, err)
+			}
+
+			// problems are logged, if the listing continues on error
+			entries, err := extract.List(ctx, bytes.NewReader(tc.src), extract.NewConfig(extract.WithContinueOnError(true)))
+			if err != nil || len(entries) != 2 {
+				t.Errorf("expected 2 entries with continue on error, got %d (%v)", len(entries), err)
+			}
+		})
+	}
+}
+
+// rawZipEntry is an entry with raw content for packRawZip
+type rawZipEntry struct {
+	name   string
+	data   []byte
+	size   uint64
+	method uint16
+}
+
+// packRawZip creates a zip archive with entries, which raw content is written without
+// compression and which declare the given uncompressed size.
+func packRawZip(t *testing.T, entries []rawZipEntry) []byte {
+	t.Helper()
+	b := new(bytes.Buffer)
+	w := zip.NewWriter(b)
+	for _, e := range entries {
+		h := &zip.FileHeader{
+			Name:               e.name,
+			Method:             e.method,
+			CompressedSize64:   uint64(len(e.data)),
+			UncompressedSize64: e.size,
+		}
+		h.SetMode(0644)
+		f, err := w.CreateRaw(h)
+		if err != nil {
+			t.Fatalf("error creating zip header: %v", err)
+		}
+		if _, err := f.Write(e.data); err != nil {
+			t.Fatalf("error writing zip data: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("error closing zip writer: %v", err)
+	}
+	return b.Bytes()
+}
+
+// compressFlate returns data compressed with deflate
+func compressFlate(t *testing.T, data []byte) []byte {
+	t.Helper()
+	b := new(bytes.Buffer)
+	fw, _ := flate.NewWriter(b, flate.BestCompression)
+	if _, err := fw.Write(data); err != nil {
+		t.Fatalf("error compressing data: %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("error compressing data: %v", err)
+	}
+	return b.Bytes()
+}
+
+// appendZipDirectoryEntry appends a copy of the first central directory header of the zip archive
+// b with the given name, so that the new entry refers to the local file header of the first entry.
+func appendZipDirectoryEntry(t *testing.T, b []byte, name string) []byte {
+	t.Helper()
+	end := bytes.LastIndex(b, []byte{0x50, 0x4B, 0x05, 0x06})
+	if end < 0 {
+		t.Fatalf("end of central directory not found")
+	}
+	start := int(binary.LittleEndian.Uint32(b[end+16:]))
+	first := b[start:]
+
+	// copy header with new name and without extra field and comment
+	h := append(bytes.Clone(first[:46]), name...)
+	binary.LittleEndian.PutUint16(h[28:], uint16(len(name)))
+	binary.LittleEndian.PutUint16(h[30:], 0)
+	binary.LittleEndian.PutUint16(h[32:], 0)
+
+	// insert header and update end of central directory
+	eocd := bytes.Clone(b[end:])
+	binary.LittleEndian.PutUint16(eocd[8:], binary.LittleEndian.Uint16(eocd[8:])+1)
+	binary.LittleEndian.PutUint16(eocd[10:], binary.LittleEndian.Uint16(eocd[10:])+1)
+	binary.LittleEndian.PutUint32(eocd[12:], binary.LittleEndian.Uint32(eocd[12:])+uint32(len(h)))
+	return append(append(bytes.Clone(b[:end]), h...), eocd...)
+}
@@ -0,0 +1,292 @@
+// Copyright IBM Corp. 2023, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package extract
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+)
+
+const (
+	// zipLocalHeaderLength is the length of a local file header without its variable fields
+	zipLocalHeaderLength = 30
+
+	// zipDirectory64LocatorLength is the length of the zip64 end of central directory locator
+	zipDirectory64LocatorLength = 20
+
+	// zipDirectory64EndLength is the length of the zip64 end of central directory record
+	zipDirectory64EndLength = 56
+
+	// zipExtraZip64 is the id of the zip64 extended information extra field
+	zipExtraZip64 = 0x0001
+
+	// zipMaxUint32 indicates that the actual value is stored in the zip64 extra field
+	zipMaxUint32 = 0xffffffff
+)
+
+var (
+	// zipLocalHeaderSignature is the signature of a local file header.
+	zipLocalHeaderSignature = []byte{0x50, 0x4B, 0x03, 0x04}
+
+	// zipDirectory64LocatorSignature is the signature of the zip64 end of central directory locator.
+	zipDirectory64LocatorSignature = []byte{0x50, 0x4B, 0x06, 0x07}
+
+	// zipDirectory64EndSignature is the signature of the zip64 end of central directory record.
+	zipDirectory64EndSignature = []byte{0x50, 0x4B, 0x06, 0x06}
+)
+
+// zipRecord is the header of an entry, as it is stored in the central directory or in the
+// local file header.
+type zipRecord struct {
+	name             []byte
+	flags            uint16
+	compressedSize   uint64
+	uncompressedSize uint64
+	headerOffset     int64
+}
+
+// zipRange is the range of an entry in a zip archive, from the start of its local file header
+// to the end of its compressed data.
+type zipRange struct {
+	name       string
+	start, end int64
+}
+
+// validateZip checks the zip archive in ra, which central directory lists the files, for entries,
+// which overlap each other or the central directory, for duplicate names and for names and sizes,
+// which differ between the local file header and the central directory. All problems are
+// returned as errors, which wrap [ErrZipOverlappingEntries], [ErrZipDuplicateEntry],
+// [ErrZipNameMismatch] or [ErrZipSizeMismatch]. The second return value is set, if the archive
+// cannot be read.
+func validateZip(ra io.ReaderAt, size int64, files []*zip.File) ([]error, error) {
+	records, directoryOffset, err := readZipDirectory(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read central directory: %w", err)
+	}
+	if len(records) != len(files) {
+		return nil, fmt.Errorf("central directory lists %d entries, got %d", len(files), len(records))
+	}
+
+	var problems []error
+	names := make(map[string]struct{}, len(files))
+	ranges := make([]zipRange, 0, len(files))
+	for i, f := range files {
+		cd := records[i]
+
+		// check for duplicate names, which are ambiguous
+		name := path.Clean(f.Name)
+		if _, found := names[name]; found {
+			problems = append(problems, fmt.Errorf("%w: %s", ErrZipDuplicateEntry, f.Name))
+		}
+		names[name] = struct{}{}
+
+		// read local file header, a missing header is reported when the entry is opened
+		local, dataOffset, err := readZipLocalHeader(ra, size, cd.headerOffset)
+		if err != nil {
+			continue
+		}
+
+		// compare local file header and central directory
+		if !bytes.Equal(local.name, cd.name) {
+			problems = append(problems, fmt.Errorf("%w: %s has the local name %q", ErrZipNameMismatch, f.Name, local.name))
+		}
+		if local.flags&zipFlagDataDescriptor == 0 && (local.compressedSize != cd.compressedSize || local.uncompressedSize != cd.uncompressedSize) {
+			problems = append(problems, fmt.Errorf("%w: %s has %d/%d bytes in the local header and %d/%d bytes in the central directory",
+				ErrZipSizeMismatch, f.Name, local.compressedSize, local.uncompressedSize, cd.compressedSize, cd.uncompressedSize))
+		}
+		ranges = append(ranges, zipRange{name: f.Name, start: cd.headerOffset, end: dataOffset + int64(cd.compressedSize)})
+	}
+
+	// check for entries, which overlap each other or the central directory
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	var last zipRange
+	for i, r := range ranges {
+		if i > 0 && r.start < last.end {
+			problems = append(problems, fmt.Errorf("%w: %s overlaps %s", ErrZipOverlappingEntries, r.name, last.name))
+		}
+		if r.end > directoryOffset {
+			problems = append(problems, fmt.Errorf("%w: %s overlaps the central directory", ErrZipOverlappingEntries, r.name))
+		}
+		if i == 0 || r.end > last.end {
+			last = r
+		}
+	}
+	return problems, nil
+}
+
+// readZipDirectory reads the headers of the central directory of the zip archive in ra and
+// returns them together with the offset of the central directory. Like [zip.NewReader], the
+// offsets are adjusted, if data is prepended to the archive.
+func readZipDirectory(ra io.ReaderAt, size int64) ([]zipRecord, int64, error) {
+	// find end of central directory record
+	buf := make([]byte, min(size, zipDirectoryEndLength+0xffff))
+	if _, err := ra.ReadAt(buf, size-int64(len(buf))); err != nil && err != io.EOF {
+		return nil, 0, err
+	}
+	pos := bytes.LastIndex(buf, zipDirectoryEndSignature)
+	if pos < 0 || len(buf)-pos < zipDirectoryEndLength {
+		return nil, 0, fmt.Errorf("end of central directory not found")
+	}
+	endOffset := size - int64(len(buf)) + int64(pos)
+	end := buf[pos:]
+	directorySize := uint64(binary.LittleEndian.Uint32(end[12:]))
+	directoryOffset := uint64(binary.LittleEndian.Uint32(end[16:]))
+
+	// read zip64 end of central directory record
+	if directorySize == zipMaxUint32 || directoryOffset == zipMaxUint32 || binary.LittleEndian.Uint16(end[10:]) == 0xffff {
+		locator := make([]byte, zipDirectory64LocatorLength)
+		if endOffset >= zipDirectory64LocatorLength {
+			if _, err := ra.ReadAt(locator, endOffset-zipDirectory64LocatorLength); err != nil {
+				return nil, 0, err
+			}
+		}
+		if bytes.Equal(locator[:4], zipDirectory64LocatorSignature) {
+			endOffset = int64(binary.LittleEndian.Uint64(locator[8:]))
+			end64 := make([]byte, zipDirectory64EndLength)
+			if _, err := ra.ReadAt(end64, endOffset); err != nil {
+				return nil, 0, err
+			}
+			if !bytes.Equal(end64[:4], zipDirectory64EndSignature) {
+				return nil, 0, fmt.Errorf("invalid zip64 end of central directory")
+			}
+			directorySize = binary.LittleEndian.Uint64(end64[40:])
+			directoryOffset = binary.LittleEndian.Uint64(end64[48:])
+		}
+	}
+	if directorySize > uint64(size) || directoryOffset > uint64(size) {
+		return nil, 0, fmt.Errorf("invalid central directory")
+	}
+
+	// determine offset of prepended data, see zip.NewReader
+	baseOffset := endOffset - int64(directorySize) - int64(directoryOffset)
+	if o := baseOffset + int64(directoryOffset); o < 0 || o >= size {
+		return nil, 0, fmt.Errorf("invalid central directory")
+	}
+	if baseOffset > 0 {
+		sig := make([]byte, 4)
+		if _, err := ra.ReadAt(sig, int64(directoryOffset)); err == nil && bytes.Equal(sig, zipDirectoryHeaderSignature) {
+			baseOffset = 0
+		}
+	}
+
+	// read central directory headers until an invalid header is found, like zip.NewReader
+	start := baseOffset + int64(directoryOffset)
+	directory := bufio.NewReader(io.NewSectionReader(ra, start, size-start))
+	var records []zipRecord
+	h := make([]byte, zipDirectoryHeaderLength)
+	for {
+		if _, err := io.ReadFull(directory, h); err != nil || !bytes.Equal(h[:4], zipDirectoryHeaderSignature) {
+			break
+		}
+		nameLength := int(binary.LittleEndian.Uint16(h[28:]))
+		extraLength := int(binary.LittleEndian.Uint16(h[30:]))
+		v := make([]byte, nameLength+extraLength+int(binary.LittleEndian.Uint16(h[32:])))
+		if _, err := io.ReadFull(directory, v); err != nil {
+			break
+		}
+		r := zipRecord{
+			name:             v[:nameLength],
+			flags:            binary.LittleEndian.Uint16(h[8:]),
+			compressedSize:   uint64(binary.LittleEndian.Uint32(h[20:])),
+			uncompressedSize: uint64(binary.LittleEndian.Uint32(h[24:])),
+		}
+		offset := uint64(binary.LittleEndian.Uint32(h[42:]))
+		readZip64Extra(v[nameLength:nameLength+extraLength], &r.uncompressedSize, &r.compressedSize, &offset)
+		r.headerOffset = baseOffset + int64(offset)
+		records = append(records, r)
+	}
+	return records, start, nil
+}
+
+// readZipLocalHeader reads the local file header at offset and returns it together with the
+// offset of the compressed data.
+func readZipLocalHeader(ra io.ReaderAt, size int64, offset int64) (zipRecord, int64, error) {
+	if offset < 0 || offset+zipLocalHeaderLength > size {
+		return zipRecord{}, 0, io.ErrUnexpectedEOF
+	}
+	h := make([]byte, zipLocalHeaderLength)
+	if _, err := ra.ReadAt(h, offset); err != nil {
+		return zipRecord{}, 0, err
+	}
+	if !bytes.Equal(h[:4], zipLocalHeaderSignature) {
+		return zipRecord{}, 0, errors.New("invalid local file header")
+	}
+	nameLength := int64(binary.LittleEndian.Uint16(h[26:]))
+	extraLength := int64(binary.LittleEndian.Uint16(h[28:]))
+	dataOffset := offset + zipLocalHeaderLength + nameLength + extraLength
+	if dataOffset > size {
+		return zipRecord{}, 0, io.ErrUnexpectedEOF
+	}
+	v := make([]byte, nameLength+extraLength)
+	if _, err := ra.ReadAt(v, offset+zipLocalHeaderLength); err != nil {
+		return zipRecord{}, 0, err
+	}
+	r := zipRecord{
+		name:             v[:nameLength],
+		flags:            binary.LittleEndian.Uint16(h[6:]),
+		compressedSize:   uint64(binary.LittleEndian.Uint32(h[18:])),
+		uncompressedSize: uint64(binary.LittleEndian.Uint32(h[22:])),
+		headerOffset:     offset,
+	}
+	readZip64Extra(v[nameLength:], &r.uncompressedSize, &r.compressedSize)
+	return r, dataOffset, nil
+}
+
+// readZip64Extra reads the values of fields, which are set to zipMaxUint32, in their order from
+// the zip64 extended information extra field in extra.
+func readZip64Extra(extra []byte, fields ...*uint64) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		length := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+length {
+			return
+		}
+		data := extra[4 : 4+length]
+		extra = extra[4+length:]
+		if id != zipExtraZip64 {
+			continue
+		}
+		for _, f := range fields {
+			if *f != zipMaxUint32 {
+				continue
+			}
+			if len(data) < 8 {
+				return
+			}
+			*f = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		}
+		return
+	}
+}
+
+// zipSizeReader is a reader for the content of an entry, which returns [ErrZipSizeMismatch], if
+// the content is larger or smaller than the declared uncompressed size.
+type zipSizeReader struct {
+	io.ReadCloser
+	name string
+	size uint64
+	n    uint64
+}
+
+// Read reads from the underlying reader and checks the number of bytes read against the
+// declared uncompressed size.
+func (r *zipSizeReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += uint64(n)
+	switch {
+	case r.n > r.size, errors.Is(err, zip.ErrFormat): // zip.File.Open returns zip.ErrFormat for additional content
+		return n, fmt.Errorf("%w: %s is larger than %d bytes", ErrZipSizeMismatch, r.name, r.size)
+	case (err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF)) && r.n < r.size:
+		return n, fmt.Errorf("%w: %s has %d instead of %d bytes", ErrZipSizeMismatch, r.name, r.n, r.size)
+	}
+	return n, err
+}
@@ -35,6 +35,11 @@ func unpackZlib(ctx context.Context, t Target, dst string, src io.Reader, cfg *C
 	return decompress(ctx, t, dst, src, cfg, decompressZlibStream, fileExtensionZlib)
 }
 
+// walkZlib opens the zlib compressed src and returns a Walker for the decompressed content.
+func walkZlib(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return walkDecompressed(ctx, src, cfg, decompressZlibStream, fileExtensionZlib)
+}
+
 // decompressZlibStream returns an io.Reader that decompresses src with zlib algorithm
 func decompressZlibStream(src io.Reader) (io.Reader, error) {
 	return zlib.NewReader(src)
@@ -29,6 +29,11 @@ func unpackZstd(ctx context.Context, t Target, dst string, src io.Reader, cfg *C
 	return decompress(ctx, t, dst, src, cfg, decompressZstdStream, fileExtensionZstd)
 }
 
+// walkZstd opens the zstd compressed src and returns a Walker for the decompressed content.
+func walkZstd(ctx context.Context, src io.Reader, cfg *Config) (Walker, error) {
+	return walkDecompressed(ctx, src, cfg, decompressZstdStream, fileExtensionZstd)
+}
+
 // decompressZstdStream returns an io.Reader that decompresses src with zstandard algorithm
 func decompressZstdStream(src io.Reader) (io.Reader, error) {
 	return zstd.NewReader(src)